@@ -2,35 +2,278 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"goRunFiles/internal/app"
 	"goRunFiles/internal/config"
+	"goRunFiles/internal/control"
+	"goRunFiles/internal/process"
 )
 
 var buildVersion = generatedVersion
 
 func main() {
-	configPath := config.DefaultConfigName
-	if exePath, err := os.Executable(); err == nil {
-		exeConfig := filepath.Join(filepath.Dir(exePath), config.DefaultConfigName)
-		if _, err := os.Stat(exeConfig); err == nil {
-			configPath = exeConfig
-		}
+	os.Exit(runCommand(os.Args[1:]))
+}
+
+// runCommand dispatches "goRunFiles [command] [flags]". Without a command the
+// monitor runs as before.
+func runCommand(args []string) int {
+	name := "run"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+	switch name {
+	case "run":
+		return cmdRun(args)
+	case "config":
+		return cmdConfig(args)
+	case "validate":
+		return cmdValidate(args)
+	case "ctl":
+		return cmdCtl(args)
+	case "record":
+		return cmdRecord(args)
+	case "help", "-h", "--help":
+		printUsage()
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage()
+		return 2
+	}
+}
+
+func printUsage() {
+	fmt.Fprint(os.Stderr, `Usage:
+  goRunFiles [run] [-config path] [-control-addr host:port|off]
+                                       run the process monitor; ctl is off unless
+                                       settings.controlAddr or -control-addr is set
+  goRunFiles run [-config path] [-control-addr host:port] -dry-run
+                                       check processes but only log what would be started or killed;
+                                       ctl is off unless -control-addr is given
+  goRunFiles run [-config path] [-control-addr host:port] -simulate FILE
+                                       dry run against a recorded process table instead of the live one
+  goRunFiles record [-o FILE] [-every D] [-for D]
+                                       record the process table for -simulate, once or every D for D
+  goRunFiles validate [-config path]   check a config and list errors and warnings
+  goRunFiles config convert SRC DST    convert a config between ini/json/yaml/toml
+  goRunFiles ctl [-config path] [-addr host:port] profile [NAME|-]
+                                       list profiles or switch the running monitor
+  goRunFiles ctl [-config path] [-addr host:port] start|stop|restart [-parallel N] [-stagger D] all|NAME...|-group G
+                                       start, stop or restart processes and report each
+  goRunFiles ctl [-config path] [-addr host:port] rolling [-batch N] [-timeout D] [all|NAME...|-group G]
+                                       restart processes a batch at a time, each once running,
+                                       or show the progress of the last rolling restart
+  goRunFiles ctl [-config path] [-addr host:port] maintenance [off | -for D [-reason TEXT] all|NAME...|-group G]
+                                       leave processes alone for a while, end or list maintenances
+  goRunFiles ctl [-config path] [-addr host:port] groups
+                                       list process groups and their members
+  goRunFiles ctl [-config path] [-addr host:port] matches [all|NAME...|-group G]
+                                       show the PIDs the checks of processes match and exclude
+  goRunFiles ctl [-config path] [-addr host:port] instance
+                                       show which monitor answers: live or dry run, its pid and version
+`)
+}
+
+func cmdRun(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	configPath := fs.String("config", resolveConfigPath(), "config file (ini, json, yaml or toml)")
+	dryRun := fs.Bool("dry-run", false, "log starts and kills instead of doing them")
+	simulate := fs.String("simulate", "", "replay a process table recorded with \"goRunFiles record\" (implies -dry-run)")
+	controlAddr := fs.String("control-addr", "", "control address, overrides settings.controlAddr; off in a dry run unless given")
+	if err := fs.Parse(args); err != nil {
+		return 2
 	}
 
 	log.Print(config.Banner)
 
-	cfg, err := config.Load(configPath)
+	logf := func(format string, args ...any) {
+		log.Printf("%s %s", app.LogTag, fmt.Sprintf(format, args...))
+	}
+	switch {
+	case *simulate != "":
+		rec, err := process.LoadRecording(*simulate)
+		if err != nil {
+			log.Printf("%s [ART3D-CHEKER]: simulation: %v", app.LogTag, err)
+			return 1
+		}
+		process.Simulate(rec, logf)
+	case *dryRun:
+		process.DryRun(logf)
+	}
+
+	// A dry run leaves the config file alone.
+	cfg, err := app.LoadConfig(*configPath, log.Default())
 	if err != nil {
 		log.Printf("%s [ART3D-CHEKER]: Ошибка загрузки конфига: %v", app.LogTag, err)
-		return
+		return 1
+	}
+	for _, d := range config.Validate(cfg, app.ValidateOptions()) {
+		log.Printf("%s [ART3D-CHEKER]: config %s", app.LogTag, d)
+	}
+
+	switch {
+	case *controlAddr != "":
+		cfg.Settings.ControlAddr = *controlAddr
+	case process.SandboxMode() != "":
+		// The live monitor usually runs next to a dry run and keeps its
+		// control channel.
+		cfg.Settings.ControlAddr = "off"
 	}
 
+	ctx := context.Background()
 	application := app.New(cfg, log.Default(), buildVersion)
-	if err := application.Run(context.Background()); err != nil {
+	if mode := process.SandboxMode(); mode != "" {
+		// The state of the real monitor is neither used nor overwritten.
+		if report, err := application.Matches(app.Selector{All: true}); err == nil {
+			log.Printf("%s %s, processes found by the checks:\n%s", app.LogTag, mode, report)
+		}
+	} else if err := application.UseStateFile(config.StatePath(*configPath)); err != nil {
+		log.Printf("%s [ART3D-CHEKER]: state not restored: %v", app.LogTag, err)
+	}
+	go application.WatchConfig(ctx, *configPath)
+	go application.ServeControl(ctx)
+	if err := application.Run(ctx); err != nil {
 		log.Printf("%s [ART3D-CHEKER]: Приложение остановлено: %v", app.LogTag, err)
+		return 1
+	}
+	return 0
+}
+
+// cmdValidate prints every problem in the config and exits with 1 if any of
+// them is an error.
+func cmdValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	configPath := fs.String("config", resolveConfigPath(), "config file (ini, json, yaml or toml)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	ds := config.ValidateFile(*configPath, app.ValidateOptions())
+	errs := 0
+	for _, d := range ds {
+		if d.Severity == config.SeverityError {
+			errs++
+		}
+		file := *configPath
+		if d.File != "" {
+			file = config.IncludePath(*configPath, d.File)
+			d.File = ""
+		}
+		fmt.Printf("%s: %s\n", file, d)
+	}
+	fmt.Printf("%s: %d error(s), %d warning(s)\n", *configPath, errs, len(ds)-errs)
+	if errs > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cmdCtl sends a command to the running monitor. The address comes from
+// -addr or settings.controlAddr of the config.
+func cmdCtl(args []string) int {
+	fs := flag.NewFlagSet("ctl", flag.ContinueOnError)
+	configPath := fs.String("config", resolveConfigPath(), "config file used to find the control address")
+	addrFlag := fs.String("addr", "", "control address, overrides settings.controlAddr")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		printUsage()
+		return 2
+	}
+	addr := *addrFlag
+	if addr == "" {
+		configured := ""
+		if cfg, err := config.Load(*configPath); err == nil {
+			configured = cfg.Settings.ControlAddr
+		}
+		var ok bool
+		if addr, ok = control.Addr(configured); !ok {
+			fmt.Fprintf(os.Stderr, "ctl: control channel is off: set settings.controlAddr (e.g. %s) or pass -addr\n", control.SuggestedAddr)
+			return 1
+		}
+	}
+	resp, err := control.Call(addr, control.Request{Command: fs.Arg(0), Args: fs.Args()[1:]})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ctl: %v\n", err)
+		return 1
+	}
+	if resp.Output != "" {
+		fmt.Println(resp.Output)
+	}
+	if resp.Error != "" {
+		fmt.Fprintf(os.Stderr, "ctl: %s\n", resp.Error)
+		return 1
+	}
+	return 0
+}
+
+func cmdConfig(args []string) int {
+	if len(args) == 0 || args[0] != "convert" {
+		printUsage()
+		return 2
+	}
+	if len(args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: goRunFiles config convert SRC DST")
+		return 2
+	}
+	src, dst := args[1], args[2]
+	if err := config.ConvertFile(src, dst); err != nil {
+		fmt.Fprintf(os.Stderr, "convert: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%s (%s) -> %s (%s)\n", src, config.DetectFormat(src), dst, config.DetectFormat(dst))
+	return 0
+}
+
+// cmdRecord writes the live process table to a file for run -simulate:
+// one frame, or a frame every -every for -for.
+func cmdRecord(args []string) int {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	out := fs.String("o", "processes.json", "file to write")
+	every := fs.Duration("every", 5*time.Second, "time between frames")
+	total := fs.Duration("for", 0, "how long to record, one frame when 0")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *every <= 0 {
+		fmt.Fprintln(os.Stderr, "record: -every must be positive")
+		return 2
+	}
+	host, _ := os.Hostname()
+	rec := process.Recording{Host: host, Recorded: time.Now()}
+	for {
+		procs, err := process.Record()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "record: %v\n", err)
+			return 1
+		}
+		rec.Frames = append(rec.Frames, process.Frame{At: time.Since(rec.Recorded).Milliseconds(), Processes: procs})
+		if time.Since(rec.Recorded)+*every > *total {
+			break
+		}
+		time.Sleep(*every)
+	}
+	if err := rec.Save(*out); err != nil {
+		fmt.Fprintf(os.Stderr, "record: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%s: %d frame(s), %d processes in the last\n", *out, len(rec.Frames), len(rec.Frames[len(rec.Frames)-1].Processes))
+	return 0
+}
+
+func resolveConfigPath() string {
+	configPath := config.DefaultConfigName
+	if exePath, err := os.Executable(); err == nil {
+		if found, ok := config.FindInDir(filepath.Dir(exePath)); ok {
+			configPath = found
+		}
 	}
+	return configPath
 }
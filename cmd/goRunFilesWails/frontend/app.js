@@ -7,18 +7,44 @@ const elUpdated                 = document.getElementById("updated");
 const elVersion                 = document.getElementById("version");
 const elNetStatus               = document.getElementById("netStatus");
 const elNetDebug                = document.getElementById("netDebug");
+const elConfigError             = document.getElementById("configError");
+const elConfigErrorBlock        = document.getElementById("configErrorBlock");
+const profileSelect             = document.getElementById("profileSelect");
+const groupSelect               = document.getElementById("groupSelect");
+const elMaintenanceBanner       = document.getElementById("maintenanceBanner");
+const elMaintenanceText         = document.getElementById("maintenanceText");
+const exitMaintenanceBtn        = document.getElementById("exitMaintenance");
+const enterMaintenanceBtn       = document.getElementById("enterMaintenance");
+const maintenanceModal          = document.getElementById("maintenanceModal");
+const maintenanceModalTitle     = document.getElementById("maintenanceModalTitle");
+const maintenanceDuration       = document.getElementById("maintenanceDuration");
+const maintenanceReason         = document.getElementById("maintenanceReason");
+const startMaintenanceBtn       = document.getElementById("startMaintenance");
+const cancelMaintenanceBtn      = document.getElementById("cancelMaintenance");
+const closeMaintenanceBtn       = document.getElementById("closeMaintenance");
+const elScheduleBlock           = document.getElementById("scheduleBlock");
+const elRollingBlock            = document.getElementById("rollingBlock");
+const elRollingStatus           = document.getElementById("rollingStatus");
+const elScheduleNext            = document.getElementById("scheduleNext");
 const tbody                     = document.getElementById("tbody");
 const reloadBtn                 = document.getElementById("reloadConfig");
 const saveBtn                   = document.getElementById("saveConfig");
 const toggleBtn                 = document.getElementById("toggleConfig");
 const restartAllBtn             = document.getElementById("restartAll");
+const rollingRestartBtn         = document.getElementById("rollingRestart");
 const restartAutoManualBtn      = document.getElementById("restartAutoManual");
 const stopAllBtn                = document.getElementById("stopAll");
+const startGroupBtn             = document.getElementById("startGroup");
+const restartGroupBtn           = document.getElementById("restartGroup");
+const stopGroupBtn              = document.getElementById("stopGroup");
 const killCMDBtn                = document.getElementById("killCMD");
 const toggleCheckProcessBtn     = document.getElementById("toggleCheckProcess");
 const killNodeBtn               = document.getElementById("killNode");
 const toggleConsoleBtn          = document.getElementById("toggleConsole");
 const addProcessBtn             = document.getElementById("addProcess");
+const addTemplateBtn            = document.getElementById("addTemplate");
+const addProfileBtn             = document.getElementById("addProfile");
+const addScheduleBtn            = document.getElementById("addSchedule");
 const configPanel               = document.getElementById("configPanel");
 const configPassword            = document.getElementById("configPassword");
 const unlockBtn                 = document.getElementById("unlockConfig");
@@ -26,6 +52,10 @@ const authModal                 = document.getElementById("authModal");
 const closeAuth                 = document.getElementById("closeAuth");
 const cancelAuth                = document.getElementById("cancelAuth");
 const configModal               = document.getElementById("configModal");
+const jobModal                  = document.getElementById("jobModal");
+const jobModalTitle             = document.getElementById("jobModalTitle");
+const jobRuns                   = document.getElementById("jobRuns");
+const closeJobModal             = document.getElementById("closeJobModal");
 const closeConfig               = document.getElementById("closeConfig");
 const errorConsoleContainer     = document.getElementById("errorConsoleContainer");
 const errorConsole              = document.getElementById("errorConsole");
@@ -39,7 +69,13 @@ const removeSchedulerBtn        = document.getElementById("removeScheduler");
 const refreshSchedulerBtn       = document.getElementById("refreshScheduler");
 
 const cfgCheckTiming            = document.getElementById("cfgCheckTiming");
+const cfgCheckTimingMax         = document.getElementById("cfgCheckTimingMax");
 const cfgRestartTiming          = document.getElementById("cfgRestartTiming");
+const cfgMetricsTiming          = document.getElementById("cfgMetricsTiming");
+const cfgBulkParallel           = document.getElementById("cfgBulkParallel");
+const cfgBulkStagger            = document.getElementById("cfgBulkStagger");
+const cfgRollingBatch           = document.getElementById("cfgRollingBatch");
+const cfgRollingTimeout         = document.getElementById("cfgRollingTimeout");
 const cfgAutoRestart            = document.getElementById("cfgAutoRestart");
 const cfgAutoRestartTime        = document.getElementById("cfgAutoRestartTime");
 const cfgAutoRestartOnExit      = document.getElementById("cfgAutoRestartOnExit");
@@ -50,9 +86,25 @@ const cfgNetScale               = document.getElementById("cfgNetScale");
 const cfgLaunchInNewConsole     = document.getElementById("cfgLaunchInNewConsole");
 const cfgAutoCloseErrorDialogs  = document.getElementById("cfgAutoCloseErrorDialogs");
 const cfgErrorWindowTitles      = document.getElementById("cfgErrorWindowTitles");
+const cfgVars                   = document.getElementById("cfgVars");
+const cfgWatchConfig            = document.getElementById("cfgWatchConfig");
+const cfgRestartOnConfigChange  = document.getElementById("cfgRestartOnConfigChange");
+const cfgBackupCount            = document.getElementById("cfgBackupCount");
+const cfgInclude                = document.getElementById("cfgInclude");
+const cfgProfile                = document.getElementById("cfgProfile");
+const cfgProfileNames           = document.getElementById("cfgProfileNames");
+const cfgControlAddr            = document.getElementById("cfgControlAddr");
+const cfgHolidays               = document.getElementById("cfgHolidays");
+const cfgProfiles               = document.getElementById("configProfiles");
+const cfgSchedules              = document.getElementById("configSchedules");
+const cfgBackups                = document.getElementById("cfgBackups");
+const cfgBackupDiff             = document.getElementById("cfgBackupDiff");
 const cfgFind                   = document.getElementById("cfgFind");
 const cfgProcesses              = document.getElementById("configProcesses");
+const cfgTemplates              = document.getElementById("configTemplates");
+const cfgTemplateNames          = document.getElementById("cfgTemplateNames");
 const cfgScreens                = document.getElementById("cfgScreens");
+const cfgDiagnostics            = document.getElementById("cfgDiagnostics");
 
 let lastSnapshot = null;
 const HISTORY_LEN = 40;
@@ -74,6 +126,11 @@ let checkProcessRunning = true;
 const rowMap = new Map();
 
 let currentConfigModel = null;
+// Unexpanded ${VAR} text of settings fields, dropped once a field is edited.
+let settingsRaw = {};
+// Included config files; each process card saves to one of them or to the
+// main config ("").
+let configFiles = [];
 
 const clamp = (v, min, max) => Math.max(min, Math.min(max, v));
 
@@ -306,7 +363,10 @@ const renderMonitorPicker = (container, selected) => {
       container.querySelectorAll(".monitor-pick").forEach((m) => m.classList.remove("selected"));
       el.classList.add("selected");
       const input = container.closest("label")?.querySelector('[data-f="screen"]');
-      if (input) input.value = String(s.index);
+      if (input) {
+        input.value = String(s.index);
+        input.dispatchEvent(new Event("input", { bubbles: true }));
+      }
     });
 
     container.appendChild(el);
@@ -389,7 +449,8 @@ const collectErrorLog = (data) => {
     activeNames.add(name);
     const currentError = (it.error || "").trim();
     const prevError = lastErrorByProcess.get(name) || "";
-    if (currentError && currentError !== prevError) {
+    // Errors of processes in maintenance are expected and not reported.
+    if (currentError && currentError !== prevError && !it.maintenance) {
       appendErrorLog(`[${stamp}] ${name}: ${currentError}`);
     }
     lastErrorByProcess.set(name, currentError);
@@ -442,6 +503,14 @@ const createRow = (name) => {
   btnStart.textContent = "▶️";
   tdActions.appendChild(btnStart);
 
+  const btnHistory = document.createElement("button");
+  btnHistory.dataset.action = "history";
+  btnHistory.dataset.name = name;
+  btnHistory.title = "Run history";
+  btnHistory.textContent = "📜";
+  btnHistory.classList.add("hidden");
+  tdActions.appendChild(btnHistory);
+
   const tdName = document.createElement("td");
   const tdType = document.createElement("td");
   const tdStatus = document.createElement("td");
@@ -454,6 +523,7 @@ const createRow = (name) => {
 
   const tdStarted = document.createElement("td");
   const tdUptime = document.createElement("td");
+  const tdRecycle = document.createElement("td");
 
   const makeMetricCell = (className) => {
     const td = document.createElement("td");
@@ -485,6 +555,7 @@ const createRow = (name) => {
   tr.appendChild(tdPid);
   tr.appendChild(tdStarted);
   tr.appendChild(tdUptime);
+  tr.appendChild(tdRecycle);
   tr.appendChild(cpuCell.td);
   tr.appendChild(gpuCell.td);
   tr.appendChild(memCell.td);
@@ -496,12 +567,15 @@ const createRow = (name) => {
     tr,
     checkbox,
     btnStart,
+    btnRestart,
+    btnHistory,
     tdName,
     tdType,
     tdStatus,
     pidSpan,
     tdStarted,
     tdUptime,
+    tdRecycle,
     cpu: cpuCell,
     gpu: gpuCell,
     mem: memCell,
@@ -511,6 +585,51 @@ const createRow = (name) => {
   };
 };
 
+const pad2 = (n) => String(n).padStart(2, "0");
+const WEEKDAYS = ["Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"];
+
+// formatNext mirrors the monitor's: the clock for today, the weekday within
+// a week, the date otherwise.
+const formatNext = (ms) => {
+  const t = new Date(ms);
+  const now = new Date();
+  const clock = `${pad2(t.getHours())}:${pad2(t.getMinutes())}`;
+  if (t.toDateString() === now.toDateString()) return clock;
+  if (ms - now.getTime() < 6 * 24 * 3600 * 1000) return `${WEEKDAYS[t.getDay()]} ${clock}`;
+  return `${pad2(t.getMonth() + 1)}-${pad2(t.getDate())} ${clock}`;
+};
+
+// uptimeText fills the uptime column from the structured status: the
+// countdown to the next launch or when the run window opens, and for an
+// idle job its next run.
+const uptimeText = (it) => {
+  if (it.status === "scheduled-off") {
+    return it.next_attempt ? `opens ${formatNext(it.next_attempt)}` : "closed";
+  }
+  if (it.next_attempt) {
+    const left = Math.floor((it.next_attempt - Date.now()) / 1000);
+    if (left < 0) return "restart now";
+    const m = Math.floor(left / 60);
+    return m > 99 ? `${m}m` : `restart in ${pad2(m)}:${pad2(left % 60)}`;
+  }
+  if (it.status === "idle") {
+    if (it.job_paused) return "paused";
+    return it.next_run ? `next ${formatNext(it.next_run)}` : "on demand";
+  }
+  return it.uptime || "-";
+};
+
+const statusTitle = (it) => {
+  const lines = [it.status || ""];
+  if (it.reason) lines.push(`reason: ${it.reason}`);
+  if (it.maintenance) lines.push("in maintenance: not relaunched or killed");
+  if (it.last_exit) {
+    const e = it.last_exit;
+    lines.push(`last exit: ${new Date(e.at).toLocaleString()} PID ${e.pid}${e.error ? ` (${e.error})` : ""}`);
+  }
+  return lines.join("\n");
+};
+
 const updateRow = (row, it, prev, netUnit, netIsMB) => {
   if (it.hung) row.tr.classList.add("hung");
   else row.tr.classList.remove("hung");
@@ -518,13 +637,17 @@ const updateRow = (row, it, prev, netUnit, netIsMB) => {
   else row.tr.classList.remove("row-disabled");
 
   row.checkbox.checked = !!it.disabled;
-  const canStart = it.status !== "running" && it.status !== "started";
-  row.btnStart.disabled = !canStart;
+  const canStart = !["starting", "running", "unhealthy"].includes(it.status);
+  // A start or restart waits behind the operation in progress.
+  row.btnStart.disabled = !canStart || !!it.pending;
+  row.btnRestart.disabled = !!it.pending;
+  row.btnHistory.classList.toggle("hidden", it.type !== "job");
 
   row.tdName.textContent = it.name || "";
   row.tdType.textContent = it.type || "";
   row.tdStatus.className = `status ${it.status || ""}`;
   row.tdStatus.textContent = it.icon || "";
+  row.tdStatus.title = statusTitle(it);
 
   const pidNum = Number(it.pid);
   const prevPid = Number(prev.pid);
@@ -540,7 +663,8 @@ const updateRow = (row, it, prev, netUnit, netIsMB) => {
   }
 
   row.tdStarted.textContent = it.started_at || "-";
-  row.tdUptime.textContent = it.uptime || "-";
+  row.tdUptime.textContent = uptimeText(it);
+  row.tdRecycle.textContent = it.recycle || "-";
 
   const cpuVal = parseFloat(it.cpu || "0") || 0;
   const gpuVal = parseFloat(it.gpu || "0") || 0;
@@ -613,6 +737,96 @@ const updateRow = (row, it, prev, netUnit, netIsMB) => {
   row.tdTarget.textContent = it.target || "";
 };
 
+// renderProfiles fills the header profile switcher. Options are rebuilt
+// only when the list changes so an open dropdown is not reset by polling.
+const renderProfiles = (names, active) => {
+  if (!profileSelect) return;
+  const key = names.join("\n");
+  if (profileSelect.dataset.names !== key) {
+    profileSelect.dataset.names = key;
+    profileSelect.innerHTML = `<option value="">(none)</option>` +
+      names.map((n) => `<option value="${escapeAttr(n)}">${escapeAttr(n)}</option>`).join("");
+  }
+  if (document.activeElement !== profileSelect) profileSelect.value = active;
+  profileSelect.closest(".block").classList.toggle("hidden", names.length === 0 && !active);
+};
+
+// activeGroup is the group the process table is narrowed to, "" for all.
+let activeGroup = "";
+
+// renderGroups fills the header group filter and shows the group buttons
+// while a group is picked. A group gone from the config is dropped.
+const renderGroups = (names) => {
+  if (!groupSelect) return;
+  if (activeGroup && !names.includes(activeGroup)) activeGroup = "";
+  const key = names.join("\n");
+  if (groupSelect.dataset.names !== key) {
+    groupSelect.dataset.names = key;
+    groupSelect.innerHTML = `<option value="">(all)</option>` +
+      names.map((n) => `<option value="${escapeAttr(n)}">${escapeAttr(n)}</option>`).join("");
+  }
+  if (document.activeElement !== groupSelect) groupSelect.value = activeGroup;
+  groupSelect.closest(".block").classList.toggle("hidden", names.length === 0);
+  for (const btn of [startGroupBtn, restartGroupBtn, stopGroupBtn]) {
+    btn?.classList.toggle("hidden", !activeGroup);
+  }
+};
+
+// rollingSeen is the time of the last rolling restart event logged.
+let rollingSeen = 0;
+
+// renderRolling shows the progress of the running or last rolling restart
+// in the header, its steps in the tooltip, and logs its failures.
+const renderRolling = (p) => {
+  if (!elRollingBlock) return;
+  elRollingBlock.classList.toggle("hidden", !p);
+  if (rollingRestartBtn) rollingRestartBtn.disabled = !!p?.active;
+  if (!p) return;
+  const state = p.active ? (p.current || []).join(", ") : p.failed ? `aborted on ${p.failed}` : "done";
+  elRollingStatus.textContent = `${p.done}/${p.total} ${state}`;
+  elRollingStatus.classList.toggle("rolling-failed", !!p.failed);
+  const events = p.events || [];
+  elRollingBlock.title = events.map((e) => {
+    const at = new Date(e.at).toLocaleTimeString();
+    return `${at} ${e.name || "—"} ${e.stage}${e.error ? `: ${e.error}` : ""}`;
+  }).join("\n");
+  for (const e of events) {
+    if (e.at <= rollingSeen) continue;
+    if (e.stage === "failed") {
+      const stamp = new Date(e.at).toISOString().replace("T", " ").slice(0, 19);
+      appendErrorLog(`[${stamp}] rolling restart ${e.name}: ${e.error}`);
+    }
+  }
+  if (events.length) rollingSeen = Math.max(rollingSeen, events[events.length - 1].at);
+};
+
+// renderMaintenance shows the active maintenances as a banner.
+const renderMaintenance = (list) => {
+  if (!elMaintenanceBanner) return;
+  elMaintenanceBanner.classList.toggle("hidden", list.length === 0);
+  elMaintenanceText.textContent = list.map((m) => {
+    const scope = m.names?.length ? m.names.join(", ") : "all processes";
+    const until = formatNext(m.until);
+    return `Maintenance of ${scope} until ${until}${m.reason ? `: ${m.reason}` : ""} (${m.source})`;
+  }).join(" · ");
+};
+
+// renderSchedules shows the soonest schedule run in the header; the
+// tooltip lists every schedule with its last result.
+const renderSchedules = (list) => {
+  if (!elScheduleBlock) return;
+  elScheduleBlock.classList.toggle("hidden", list.length === 0);
+  const upcoming = list.filter((s) => s.next).sort((a, b) => a.next.localeCompare(b.next));
+  const first = upcoming[0];
+  elScheduleNext.textContent = first ? `${first.next.slice(5, 16)} ${first.name}` : "—";
+  elScheduleNext.classList.toggle("schedule-failed", list.some((s) => s.last_error));
+  elScheduleBlock.title = list.map((s) => {
+    const action = s.target ? `${s.action} ${s.target}` : s.action;
+    const last = s.last_run ? `, last ${s.last_run}${s.last_error ? `: ${s.last_error}` : ""}` : "";
+    return `${s.name} (${s.cron}): ${action}, next ${s.next || "never"}${last}`;
+  }).join("\n");
+};
+
 const render = (data) => {
   if (!data) return;
   setCheckProcessButton(data.check_process_running !== false);
@@ -627,6 +841,17 @@ const render = (data) => {
   if (elNetDebug) {
     elNetDebug.textContent = data.net_dbg || "—";
   }
+  if (elConfigError && elConfigErrorBlock) {
+    const cfgErr = data.config_error || "";
+    elConfigError.textContent = cfgErr || "—";
+    elConfigError.title = cfgErr;
+    elConfigErrorBlock.classList.toggle("hidden", !cfgErr);
+  }
+  renderProfiles(data.profiles || [], data.profile || "");
+  renderGroups(data.groups || []);
+  renderRolling(data.rolling || null);
+  renderMaintenance(data.maintenance || []);
+  renderSchedules(data.schedules || []);
   collectErrorLog(data);
   const netUnit = (data.net_unit || "KB").toUpperCase();
   const netIsMB = netUnit === "MB";
@@ -689,7 +914,7 @@ const setCheckProcessButton = (running) => {
 
 const tick = async () => {
   if (!api) return;
-  const data = await api.GetSnapshot();
+  const data = activeGroup ? await api.GetGroupSnapshot(activeGroup) : await api.GetSnapshot();
   render(data);
   if (data && Number.isFinite(data.check_timing_ms) && data.check_timing_ms > 0) {
     tickIntervalMs = Math.max(MIN_TICK_MS, data.check_timing_ms);
@@ -706,11 +931,52 @@ tbody.addEventListener("click", async (e) => {
     if (action === "start") await api.Start(name);
     if (action === "stop") await api.Stop(name);
     if (action === "restart") await api.Restart(name);
+    if (action === "history") await openJobHistory(name);
   } catch (err) {
     console.error(err);
   }
 });
 
+const renderJobRuns = (runs) => {
+  jobRuns.innerHTML = "";
+  if (!Array.isArray(runs) || runs.length === 0) {
+    jobRuns.textContent = "No runs yet.";
+    return;
+  }
+  for (const r of runs) {
+    const item = document.createElement("details");
+    item.className = "job-run";
+    if (r.running) item.classList.add("running");
+    else if (r.error) item.classList.add("failed");
+    const result = r.running ? "running" : (r.error || `exit ${r.exit_code}`);
+    const summary = document.createElement("summary");
+    summary.textContent = `${r.started} · ${r.trigger} · ${r.duration} · ${result}`;
+    const pre = document.createElement("pre");
+    pre.textContent = r.output || (r.running ? "" : "(no output)");
+    item.appendChild(summary);
+    item.appendChild(pre);
+    jobRuns.appendChild(item);
+  }
+};
+
+const openJobHistory = async (name) => {
+  jobModalTitle.textContent = `Job runs: ${name}`;
+  jobRuns.textContent = "—";
+  jobModal.classList.remove("hidden");
+  try {
+    renderJobRuns(await api.JobHistory(name));
+  } catch (err) {
+    jobRuns.textContent = err.message || String(err);
+  }
+};
+
+closeJobModal.addEventListener("click", () => jobModal.classList.add("hidden"));
+jobModal.addEventListener("click", (e) => {
+  if (e.target.classList.contains("modal-backdrop")) {
+    jobModal.classList.add("hidden");
+  }
+});
+
 tbody.addEventListener("change", async (e) => {
   const el = e.target;
   if (!el || !api) return;
@@ -724,9 +990,15 @@ tbody.addEventListener("change", async (e) => {
     if (!model || !Array.isArray(model.processes)) {
       throw new Error("Config load failed");
     }
+    // Instances (NAME#N) toggle their whole entry.
+    const entry = model.processes.some((p) => p.name === name) ? name : name.replace(/#\d+$/, "");
     for (const p of model.processes) {
-      if (p.name === name) {
+      if (p.name === entry) {
         p.disabled = disabled;
+        if (p.inherited && "disabled" in p.inherited) {
+          p.overridden = { ...(p.overridden || {}), disabled: p.inherited.disabled };
+          delete p.inherited.disabled;
+        }
         break;
       }
     }
@@ -741,15 +1013,38 @@ tbody.addEventListener("change", async (e) => {
   }
 });
 
+// reportBulk logs the processes a bulk operation failed on and tells the
+// user about them. Rolling restarts are logged by renderRolling.
+const reportBulk = (action, results, log = true) => {
+  const failed = (results || []).filter((r) => r.error);
+  if (failed.length === 0) return;
+  const stamp = new Date().toISOString().replace("T", " ").slice(0, 19);
+  if (log) for (const r of failed) appendErrorLog(`[${stamp}] ${action} ${r.name}: ${r.error}`);
+  alert(`${action}: ${failed.length} of ${results.length} failed\n\n` + failed.map((r) => `${r.name}: ${r.error}`).join("\n"));
+};
+
 restartAllBtn.addEventListener("click", async () => {
   if (!api) return;
   try {
-    await api.RestartAll();
+    reportBulk("restart", await api.RestartAll());
   } catch (err) {
     console.error(err);
   }
 });
 
+// The rolling restart covers the selected group, or all processes.
+rollingRestartBtn?.addEventListener("click", async () => {
+  if (!api) return;
+  rollingRestartBtn.disabled = true;
+  try {
+    reportBulk(activeGroup ? `rolling restart ${activeGroup}` : "rolling restart", await api.RollingRestart(activeGroup), false);
+  } catch (err) {
+    alert(err.message || String(err));
+  } finally {
+    tick();
+  }
+});
+
 restartAutoManualBtn.addEventListener("click", async () => {
   if (!api) return;
   try {
@@ -762,12 +1057,26 @@ restartAutoManualBtn.addEventListener("click", async () => {
 stopAllBtn.addEventListener("click", async () => {
   if (!api) return;
   try {
-    await api.StopAll();
+    reportBulk("stop", await api.StopAll());
   } catch (err) {
     console.error(err);
   }
 });
 
+// runGroup runs a bulk operation on the selected group.
+const runGroup = async (action, call) => {
+  if (!api || !activeGroup) return;
+  try {
+    reportBulk(`${action} ${activeGroup}`, await call(activeGroup));
+  } catch (err) {
+    alert(err.message || String(err));
+  }
+};
+
+startGroupBtn?.addEventListener("click", () => runGroup("start", (g) => api.StartGroup(g)));
+restartGroupBtn?.addEventListener("click", () => runGroup("restart", (g) => api.RestartGroup(g)));
+stopGroupBtn?.addEventListener("click", () => runGroup("stop", (g) => api.StopGroup(g)));
+
 killCMDBtn.addEventListener("click", async () => {
   if (!api) return;
   try {
@@ -777,6 +1086,47 @@ killCMDBtn.addEventListener("click", async () => {
   }
 });
 
+// The maintenance covers the selected group, or the whole monitor.
+const openMaintenanceModal = () => {
+  maintenanceModalTitle.textContent = activeGroup ? `Maintenance of group ${activeGroup}` : "Maintenance of all processes";
+  maintenanceModal.classList.remove("hidden");
+  maintenanceDuration.focus();
+};
+
+const closeMaintenanceModal = () => {
+  maintenanceModal.classList.add("hidden");
+  maintenanceReason.value = "";
+};
+
+enterMaintenanceBtn?.addEventListener("click", openMaintenanceModal);
+cancelMaintenanceBtn?.addEventListener("click", closeMaintenanceModal);
+closeMaintenanceBtn?.addEventListener("click", closeMaintenanceModal);
+maintenanceModal?.addEventListener("click", (e) => {
+  if (e.target.classList.contains("modal-backdrop")) closeMaintenanceModal();
+});
+
+startMaintenanceBtn?.addEventListener("click", async () => {
+  if (!api) return;
+  const scope = activeGroup ? { group: activeGroup } : { all: true };
+  try {
+    await api.EnterMaintenance(maintenanceDuration.value, scope, maintenanceReason.value);
+    closeMaintenanceModal();
+    await tick();
+  } catch (err) {
+    alert(err.message || String(err));
+  }
+});
+
+exitMaintenanceBtn?.addEventListener("click", async () => {
+  if (!api) return;
+  try {
+    await api.ExitMaintenance();
+    await tick();
+  } catch (err) {
+    console.error(err);
+  }
+});
+
 toggleCheckProcessBtn.addEventListener("click", async () => {
   if (!api) return;
   toggleCheckProcessBtn.disabled = true;
@@ -829,7 +1179,13 @@ const renderConfig = (model) => {
 
   const s = model.settings || {};
   cfgCheckTiming.value = s.checkTiming || "";
+  cfgCheckTimingMax.value = s.checkTimingMax || "";
   cfgRestartTiming.value = s.restartTiming || "";
+  cfgMetricsTiming.value = s.metricsTiming || "";
+  cfgBulkParallel.value = s.bulkParallel ? String(s.bulkParallel) : "";
+  cfgBulkStagger.value = s.bulkStagger || "";
+  cfgRollingBatch.value = s.rollingBatch ? String(s.rollingBatch) : "";
+  cfgRollingTimeout.value = s.rollingTimeout || "";
   cfgAutoRestart.checked = !!s.autoRestart;
   cfgAutoRestartTime.value = s.autoRestartTime || "";
   cfgAutoRestartOnExit.checked = !!s.autoRestartOnExit;
@@ -840,14 +1196,185 @@ const renderConfig = (model) => {
   cfgLaunchInNewConsole.checked = !!s.launchInNewConsole;
   cfgAutoCloseErrorDialogs.checked = !!s.autoCloseErrorDialogs;
   cfgErrorWindowTitles.value = s.errorWindowTitles || "";
+  settingsRaw = { ...(s.raw || {}) };
+  cfgErrorWindowTitles.title = settingsRaw.errorWindowTitles || "";
+  cfgVars.value = Object.entries(model.vars || {})
+    .sort(([a], [b]) => a.localeCompare(b))
+    .map(([k, v]) => `${k}=${v}`)
+    .join("\n");
+  cfgWatchConfig.checked = !!s.watchConfig;
+  cfgRestartOnConfigChange.checked = !!s.restartOnConfigChange;
+  cfgBackupCount.value = s.backupCount ? String(s.backupCount) : "";
+  cfgInclude.value = s.include || "";
+  cfgProfile.value = s.profile || "";
+  cfgControlAddr.value = s.controlAddr || "";
+  cfgHolidays.value = s.holidays || "";
+  configFiles = model.files || [];
+
+  cfgSchedules.innerHTML = "";
+  for (const s of model.schedules || []) {
+    cfgSchedules.appendChild(buildScheduleRow(s));
+  }
+
+  cfgProfiles.innerHTML = "";
+  for (const p of model.profiles || []) {
+    cfgProfiles.appendChild(buildProfileRow(p));
+  }
+  refreshProfileNames();
 
   cfgProcesses.innerHTML = "";
+  cfgTemplates.innerHTML = "";
 
+  for (const t of model.templates || []) {
+    cfgTemplates.appendChild(buildProcessRow(t));
+  }
   for (const p of model.processes || []) {
     cfgProcesses.appendChild(buildProcessRow(p));
   }
+  refreshTemplateNames();
 
   applyFilter();
+  validateConfig();
+};
+
+const settingsInputs = {
+  checkTiming: cfgCheckTiming,
+  checkTimingMax: cfgCheckTimingMax,
+  restartTiming: cfgRestartTiming,
+  metricsTiming: cfgMetricsTiming,
+  bulkParallel: cfgBulkParallel,
+  bulkStagger: cfgBulkStagger,
+  rollingBatch: cfgRollingBatch,
+  rollingTimeout: cfgRollingTimeout,
+  autoRestart: cfgAutoRestart,
+  autoRestartTime: cfgAutoRestartTime,
+  autoRestartOnExit: cfgAutoRestartOnExit,
+  useETWNetwork: cfgUseETWNetwork,
+  netDebug: cfgNetDebug,
+  netUnit: cfgNetUnit,
+  netScale: cfgNetScale,
+  launchInNewConsole: cfgLaunchInNewConsole,
+  autoCloseErrorDialogs: cfgAutoCloseErrorDialogs,
+  errorWindowTitles: cfgErrorWindowTitles,
+  watchConfig: cfgWatchConfig,
+  restartOnConfigChange: cfgRestartOnConfigChange,
+  backupCount: cfgBackupCount,
+  include: cfgInclude,
+  profile: cfgProfile,
+  controlAddr: cfgControlAddr,
+  holidays: cfgHolidays,
+};
+
+// diagnosticInput finds the editor field a diagnostic points at.
+const diagnosticInput = (d) => {
+  if (!d.key) return null;
+  const schedule = /^\[schedule "(.*)"\]$/.exec(d.section || "");
+  if (schedule) {
+    for (const card of cfgSchedules.querySelectorAll(".schedule-card")) {
+      if ((card.querySelector('[data-f="name"]').value || "").trim() !== schedule[1]) continue;
+      return card.querySelector(`[data-f="${d.key}"]`) || card.querySelector('[data-f="name"]');
+    }
+    return null;
+  }
+  if (!d.process) return settingsInputs[d.key] || null;
+  for (const card of cfgProcesses.querySelectorAll(".process-card")) {
+    const name = (card.querySelector('[data-f="name"]').value || "").trim();
+    if (name !== d.process) continue;
+    return card.querySelector(`[data-f="${d.key}"]`) || card.querySelector('[data-f="name"]');
+  }
+  return null;
+};
+
+const renderDiagnostics = (list) => {
+  for (const el of configPanel.querySelectorAll(".diag-invalid, .diag-warning")) {
+    el.classList.remove("diag-invalid", "diag-warning");
+  }
+  for (const el of configPanel.querySelectorAll(".diag-msg")) el.remove();
+  cfgDiagnostics.innerHTML = "";
+  cfgDiagnostics.classList.toggle("hidden", list.length === 0);
+
+  for (const d of list) {
+    const cls = d.severity === "error" ? "diag-invalid" : "diag-warning";
+    const row = document.createElement("div");
+    row.className = cls;
+    row.textContent = [d.file, d.section, d.key, d.message].filter(Boolean).join(" · ");
+    cfgDiagnostics.appendChild(row);
+
+    const input = diagnosticInput(d);
+    if (!input) continue;
+    input.classList.add(cls);
+    const label = input.closest("label");
+    if (!label) continue;
+    const msg = document.createElement("div");
+    msg.className = `diag-msg ${cls}`;
+    msg.textContent = d.message;
+    label.appendChild(msg);
+  }
+};
+
+// validateConfig checks the edited config and marks problems inline.
+// Returns true when there are no errors.
+const validateConfig = async () => {
+  if (!api || !unlocked) return true;
+  let list;
+  try {
+    list = await api.ValidateConfigModel(collectConfig());
+  } catch (err) {
+    list = [{ severity: "error", message: err.message || String(err) }];
+  }
+  list = Array.isArray(list) ? list : [];
+  renderDiagnostics(list);
+  return !list.some((d) => d.severity === "error");
+};
+
+let validateTimer = null;
+configPanel.addEventListener("input", (e) => {
+  if (e.target === cfgFind) return;
+  clearTimeout(validateTimer);
+  validateTimer = setTimeout(validateConfig, 400);
+});
+
+const refreshTemplateNames = () => {
+  cfgTemplateNames.innerHTML = "";
+  for (const input of cfgTemplates.querySelectorAll('[data-f="name"]')) {
+    const name = (input.value || "").trim();
+    if (!name) continue;
+    const opt = document.createElement("option");
+    opt.value = name;
+    cfgTemplateNames.appendChild(opt);
+  }
+};
+
+const fieldValue = (el) => {
+  if (!el) return "";
+  return el.type === "checkbox" ? el.checked : el.value;
+};
+
+// originKey maps an editor field to the config key it belongs to.
+const originKey = (f) => (f === "screenExpr" ? "screen" : f);
+
+// applyOrigins marks fields that come from a template and fields that
+// override one.
+const applyOrigins = (card) => {
+  for (const el of card.querySelectorAll("[data-f]")) {
+    const key = originKey(el.dataset.f);
+    const label = el.closest("label");
+    if (!label) continue;
+    label.classList.toggle("inherited", key in card.inherited);
+    label.classList.toggle("overridden", key in card.overridden);
+    label.title = key in card.inherited
+      ? `inherited from ${card.inherited[key]}`
+      : key in card.overridden ? `overrides ${card.overridden[key]}` : "";
+  }
+};
+
+// templateValue returns the value a template card currently shows for key.
+const templateValue = (name, key) => {
+  for (const card of cfgTemplates.querySelectorAll(".process-card")) {
+    if ((card.querySelector('[data-f="name"]').value || "").trim() !== name) continue;
+    return fieldValue(card.querySelector(`[data-f="${key}"]`));
+  }
+  return undefined;
 };
 
 const buildProcessRow = (p = {}) => {
@@ -860,14 +1387,27 @@ const buildProcessRow = (p = {}) => {
       <label>Name
         <input data-f="name" value="${escapeAttr(p.name)}" />
       </label>
+      <label>Extends
+        <input data-f="extends" list="cfgTemplateNames" value="${escapeAttr(p.extends)}" placeholder="template" />
+      </label>
       <label>Disabled
         <input data-f="disabled" type="checkbox" ${p.disabled ? "checked" : ""} />
       </label>
+      <label>Groups
+        <input data-f="groups" value="${escapeAttr(p.groups)}" placeholder="show, web" />
+      </label>
+      <label class="${configFiles.length ? "" : "hidden"}">File
+        <select data-f="source">
+          <option value="">main config</option>
+          ${configFiles.map((f) => `<option value="${escapeAttr(f)}">${escapeAttr(f)}</option>`).join("")}
+        </select>
+      </label>
       <label>Type
         <select data-f="type">
           <option value="exe">exe</option>
           <option value="cmd">cmd</option>
           <option value="bat">bat</option>
+          <option value="job">job</option>
         </select>
       </label>
       <label>Process
@@ -886,6 +1426,12 @@ const buildProcessRow = (p = {}) => {
         <input data-f="screen" type="hidden" value="${Number(p.screen) || 0}" />
         <div class="monitor-picker"></div>
       </label>
+      <label>Screen template
+        <input data-f="screenExpr" value="${escapeAttr(p.screenExpr)}" placeholder="{{.Index}}" />
+      </label>
+      <label>Instances
+        <input data-f="instances" type="number" min="0" value="${Number(p.instances) || ""}" placeholder="1" />
+      </label>
       <label>CheckProcess
         <input data-f="checkProcess" value="${escapeAttr(p.checkProcess)}" />
       </label>
@@ -898,12 +1444,33 @@ const buildProcessRow = (p = {}) => {
       <label>DelayStartTime
         <input data-f="delayStartTime" value="${escapeAttr(p.delayStartTime)}" placeholder="30s" />
       </label>
+      <label>StartTimeout (must be found within)
+        <input data-f="startTimeout" value="${escapeAttr(p.startTimeout)}" placeholder="30s" />
+      </label>
+      <label>MinUptime (failed start if gone sooner)
+        <input data-f="minUptime" value="${escapeAttr(p.minUptime)}" placeholder="5s" />
+      </label>
       <label>MonitorHang
         <input data-f="monitorHang" type="checkbox" ${p.monitorHang ? "checked" : ""} />
       </label>
       <label>HangTimeout
         <input data-f="hangTimeout" value="${escapeAttr(p.hangTimeout)}" />
       </label>
+      <label>MaxUptime (recycle after)
+        <input data-f="maxUptime" value="${escapeAttr(p.maxUptime)}" placeholder="12h" />
+      </label>
+      <label>RestartAt (daily recycle)
+        <input data-f="restartAt" value="${escapeAttr(p.restartAt)}" placeholder="04:30" />
+      </label>
+      <label>Cron (type job)
+        <input data-f="cron" value="${escapeAttr(p.cron)}" placeholder="0 3 * * *" />
+      </label>
+      <label>Timeout (type job)
+        <input data-f="timeout" value="${escapeAttr(p.timeout)}" placeholder="10m" />
+      </label>
+      <label class="wide">RunWindow (outside it the process is kept stopped)
+        <input data-f="runWindow" value="${escapeAttr(p.runWindow)}" placeholder="Mon-Fri 09:00-21:00; Sat-Sun 10:00-18:00" />
+      </label>
     </div>
     <div class="process-actions">
       <button data-action="remove">Remove</button>
@@ -912,6 +1479,8 @@ const buildProcessRow = (p = {}) => {
   const picker = card.querySelector('.monitor-picker');
   if (picker) renderMonitorPicker(picker, p.screen);
 
+  card.querySelector('select[data-f="source"]').value = p.source || "";
+
   const typeSelect = card.querySelector('select[data-f="type"]');
   typeSelect.value = initialType;
   typeSelect.addEventListener("change", () => {
@@ -921,19 +1490,211 @@ const buildProcessRow = (p = {}) => {
       excludeInput.value = CMD_CHECK_CMDLINE_EXCLUDE_DEFAULT;
     }
   });
+
+  card.inherited = { ...(p.inherited || {}) };
+  card.overridden = { ...(p.overridden || {}) };
+  card.raw = { ...(p.raw || {}) };
+  applyOrigins(card);
+  // Fields using ${VAR} show the expanded value; the tooltip has the source.
+  for (const [key, raw] of Object.entries(card.raw)) {
+    const input = card.querySelector(`[data-f="${key}"]`);
+    if (input) {
+      input.title = raw;
+      input.classList.add("expanded");
+    }
+  }
+  card.addEventListener("input", (e) => {
+    const f = e.target.dataset?.f;
+    if (f && f in card.raw) {
+      delete card.raw[f];
+      e.target.title = "";
+      e.target.classList.remove("expanded");
+    }
+  });
+  card.addEventListener("input", (e) => {
+    const key = originKey(e.target.dataset?.f);
+    if (!key || !(key in card.inherited)) return;
+    card.overridden[key] = card.inherited[key];
+    delete card.inherited[key];
+    applyOrigins(card);
+  });
+  card.addEventListener("change", (e) => {
+    const el = e.target;
+    const key = el.dataset?.f;
+    if (!key || !(key in card.overridden) || el.type === "checkbox" || String(el.value).trim() !== "") return;
+    // A cleared override falls back to the template value.
+    const value = templateValue(card.overridden[key], key);
+    if (value === undefined) return;
+    el.value = value;
+    card.inherited[key] = card.overridden[key];
+    delete card.overridden[key];
+    applyOrigins(card);
+  });
   return card;
 };
 
-const collectConfig = () => {
-  const processes = [];
+// buildScheduleRow renders a [schedule] entry.
+const buildScheduleRow = (s = {}) => {
+  const card = document.createElement("div");
+  card.className = "process-card schedule-card";
+  card.innerHTML = `
+    <div class="process-grid">
+      <label>Name
+        <input data-f="name" value="${escapeAttr(s.name)}" />
+      </label>
+      <label>Cron (min hour day month weekday)
+        <input data-f="cron" value="${escapeAttr(s.cron)}" placeholder="30 4 * * Mon-Fri" />
+      </label>
+      <label>Action
+        <select data-f="action">
+          <option value="">restart-all</option>
+          <option value="start">start</option>
+          <option value="stop">stop</option>
+          <option value="restart">restart</option>
+          <option value="rolling-restart">rolling-restart</option>
+          <option value="maintenance">maintenance</option>
+        </select>
+      </label>
+      <label>Target (processes, comma-separated)
+        <input data-f="target" value="${escapeAttr(s.target)}" />
+      </label>
+      <label>Timezone
+        <input data-f="timezone" value="${escapeAttr(s.timezone)}" placeholder="local" />
+      </label>
+      <label>Catch up missed run within
+        <input data-f="catchUp" value="${escapeAttr(s.catchUp)}" placeholder="2h" />
+      </label>
+      <label>Duration (maintenance)
+        <input data-f="duration" value="${escapeAttr(s.duration)}" placeholder="1h" />
+      </label>
+      <label>Disabled
+        <input data-f="disabled" type="checkbox" ${s.disabled ? "checked" : ""} />
+      </label>
+    </div>
+    <div class="process-actions">
+      <button data-action="remove">Remove</button>
+    </div>
+  `;
+  const action = (s.action || "").toLowerCase();
+  card.querySelector('[data-f="action"]').value = action === "restart-all" ? "" : action;
+  return card;
+};
+
+const collectSchedules = () => {
+  const schedules = [];
+  for (const card of cfgSchedules.querySelectorAll(".schedule-card")) {
+    const get = (key) => card.querySelector(`[data-f="${key}"]`);
+    schedules.push({
+      name: (get("name").value || "").trim(),
+      disabled: get("disabled").checked,
+      cron: get("cron").value.trim(),
+      action: get("action").value,
+      target: get("target").value.trim(),
+      timezone: get("timezone").value.trim(),
+      catchUp: get("catchUp").value.trim(),
+      duration: get("duration").value.trim(),
+    });
+  }
+  return schedules;
+};
+
+// buildProfileRow renders a profile: the processes it runs and its
+// NAME.field=value overrides, one per line.
+const buildProfileRow = (p = {}) => {
+  const card = document.createElement("div");
+  card.className = "process-card profile-card";
+  const overrides = [];
+  for (const [name, fields] of Object.entries(p.overrides || {})) {
+    for (const [field, value] of Object.entries(fields || {})) {
+      overrides.push(`${name ? `${name}.` : ""}${field}=${value}`);
+    }
+  }
+  overrides.sort();
+  card.innerHTML = `
+    <div class="process-grid">
+      <label>Name
+        <input data-f="name" value="${escapeAttr(p.name)}" />
+      </label>
+      <label class="wide">Processes (comma-separated, NAME#N for one instance)
+        <input data-f="processes" value="${escapeAttr((p.processes || []).join(", "))}" />
+      </label>
+      <label class="wide">Overrides (NAME.field=value per line)
+        <textarea data-f="overrides" rows="3" spellcheck="false">${escapeAttr(overrides.join("\n"))}</textarea>
+      </label>
+    </div>
+    <div class="process-actions">
+      <button data-action="remove">Remove</button>
+    </div>
+  `;
+  return card;
+};
+
+// parseOverrides turns NAME.field=value lines into the overrides map. A key
+// without a dot is kept under "" so validation can point at it.
+const parseOverrides = (text) => {
+  const out = {};
+  for (const line of String(text || "").split("\n")) {
+    const trimmed = line.trim();
+    if (!trimmed || trimmed.startsWith(";") || trimmed.startsWith("#")) continue;
+    const eq = trimmed.indexOf("=");
+    if (eq <= 0) throw new Error(`Invalid override line: ${trimmed}`);
+    const key = trimmed.slice(0, eq).trim();
+    const dot = key.lastIndexOf(".");
+    const name = dot > 0 ? key.slice(0, dot) : "";
+    const field = dot > 0 ? key.slice(dot + 1) : key;
+    (out[name] ||= {})[field] = trimmed.slice(eq + 1).trim();
+  }
+  return out;
+};
+
+const collectProfiles = () => {
+  const profiles = [];
+  for (const card of cfgProfiles.querySelectorAll(".profile-card")) {
+    const get = (key) => card.querySelector(`[data-f="${key}"]`).value;
+    profiles.push({
+      name: (get("name") || "").trim(),
+      processes: get("processes").split(",").map((s) => s.trim()).filter(Boolean),
+      overrides: parseOverrides(get("overrides")),
+    });
+  }
+  return profiles;
+};
+
+const refreshProfileNames = () => {
+  cfgProfileNames.innerHTML = [...cfgProfiles.querySelectorAll('[data-f="name"]')]
+    .map((input) => (input.value || "").trim())
+    .filter(Boolean)
+    .map((n) => `<option value="${escapeAttr(n)}"></option>`)
+    .join("");
+};
+
+const parseVars = (text) => {
+  const vars = {};
+  for (const line of String(text || "").split("\n")) {
+    const trimmed = line.trim();
+    if (!trimmed || trimmed.startsWith(";") || trimmed.startsWith("#")) continue;
+    const eq = trimmed.indexOf("=");
+    if (eq <= 0) throw new Error(`Invalid var line: ${trimmed}`);
+    vars[trimmed.slice(0, eq).trim()] = trimmed.slice(eq + 1).trim();
+  }
+  return vars;
+};
+
+cfgErrorWindowTitles.addEventListener("input", () => {
+  delete settingsRaw.errorWindowTitles;
+  cfgErrorWindowTitles.title = "";
+});
+
+const collectItems = (list, kind) => {
+  const items = [];
   const names = new Set();
-  for (const card of cfgProcesses.querySelectorAll(".process-card")) {
+  for (const card of list.querySelectorAll(".process-card")) {
     const get = (f) => card.querySelector(`[data-f="${f}"]`);
     const name = (get("name").value || "").trim();
     if (!name) continue;
-    if (names.has(name)) throw new Error(`Duplicate process name: ${name}`);
+    if (names.has(name)) throw new Error(`Duplicate ${kind} name: ${name}`);
     names.add(name);
-    processes.push({
+    items.push({
       name,
       disabled: get("disabled").checked,
       type: get("type").value,
@@ -942,18 +1703,45 @@ const collectConfig = () => {
       command: get("command").value,
       args: get("args").value,
       screen: Number(get("screen")?.value || 0),
+      screenExpr: (get("screenExpr").value || "").trim(),
+      instances: Number(get("instances").value || 0),
       checkProcess: get("checkProcess").value,
       checkCmdline: get("checkCmdline").value,
       checkCmdlineExclude: get("checkCmdlineExclude").value,
       delayStartTime: get("delayStartTime").value,
+      startTimeout: get("startTimeout").value,
+      minUptime: get("minUptime").value,
       monitorHang: get("monitorHang").checked,
       hangTimeout: get("hangTimeout").value,
+      runWindow: get("runWindow").value.trim(),
+      maxUptime: get("maxUptime").value,
+      restartAt: get("restartAt").value.trim(),
+      cron: get("cron").value.trim(),
+      timeout: get("timeout").value,
+      extends: (get("extends").value || "").trim(),
+      groups: get("groups").value.trim(),
+      source: get("source").value,
+      inherited: card.inherited,
+      overridden: card.overridden,
+      raw: card.raw,
     });
   }
+  return items;
+};
+
+const collectConfig = () => {
+  const processes = collectItems(cfgProcesses, "process");
+  const templates = collectItems(cfgTemplates, "template");
   return {
     settings: {
       checkTiming: cfgCheckTiming.value,
+      checkTimingMax: cfgCheckTimingMax.value,
       restartTiming: cfgRestartTiming.value,
+      metricsTiming: cfgMetricsTiming.value,
+      bulkParallel: Number(cfgBulkParallel.value || 0),
+      bulkStagger: cfgBulkStagger.value,
+      rollingBatch: Number(cfgRollingBatch.value || 0),
+      rollingTimeout: cfgRollingTimeout.value,
       autoRestart: cfgAutoRestart.checked,
       autoRestartTime: cfgAutoRestartTime.value,
       autoRestartOnExit: cfgAutoRestartOnExit.checked,
@@ -964,9 +1752,22 @@ const collectConfig = () => {
       launchInNewConsole: cfgLaunchInNewConsole.checked,
       autoCloseErrorDialogs: cfgAutoCloseErrorDialogs.checked,
       errorWindowTitles: cfgErrorWindowTitles.value,
+      raw: settingsRaw,
+      watchConfig: cfgWatchConfig.checked,
+      restartOnConfigChange: cfgRestartOnConfigChange.checked,
+      backupCount: Number(cfgBackupCount.value || 0),
+      include: cfgInclude.value,
+      profile: cfgProfile.value.trim(),
+      controlAddr: cfgControlAddr.value.trim(),
+      holidays: cfgHolidays.value.trim(),
       cfgFind: cfgFind.value,
     },
     processes,
+    templates,
+    profiles: collectProfiles(),
+    schedules: collectSchedules(),
+    vars: parseVars(cfgVars.value),
+    files: configFiles,
   };
 };
 
@@ -976,6 +1777,7 @@ reloadBtn.addEventListener("click", async () => {
   await refreshScreens();
   const model = await api.GetConfigModel();
   renderConfig(model);
+  await refreshBackups();
 });
 
 cfgFind.addEventListener("input", () => {
@@ -994,8 +1796,14 @@ saveBtn.addEventListener("click", async () => {
   if (!unlocked) return;
   try {
     const model = collectConfig();
+    if (!(await validateConfig())) {
+      alert("Config has errors, see the list above.");
+      return;
+    }
 
     await api.SaveConfigModel(model);
+    renderConfig(await api.GetConfigModel());
+    await refreshBackups();
   } catch (err) {
     alert(err.message || String(err));
   }
@@ -1051,13 +1859,56 @@ addProcessBtn.addEventListener("click", () => {
   cfgProcesses.appendChild(buildProcessRow({}));
 });
 
-cfgProcesses.addEventListener("click", (e) => {
-  const btn = e.target.closest("button");
-  if (!btn) return;
-  if (btn.dataset.action === "remove") {
-    if (!unlocked) return;
-    btn.closest(".process-card").remove();
+addTemplateBtn.addEventListener("click", () => {
+  if (!unlocked) return;
+  cfgTemplates.appendChild(buildProcessRow({}));
+});
+
+addScheduleBtn.addEventListener("click", () => {
+  if (!unlocked) return;
+  cfgSchedules.appendChild(buildScheduleRow({}));
+});
+
+addProfileBtn.addEventListener("click", () => {
+  if (!unlocked) return;
+  cfgProfiles.appendChild(buildProfileRow({}));
+});
+
+profileSelect?.addEventListener("change", async () => {
+  if (!api) return;
+  try {
+    await api.ActivateProfile(profileSelect.value);
+  } catch (err) {
+    console.error(err);
   }
+  profileSelect.blur();
+});
+
+groupSelect?.addEventListener("change", () => {
+  activeGroup = groupSelect.value;
+  groupSelect.blur();
+  tick();
+});
+
+cfgProfiles.addEventListener("change", (e) => {
+  if (e.target.dataset?.f === "name") refreshProfileNames();
+});
+
+for (const list of [cfgProcesses, cfgTemplates, cfgProfiles, cfgSchedules]) {
+  list.addEventListener("click", (e) => {
+    const btn = e.target.closest("button");
+    if (!btn) return;
+    if (btn.dataset.action === "remove") {
+      if (!unlocked) return;
+      btn.closest(".process-card").remove();
+      refreshTemplateNames();
+      validateConfig();
+    }
+  });
+}
+
+cfgTemplates.addEventListener("change", (e) => {
+  if (e.target.dataset?.f === "name") refreshTemplateNames();
 });
 
 let unlocked = false;
@@ -1089,6 +1940,7 @@ const unlockConfig = async () => {
   const model = await api.GetConfigModel();
   renderConfig(model);
   await refreshSchedulerStatus();
+  await refreshBackups();
 };
 
 unlockBtn.addEventListener("click", unlockConfig);
@@ -1177,6 +2029,65 @@ if (removeSchedulerBtn) {
   });
 }
 
+const renderBackupDiff = (text) => {
+  if (!cfgBackupDiff) return;
+  cfgBackupDiff.innerHTML = "";
+  for (const line of String(text || "").split("\n")) {
+    const span = document.createElement("span");
+    if (line.startsWith("+") && !line.startsWith("+++")) span.className = "add";
+    if (line.startsWith("-") && !line.startsWith("---")) span.className = "del";
+    span.textContent = `${line}\n`;
+    cfgBackupDiff.appendChild(span);
+  }
+  cfgBackupDiff.classList.remove("hidden");
+};
+
+const refreshBackups = async () => {
+  if (!api || !cfgBackups) return;
+  try {
+    const list = await api.ListConfigBackups();
+    cfgBackups.innerHTML = "";
+    if (!Array.isArray(list) || list.length === 0) {
+      cfgBackups.textContent = "No backups yet.";
+      return;
+    }
+    for (const b of list) {
+      const row = document.createElement("div");
+      row.className = "backup-row";
+      row.innerHTML = `
+        <span>${escapeAttr(b.created)} (${Number(b.size) || 0} B)</span>
+        <button class="panel-actions__button fixed" data-action="diff-backup" data-id="${escapeAttr(b.id)}">Diff</button>
+        <button class="panel-actions__button fixed" data-action="restore-backup" data-id="${escapeAttr(b.id)}">Restore</button>
+      `;
+      cfgBackups.appendChild(row);
+    }
+  } catch (err) {
+    cfgBackups.textContent = err.message || String(err);
+  }
+};
+
+if (cfgBackups) {
+  cfgBackups.addEventListener("click", async (e) => {
+    const btn = e.target.closest("button");
+    if (!btn || !api || !unlocked) return;
+    const id = btn.dataset.id;
+    try {
+      if (btn.dataset.action === "diff-backup") {
+        renderBackupDiff(await api.DiffConfigBackup(id));
+      }
+      if (btn.dataset.action === "restore-backup") {
+        if (!confirm(`Restore config from ${id}?`)) return;
+        await api.RestoreConfigBackup(id);
+        renderConfig(await api.GetConfigModel());
+        cfgBackupDiff?.classList.add("hidden");
+        await refreshBackups();
+      }
+    } catch (err) {
+      alert(err.message || String(err));
+    }
+  });
+}
+
 if (refreshSchedulerBtn) {
   refreshSchedulerBtn.addEventListener("click", refreshSchedulerStatus);
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"goRunFiles/internal/app"
 	"goRunFiles/internal/config"
@@ -54,6 +56,9 @@ func main() {
 		mon:        app.New(cfg, log.Default(), buildVersion),
 		configPath: configPath,
 	}
+	if err := gui.mon.UseStateFile(config.StatePath(configPath)); err != nil {
+		log.Printf("%s [ART3D-CHEKER]: state not restored: %v", app.LogTag, err)
+	}
 
 	err = wails.Run(&options.App{
 		Title:  "ART3D Process Monitor",
@@ -63,12 +68,17 @@ func main() {
 			Assets: assets,
 		},
 		OnStartup: func(ctx context.Context) {
+			go gui.mon.WatchConfig(ctx, gui.configPath)
+			go gui.mon.ServeControl(ctx)
 			go func() {
 				_ = gui.mon.RunWithObserver(ctx, gui.updateSnapshot)
 			}()
 		},
 		OnShutdown: func(ctx context.Context) {
 			// Keep child processes running after UI closes.
+			if err := gui.mon.SaveState(); err != nil {
+				log.Printf("%s [ART3D-CHEKER]: state not saved: %v", app.LogTag, err)
+			}
 		},
 		Bind: []interface{}{gui},
 	})
@@ -80,15 +90,13 @@ func main() {
 func resolveConfigPath() string {
 	configPath := config.DefaultConfigName
 	if exePath, err := os.Executable(); err == nil {
-		exeConfig := filepath.Join(filepath.Dir(exePath), config.DefaultConfigName)
-		if _, err := os.Stat(exeConfig); err == nil {
-			configPath = exeConfig
+		if found, ok := config.FindInDir(filepath.Dir(exePath)); ok {
+			configPath = found
 		}
 	}
 	if cwd, err := os.Getwd(); err == nil {
-		cwdConfig := filepath.Join(cwd, config.DefaultConfigName)
-		if _, err := os.Stat(cwdConfig); err == nil {
-			configPath = cwdConfig
+		if found, ok := config.FindInDir(cwd); ok {
+			configPath = found
 		}
 	}
 	if exePath, err := os.Executable(); err == nil {
@@ -98,9 +106,8 @@ func resolveConfigPath() string {
 			if parent == dir {
 				break
 			}
-			candidate := filepath.Join(parent, config.DefaultConfigName)
-			if _, err := os.Stat(candidate); err == nil {
-				configPath = candidate
+			if found, ok := config.FindInDir(parent); ok {
+				configPath = found
 				break
 			}
 			dir = parent
@@ -126,6 +133,12 @@ func (g *GUI) GetSnapshot() app.DisplaySnapshot {
 	return s
 }
 
+// GetGroupSnapshot returns the latest snapshot with only the processes of
+// group; all of them for "".
+func (g *GUI) GetGroupSnapshot(group string) app.DisplaySnapshot {
+	return g.GetSnapshot().InGroup(group)
+}
+
 // GetScreens returns monitors available in the current desktop session.
 func (g *GUI) GetScreens() ([]display.Screen, error) {
 	return display.ListScreens()
@@ -156,20 +169,39 @@ func (g *GUI) IsCheckProcessRunning() bool {
 
 // Start starts a process by config name.
 func (g *GUI) Start(name string) error {
-	return g.mon.StartProcess(name)
+	return awaitOp(g.mon.Start(context.Background(), name))
 }
 
 // Stop stops a process by config name.
 func (g *GUI) Stop(name string) error {
-	return g.mon.StopProcess(name)
+	return awaitOp(g.mon.Stop(context.Background(), name))
 }
 
 // Restart restarts a process by config name.
 func (g *GUI) Restart(name string) error {
-	return g.mon.RestartProcess(name)
+	return awaitOp(g.mon.Restart(context.Background(), name))
 }
 
-// SetDisabled enables or disables a process by config name.
+// opWait is how long a button waits for its operation. A slower one goes
+// on in the background and the table shows it until it ends.
+const opWait = 10 * time.Second
+
+func awaitOp(op *app.Op) error {
+	ctx, cancel := context.WithTimeout(context.Background(), opWait)
+	defer cancel()
+	if err := op.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+// JobHistory returns the recent runs of a type=job process, newest first.
+func (g *GUI) JobHistory(name string) ([]app.JobRun, error) {
+	return g.mon.JobHistory(name)
+}
+
+// SetDisabled enables or disables a process by config name. For an
+// instance (NAME#N) the whole entry is toggled.
 func (g *GUI) SetDisabled(name string, disabled bool) error {
 	name = strings.TrimSpace(name)
 	if name == "" {
@@ -179,15 +211,29 @@ func (g *GUI) SetDisabled(name string, disabled bool) error {
 	if err != nil {
 		return err
 	}
-	item, ok := cfg.Process[name]
-	if !ok {
+	if _, ok := cfg.Process[name]; !ok {
+		name, _ = config.BaseName(name)
+	}
+	if _, ok := cfg.Process[name]; !ok {
 		return fmt.Errorf("process %q not found", name)
 	}
-	item.Disabled = disabled
 	dto := config.ToDTO(cfg)
+	for i := range dto.Processes {
+		if dto.Processes[i].Name == name {
+			dto.Processes[i].Disabled = disabled
+			dto.Processes[i].Override("disabled")
+		}
+	}
+	if cfg, err = config.FromDTO(dto); err != nil {
+		return err
+	}
 	if err := config.WriteFromDTO(g.configPath, dto); err != nil {
 		return err
 	}
+	// Reload so files read next to the config, like holidays, come along.
+	if saved, err := config.Load(g.configPath); err == nil {
+		cfg = saved
+	}
 	g.mon.UpdateConfig(cfg)
 	if err := updateSchedulerScriptIfInstalled(cfg); err != nil {
 		return err
@@ -195,14 +241,66 @@ func (g *GUI) SetDisabled(name string, disabled bool) error {
 	return nil
 }
 
-// RestartAll restarts all enabled processes.
-func (g *GUI) RestartAll() error {
-	return g.mon.RestartAll()
+// ActivateProfile switches the running monitor to a profile, or back to
+// the plain config for "". Only processes whose state changes are touched.
+func (g *GUI) ActivateProfile(name string) error {
+	return g.mon.ActivateProfile(name)
+}
+
+// RestartAll restarts all enabled processes and reports each of them.
+func (g *GUI) RestartAll() ([]app.BulkResult, error) {
+	return g.Bulk(app.OpRestart, app.Selector{All: true})
+}
+
+// StopAll stops all processes and reports each of them.
+func (g *GUI) StopAll() ([]app.BulkResult, error) {
+	return g.Bulk(app.OpStop, app.Selector{All: true})
+}
+
+// StartGroup starts the enabled processes of a group and reports each of them.
+func (g *GUI) StartGroup(name string) ([]app.BulkResult, error) {
+	return g.Bulk(app.OpStart, app.Selector{Group: name})
+}
+
+// StopGroup stops the processes of a group and reports each of them.
+func (g *GUI) StopGroup(name string) ([]app.BulkResult, error) {
+	return g.Bulk(app.OpStop, app.Selector{Group: name})
+}
+
+// RestartGroup restarts the enabled processes of a group and reports each
+// of them.
+func (g *GUI) RestartGroup(name string) ([]app.BulkResult, error) {
+	return g.Bulk(app.OpRestart, app.Selector{Group: name})
+}
+
+// RollingRestart restarts the enabled processes of group, all of them for
+// "", a batch at a time, each batch once the previous one runs again.
+// Progress is part of the snapshot.
+func (g *GUI) RollingRestart(group string) ([]app.BulkResult, error) {
+	sel := app.Selector{Group: group, All: group == ""}
+	return g.mon.Rolling(context.Background(), sel, app.RollingOptions{})
 }
 
-// StopAll stops all enabled processes.
-func (g *GUI) StopAll() error {
-	return g.mon.StopAll()
+// EnterMaintenance leaves the processes of scope alone for duration, e.g.
+// "2h": they are neither relaunched nor killed and their errors are not
+// reported. A selection of all puts the whole monitor in maintenance.
+func (g *GUI) EnterMaintenance(duration string, scope app.Selector, reason string) error {
+	d, err := time.ParseDuration(strings.TrimSpace(duration))
+	if err != nil {
+		return fmt.Errorf("duration: %w", err)
+	}
+	return g.mon.EnterMaintenance(d, scope, reason)
+}
+
+// ExitMaintenance ends all maintenances.
+func (g *GUI) ExitMaintenance() {
+	g.mon.ExitMaintenance()
+}
+
+// Bulk runs start, stop or restart on the selected processes with the
+// bulk settings and reports each of them.
+func (g *GUI) Bulk(action string, sel app.Selector) ([]app.BulkResult, error) {
+	return g.mon.Bulk(context.Background(), action, sel, app.BulkOptions{})
 }
 
 // RestartAutoManual triggers the auto-restart sequence immediately.
@@ -258,12 +356,66 @@ func (g *GUI) GetConfigModel() (config.ConfigDTO, error) {
 	return config.ToDTO(cfg), nil
 }
 
-// SaveConfig writes config.ini and reloads it.
+// ValidateConfigModel returns errors and warnings for an edited config.
+func (g *GUI) ValidateConfigModel(dto config.ConfigDTO) config.Diagnostics {
+	return config.ValidateDTO(dto, app.ValidateOptions())
+}
+
+// SaveConfig writes config.ini and reloads it. Configs with validation
+// errors are rejected.
 func (g *GUI) SaveConfigModel(dto config.ConfigDTO) error {
+	cfg, err := config.FromDTO(dto)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(cfg, app.ValidateOptions()).Err(); err != nil {
+		return err
+	}
 	if err := config.WriteFromDTO(g.configPath, dto); err != nil {
 		return err
 	}
-	cfg, err := config.FromDTO(dto)
+	// Reload so files read next to the config, like holidays, come along.
+	if saved, err := config.Load(g.configPath); err == nil {
+		cfg = saved
+	}
+	g.mon.UpdateConfig(cfg)
+	if err := updateSchedulerScriptIfInstalled(cfg); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListConfigBackups returns archived config versions, newest first. Each
+// one is a set that also holds the included files saved with it.
+func (g *GUI) ListConfigBackups() ([]config.Backup, error) {
+	return config.ListBackups(g.configPath)
+}
+
+// DiffConfigBackup returns a line diff from a backup to the current config
+// and its included files.
+func (g *GUI) DiffConfigBackup(id string) (string, error) {
+	return config.DiffBackup(g.configPath, id)
+}
+
+// RestoreConfigBackup rolls config.ini and the included files archived with
+// it back to a backup and reloads them.
+// Backups with validation errors are refused before anything is written.
+func (g *GUI) RestoreConfigBackup(id string) error {
+	backup, err := config.LoadBackup(g.configPath, id)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(backup, app.ValidateOptions()).Err(); err != nil {
+		return fmt.Errorf("backup %s: %w", id, err)
+	}
+	keep := 0
+	if cur, err := config.Load(g.configPath); err == nil {
+		keep = cur.Settings.BackupCount
+	}
+	if err := config.RestoreBackup(g.configPath, id, keep); err != nil {
+		return err
+	}
+	cfg, err := config.Load(g.configPath)
 	if err != nil {
 		return err
 	}
@@ -3,9 +3,11 @@ module goRunFiles
 go 1.25
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/Velocidex/ordereddict v0.0.0-20250821063524-02dc06e46238
 	github.com/shirou/gopsutil/v3 v3.24.5
 	github.com/wailsapp/wails/v2 v2.11.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -6,16 +6,14 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"runtime"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"goRunFiles/internal/config"
 	"goRunFiles/internal/process"
-	"goRunFiles/internal/runner"
+	"goRunFiles/internal/schedule"
 
 	"github.com/mattn/go-runewidth"
 )
@@ -23,7 +21,9 @@ import (
 const LogTag = "[ART3D-CHEKER]:"
 
 type App struct {
-	cfg             config.Config
+	cfg             config.Config // effective config: instances expanded, profile applied
+	base            config.Config // config as loaded
+	profile         string        // active profile, "" for none
 	logger          *log.Logger
 	last            map[string]Status
 	version         string
@@ -35,31 +35,50 @@ type App struct {
 	firstStart      map[string]bool
 	hungSince       map[string]time.Time
 	manualStop      map[string]bool
-	autoRestart     autoRestartConfig
+	reasons         map[string]Reason    // why a process went down, see Reason
+	lastExit        map[string]ExitInfo  // last exit of a process on its own
+	launchedAt      map[string]time.Time // launches still within startGrace
+	schedules       []*scheduleState
+	windows         map[string]*schedule.Window // run windows by process name
+	windowOverride  map[string]bool             // started manually outside the run window
+	holidays        schedule.Holidays
+	recycleTimes    map[string]*schedule.Times // parsed restartAt by process name
+	recycles        map[string]recycleState    // planned recycles of running processes
+	jobs            map[string]*jobState
+	rolling         *RollingProgress        // running or last rolling restart, nil before the first
+	maintenance     []Maintenance           // active maintenances, see maintenance.go
+	queues          map[string]*opQueue     // operations by process name
+	watches         map[int]*exitWatch      // exit watches by PID
+	wake            chan struct{}           // asks the monitor loop for a check
+	checkEvery      time.Duration           // adaptive check interval, see adaptCheck
+	settled         bool                    // the last check found nothing to wait for
+	metrics         map[string]metricSample // last samples by process name
+	statePath       string                  // runtime state file, "" to keep none
+	pidCreatedMs    map[int]int64           // OS create times of tracked PIDs
+	stateSaved      []byte                  // last state written, guarded by stateMu
+	stateMu         sync.Mutex
 	checkProcess    bool
+	configErr       string
 	onUpdateCb      func(DisplaySnapshot)
 	mu              sync.Mutex
 }
 
-type autoRestartConfig struct {
-	enabled  bool
-	clock    dayClock
-	lastDay  int
-	parseErr error
-}
-
-type dayClock struct {
-	hour int
-	min  int
-	sec  int
-}
-
 func New(cfg config.Config, logger *log.Logger, version string) *App {
 	if logger == nil {
 		logger = log.Default()
 	}
+	base := cfg
+	profile := cfg.Settings.Profile
+	cfg, err := cfg.WithProfile(profile)
+	if err != nil {
+		logger.Printf("%s %v, running without a profile", LogTag, err)
+		profile = ""
+		cfg = base.Expanded()
+	}
 	app := &App{
 		cfg:             cfg,
+		base:            base,
+		profile:         profile,
 		logger:          logger,
 		last:            make(map[string]Status),
 		version:         version,
@@ -69,9 +88,20 @@ func New(cfg config.Config, logger *log.Logger, version string) *App {
 		firstStart:      buildFirstStartMap(cfg),
 		hungSince:       make(map[string]time.Time),
 		manualStop:      make(map[string]bool),
+		reasons:         make(map[string]Reason),
+		lastExit:        make(map[string]ExitInfo),
+		launchedAt:      make(map[string]time.Time),
+		windowOverride:  make(map[string]bool),
+		pidCreatedMs:    make(map[int]int64),
+		queues:          make(map[string]*opQueue),
+		watches:         make(map[int]*exitWatch),
+		wake:            make(chan struct{}, 1),
 		checkProcess:    true,
 	}
-	app.applyAutoRestartSettings(cfg)
+	app.applySchedules(cfg, time.Now(), true)
+	app.applyWindows(cfg)
+	app.applyRecycles(cfg)
+	app.applyJobs(cfg, time.Now())
 	if err := process.SetNetworkConfig(cfg.Settings.UseETWNetwork); err != nil {
 		logger.Printf("%s ETW network disabled: %v", LogTag, err)
 	}
@@ -92,38 +122,69 @@ func (a *App) Run(ctx context.Context) error {
 
 	hideCursor()
 	defer showCursor()
+	defer a.saveState()
 
 	now := time.Now()
-	a.maybeAutoRestart(now)
+	a.runSchedules(now)
 	statuses := a.computeStatuses(true, now)
+	a.sampleMetrics(statuses)
 	a.render(statuses)
 
-	checkTicker := time.NewTicker(a.cfg.Settings.CheckTiming.Duration)
+	checkEvery, restartEvery, metricsEvery := a.timings()
+	checkTicker := time.NewTicker(checkEvery)
 	defer checkTicker.Stop()
 
-	restartTicker := time.NewTicker(a.cfg.Settings.RestartTiming.Duration)
+	restartTicker := time.NewTicker(restartEvery)
 	defer restartTicker.Stop()
 
+	metricsTicker := time.NewTicker(metricsEvery)
+	defer metricsTicker.Stop()
+
+	// Process exits and due restarts, schedules and jobs trigger a check
+	// between the ticks, which stay as the fallback.
+	wakeTimer := time.NewTimer(time.Hour)
+	defer wakeTimer.Stop()
+	check := func() {
+		if !a.IsCheckProcessRunning() {
+			a.render(statuses)
+			return
+		}
+		now := time.Now()
+		a.runSchedules(now)
+		statuses = a.computeStatuses(true, now)
+		a.render(statuses)
+	}
+
 	for {
+		a.saveState()
+		nextCheck, nextRestart, nextMetrics := a.timings()
+		resetTicker(checkTicker, &checkEvery, nextCheck)
+		resetTicker(restartTicker, &restartEvery, nextRestart)
+		resetTicker(metricsTicker, &metricsEvery, nextMetrics)
+		a.armWake(wakeTimer)
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-checkTicker.C:
-			if !a.IsCheckProcessRunning() {
-				a.render(statuses)
+			check()
+		case <-a.wake:
+			check()
+		case <-wakeTimer.C:
+			check()
+		case <-restartTicker.C:
+			if !a.IsCheckProcessRunning() || a.isSettled() {
 				continue
 			}
 			now := time.Now()
-			a.maybeAutoRestart(now)
+			a.runSchedules(now)
 			statuses = a.computeStatuses(true, now)
-			a.render(statuses)
-		case <-restartTicker.C:
+		case <-metricsTicker.C:
 			if !a.IsCheckProcessRunning() {
 				continue
 			}
-			now := time.Now()
-			a.maybeAutoRestart(now)
-			a.computeStatuses(true, now)
+			a.sampleMetrics(statuses)
+			a.render(statuses)
 		}
 	}
 }
@@ -136,7 +197,14 @@ func (a *App) notifySnapshot(statuses []procStatus, now time.Time, checkRunning
 	}
 	onUpdate := a.onUpdateCb
 	if onUpdate != nil {
-		onUpdate(buildDisplaySnapshot(a.version, statuses, now, a.cfg.Settings.CheckTiming.Duration, a.cfg.Settings.NetUnit, process.NetSource(), process.NetSourceError(), netDbg, checkRunning))
+		snap := buildDisplaySnapshot(a.version, statuses, now, a.cfg.Settings.CheckTiming.Duration, a.cfg.Settings.NetUnit, process.NetSource(), process.NetSourceError(), netDbg, checkRunning)
+		snap.ConfigError = a.ConfigError()
+		snap.Profile, snap.Profiles = a.Profiles()
+		snap.Schedules = a.Schedules()
+		snap.Groups = a.Groups()
+		snap.Rolling = a.RollingProgress()
+		snap.Maintenance = displayMaintenance(a.Maintenances())
+		onUpdate(snap)
 	}
 }
 
@@ -156,42 +224,66 @@ func (a *App) RunWithObserver(ctx context.Context, onUpdate func(DisplaySnapshot
 	}
 
 	a.onUpdateCb = onUpdate
+	defer a.saveState()
 
 	now := time.Now()
-	a.maybeAutoRestart(now)
+	a.runSchedules(now)
 	statuses := a.computeStatuses(true, now)
+	a.sampleMetrics(statuses)
 	a.notifySnapshot(statuses, now, a.IsCheckProcessRunning())
 
-	checkTicker := time.NewTicker(a.cfg.Settings.CheckTiming.Duration)
+	checkEvery, restartEvery, metricsEvery := a.timings()
+	checkTicker := time.NewTicker(checkEvery)
 	defer checkTicker.Stop()
 
-	restartTicker := time.NewTicker(a.cfg.Settings.RestartTiming.Duration)
+	restartTicker := time.NewTicker(restartEvery)
 	defer restartTicker.Stop()
 
+	metricsTicker := time.NewTicker(metricsEvery)
+	defer metricsTicker.Stop()
+
+	// Process exits and due restarts, schedules and jobs trigger a check
+	// between the ticks, which stay as the fallback.
+	wakeTimer := time.NewTimer(time.Hour)
+	defer wakeTimer.Stop()
+	check := func() {
+		if !a.IsCheckProcessRunning() {
+			a.notifySnapshot(statuses, time.Now(), false)
+			return
+		}
+		now := time.Now()
+		a.runSchedules(now)
+		statuses = a.computeStatuses(true, now)
+		a.notifySnapshot(statuses, now, true)
+	}
+
 	for {
+		a.saveState()
+		nextCheck, nextRestart, nextMetrics := a.timings()
+		resetTicker(checkTicker, &checkEvery, nextCheck)
+		resetTicker(restartTicker, &restartEvery, nextRestart)
+		resetTicker(metricsTicker, &metricsEvery, nextMetrics)
+		a.armWake(wakeTimer)
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-checkTicker.C:
-			running := a.IsCheckProcessRunning()
-			if !running {
-				a.notifySnapshot(statuses, time.Now(), false)
-				continue
-			}
-			now := time.Now()
-			a.maybeAutoRestart(now)
-			statuses = a.computeStatuses(true, now)
-			a.notifySnapshot(statuses, now, true)
+			check()
 		case <-restartTicker.C:
-			running := a.IsCheckProcessRunning()
-			if !running {
-				a.notifySnapshot(statuses, time.Now(), false)
+			if !a.isSettled() {
+				check()
+			}
+		case <-a.wake:
+			check()
+		case <-wakeTimer.C:
+			check()
+		case <-metricsTicker.C:
+			if !a.IsCheckProcessRunning() {
 				continue
 			}
-			now := time.Now()
-			a.maybeAutoRestart(now)
-			statuses = a.computeStatuses(true, now)
-			a.notifySnapshot(statuses, now, true)
+			a.sampleMetrics(statuses)
+			a.notifySnapshot(statuses, time.Now(), true)
 		}
 	}
 }
@@ -218,201 +310,128 @@ func (a *App) IsCheckProcessRunning() bool {
 }
 
 func (a *App) computeStatuses(doRestart bool, now time.Time) []procStatus {
+	// Take copies of the items so they are checked without a.mu held.
 	a.mu.Lock()
-
-	// Periodic cleanup of stale metric samples to prevent unbounded memory growth.
-	process.CleanupStaleSamples()
-	process.CleanupETWTotals()
-
-	if a.cfg.Settings.AutoCloseErrorDialogs {
-		titles := parseCSV(a.cfg.Settings.ErrorWindowTitles)
+	closeErrors := a.cfg.Settings.AutoCloseErrorDialogs
+	var titles []string
+	if closeErrors {
+		titles = parseCSV(a.cfg.Settings.ErrorWindowTitles)
 		titles = append(titles, a.buildAutoErrorTitles()...)
-		closeErrorWindows(titles)
 	}
-
-	statuses := make([]procStatus, 0, len(a.cfg.Process))
-
 	names := make([]string, 0, len(a.cfg.Process))
 	for name := range a.cfg.Process {
 		names = append(names, name)
 	}
 	sort.Strings(names)
+	items := make([]config.ProcessItem, len(names))
+	seqs := make([]uint64, len(names))
+	starting := make([]bool, len(names))
+	for i, name := range names {
+		items[i] = *a.cfg.Process[name]
+		seqs[i] = a.queueFor(name).seq
+		_, starting[i] = a.launchedAt[name]
+	}
+	a.mu.Unlock()
 
-	gpuByPid := process.GpuStatsByPid()
-	type metricTask struct {
-		idx   int
-		typ   string
-		pid   int
-		names []string
+	if closeErrors && process.SandboxMode() == "" {
+		closeErrorWindows(titles)
 	}
-	type metricResult struct {
-		idx    int
-		cpu    float64
-		memMB  int
-		netKBs float64
-		ioKBs  float64
-		gpu    float64
-		gpuMem int
+	probes := make([]probe, len(names))
+	for i := range items {
+		if items[i].Type != config.TypeJob && !items[i].Disabled {
+			probes[i] = probeItem(&items[i])
+		}
+		if p := &probes[i]; starting[i] && !p.alive && p.pid > 0 {
+			p.pidAlive = process.IsPidAlive(p.pid)
+		}
 	}
-	metricTasks := make([]metricTask, 0, len(names))
 
-	for _, name := range names {
-		item := a.cfg.Process[name]
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireMaintenance(now)
+	statuses := make([]procStatus, 0, len(names))
+	running := make(map[string]int)
+
+	for i, name := range names {
+		item, ok := a.cfg.Process[name]
+		if !ok {
+			continue // removed while the processes were checked
+		}
 		status := procStatus{
 			Name:     name,
 			Type:     item.Type,
+			Groups:   item.GroupList(),
 			Disabled: item.Disabled,
 		}
+		if item.Type == config.TypeJob {
+			a.checkJob(name, item, &status, doRestart, now)
+			statuses = append(statuses, status)
+			continue
+		}
 		if item.Disabled {
 			status.Status = StatusDisabled
+			status.Reason = ReasonDisabled
 			status.Err = ""
 			statuses = append(statuses, status)
 			continue
 		}
 
-		var namesToCheck []string
-		var alive bool
-		switch item.Type {
-		case config.TypeExe:
-			if pathErr := validatePath(item.Path, item.Process); pathErr != "" {
-				status.Err = pathErr
-			}
-			namesToCheck = parseProcessList(item.Process, item.CheckProcess)
-			checkCmdline := strings.TrimSpace(item.CheckCmdline)
-			if checkCmdline != "" {
-				ok, pid, err := byProcessListAndCmdline(item.Process, item.CheckProcess, checkCmdline, item.CheckCmdlineExclude)
-				if err != nil {
-					status.Err = err.Error()
-				}
-				alive = ok
-				if pid > 0 {
-					item.Pid = pid
-				}
-			} else {
-				namesToCheck = parseProcessList(item.Process, item.CheckProcess)
-				for _, procName := range namesToCheck {
-					ok, pid, err := process.ByName(procName)
-					if err != nil {
-						status.Err = err.Error()
-					}
-					if ok {
-						alive = true
-						if pid > 0 {
-							item.Pid = pid
-						}
-						break
-					}
-				}
+		p := probes[i]
+		q := a.queueFor(name)
+		maint := a.inMaintenance(name, now)
+		status.Maintenance = maint
+		status.LastExit = a.lastExit[name]
+		if !p.checked || p.unknown {
+			// Enabled after the check started, or of an unknown type.
+			status.Status = StatusStopped
+			status.Err = p.err
+			status.StartedAt = "-"
+			status.Uptime = "-"
+			statuses = append(statuses, status)
+			continue
+		}
+		status.Err = p.err
+		status.Target = p.target
+		namesToCheck := p.names
+		alive := p.alive
+		if action := q.pending(); action != "" || q.seq != seqs[i] {
+			// An operation owns the process; its result shows on the next check.
+			a.fillPending(name, &status, item, alive, action, now)
+			statuses = append(statuses, status)
+			continue
+		}
+		item.Pid = p.pid
+
+		if p.hungChecked {
+			status.Hung = p.hung
+			if p.hungPid > 0 {
+				status.Pid = p.hungPid
 			}
-			status.Target = buildExeTarget(item, namesToCheck)
-			if alive && item.MonitorHang && item.HangTimeout.Duration > 0 {
-				hung := false
-				hungPid := 0
-				if strings.TrimSpace(item.CheckCmdline) != "" {
-					hung = isProcessHung(item.Pid)
-					if hung {
-						hungPid = item.Pid
-					}
-				} else {
-					hung, hungPid = hungProcessByNames(namesToCheck)
-				}
-				status.Hung = hung
-				if hungPid > 0 {
-					status.Pid = hungPid
+			if p.hung {
+				if _, ok := a.hungSince[name]; !ok {
+					a.hungSince[name] = now
 				}
-				if hung {
-					if _, ok := a.hungSince[name]; !ok {
-						a.hungSince[name] = now
-					}
-					if now.Sub(a.hungSince[name]) >= item.HangTimeout.Duration {
-						_ = process.KillByNames(namesToCheck)
-						alive = false
-						if hungPid > 0 {
-							status.Err = fmt.Sprintf("Not responding PID %d", hungPid)
-						} else {
-							status.Err = "Not responding"
-						}
-						a.restartAt[name] = now
-						delete(a.hungSince, name)
+				if now.Sub(a.hungSince[name]) >= item.HangTimeout.Duration && !maint {
+					if p.hungPid > 0 {
+						status.Err = fmt.Sprintf("Not responding PID %d", p.hungPid)
+					} else {
+						status.Err = "Not responding"
 					}
-				} else {
+					a.restartAt[name] = now
+					a.reasons[name] = ReasonNotResponding
 					delete(a.hungSince, name)
+					a.enqueue(context.Background(), name, opKill, nil)
+					a.fillPending(name, &status, item, false, opKill, now)
+					statuses = append(statuses, status)
+					continue
 				}
+			} else {
+				delete(a.hungSince, name)
 			}
-		case config.TypeCmd:
-			if strings.TrimSpace(item.CheckCmdline) != "" {
-				ok, pid, err := byProcessListAndCmdline("", item.CheckProcess, item.CheckCmdline, item.CheckCmdlineExclude)
-				if err != nil {
-					status.Err = err.Error()
-				}
-				alive = ok
-				if pid > 0 {
-					item.Pid = pid
-				}
-			} else if strings.TrimSpace(item.CheckProcess) != "" {
-				namesToCheck := parseProcessList("", item.CheckProcess)
-				for _, procName := range namesToCheck {
-					ok, pid, err := process.ByName(procName)
-					if err != nil {
-						status.Err = err.Error()
-					}
-					if ok {
-						alive = true
-						if pid > 0 {
-							item.Pid = pid
-						}
-						break
-					}
-				}
-			} else if item.Pid > 0 {
-				alive = process.IsPidAlive(item.Pid)
-			}
-			status.Target = item.Command
-		case config.TypeBat:
-			if pathErr := validatePath(item.Path, item.Process); pathErr != "" {
-				status.Err = pathErr
-			}
-			checkCmdline := strings.TrimSpace(item.CheckCmdline)
-			if checkCmdline != "" {
-				ok, pid, err := byProcessListAndCmdline("", item.CheckProcess, checkCmdline, item.CheckCmdlineExclude)
-				if err != nil {
-					status.Err = err.Error()
-				}
-				alive = ok
-				if pid > 0 {
-					item.Pid = pid
-				}
-			} else if strings.TrimSpace(item.CheckProcess) != "" {
-				namesToCheck := parseProcessList("", item.CheckProcess)
-				for _, procName := range namesToCheck {
-					ok, pid, err := process.ByName(procName)
-					if err != nil {
-						status.Err = err.Error()
-					}
-					if ok {
-						alive = true
-						if pid > 0 {
-							item.Pid = pid
-						}
-						break
-					}
-				}
-			} else if strings.TrimSpace(item.Process) != "" {
-				ok, pid, err := process.ByNameAndCmdlineArgsExact("", item.Process)
-				if err != nil {
-					status.Err = err.Error()
-				}
-				alive = ok
-				if pid > 0 {
-					item.Pid = pid
-				}
-			} else if item.Pid > 0 {
-				alive = process.IsPidAlive(item.Pid)
-			}
-			status.Target = buildBatTarget(item)
-		default:
-			status.Status = StatusStopped
-			status.Err = "unknown type: " + item.Type
+		}
+
+		if a.outsideWindow(name, now) && !maint {
+			a.keepScheduledOff(name, item, &status, alive, now)
 			statuses = append(statuses, status)
 			continue
 		}
@@ -420,22 +439,36 @@ func (a *App) computeStatuses(doRestart bool, now time.Time) []procStatus {
 		if alive {
 			if a.manualStop[name] {
 				// Manual STOP must win even if process is relaunched externally.
-				_ = stopProcessItem(item)
-				status.Status = StatusStopped
-				a.last[name] = StatusStopped
-				status.Uptime = "-"
-				status.StartedAt = "-"
+				a.enqueue(context.Background(), name, opKill, nil)
+				a.fillPending(name, &status, item, false, opKill, now)
+				status.Reason = ReasonManual
 				delete(a.restartAt, name)
 				statuses = append(statuses, status)
 				continue
 			}
-			if a.last[name] == StatusStarted {
-				status.Status = StatusStarted
-				a.last[name] = StatusRunning
-			} else {
+			r, planned := a.nextRecycle(name, item, now)
+			if doRestart && planned && !now.Before(r.at) && !maint {
+				a.recycle(name, item, r, now)
+				a.fillPending(name, &status, item, false, opKill, now)
+				status.Reason = ReasonRecycle
+				statuses = append(statuses, status)
+				continue
+			}
+			if planned {
+				status.Recycle = formatNext(r.at, now)
+			}
+			switch launched, ok := a.launchedAt[name]; {
+			case status.Hung:
+				status.Status = StatusUnhealthy
+				status.Reason = ReasonNotResponding
+			case ok && now.Sub(launched) < startWindow(item):
+				status.Status = StatusStarting
+			default:
 				status.Status = StatusRunning
-				a.last[name] = StatusRunning
+				q.failures = 0
+				delete(a.launchedAt, name)
 			}
+			a.last[name] = status.Status
 			displayPid := item.Pid
 			if status.Type == config.TypeExe && strings.TrimSpace(item.CheckCmdline) == "" {
 				displayPid = preferMonitoredPid(namesToCheck, displayPid)
@@ -448,19 +481,16 @@ func (a *App) computeStatuses(doRestart bool, now time.Time) []procStatus {
 			if status.Type == config.TypeExe && strings.TrimSpace(item.CheckCmdline) == "" {
 				metricsPid = preferShippingPid(namesToCheck, status.Pid)
 			}
-			if metricsPid > 0 {
-				var namesCopy []string
-				if strings.TrimSpace(item.CheckCmdline) == "" {
-					namesCopy = append([]string(nil), namesToCheck...)
-				}
-				metricTasks = append(metricTasks, metricTask{
-					idx:   len(statuses),
-					typ:   status.Type,
-					pid:   metricsPid,
-					names: namesCopy,
-				})
+			status.metricsPid = metricsPid
+			if strings.TrimSpace(item.CheckCmdline) == "" {
+				status.metricNames = append([]string(nil), namesToCheck...)
 			}
 			a.fillTimes(&status, now)
+			if item.Pid > 0 {
+				running[name] = item.Pid
+			}
+			q.err = ""
+			delete(a.reasons, name)
 			delete(a.restartAt, name)
 			delete(a.firstStart, name)
 			if !status.Hung {
@@ -470,277 +500,215 @@ func (a *App) computeStatuses(doRestart bool, now time.Time) []procStatus {
 			continue
 		}
 
-		status.Status = StatusStopped
-		a.last[name] = StatusStopped
-
+		status.StartedAt = "-"
+		status.Uptime = "-"
 		if a.manualStop[name] {
-			status.Uptime = "-"
-			status.StartedAt = "-"
+			status.Status = StatusStopped
+			status.Reason = ReasonManual
+			a.last[name] = StatusStopped
+			delete(a.launchedAt, name)
 			delete(a.restartAt, name)
 			statuses = append(statuses, status)
 			continue
 		}
-
-		if _, ok := a.restartAt[name]; !ok {
-			a.restartAt[name] = now.Add(a.restartDelay(name, item))
+		if maint {
+			// Left down until the maintenance ends; the launch then waits
+			// for restartTiming as after any exit.
+			status.Status = StatusMaintenance
+			a.last[name] = StatusMaintenance
+			delete(a.launchedAt, name)
+			delete(a.restartAt, name)
+			statuses = append(statuses, status)
+			continue
 		}
-
-		if doRestart && !a.restartAt[name].After(now) {
-			pid, err := runner.Start(item, a.cfg.Settings.LaunchInNewConsole)
-			if err != nil {
-				status.Err = err.Error()
-				status.Uptime = formatCountdown(a.restartAt[name].Sub(now))
-				statuses = append(statuses, status)
-				continue
-			}
-			item.Pid = pid
-			status.Pid = pid
-			status.Status = StatusStarted
-			a.last[name] = StatusStarted
-			delete(a.firstStart, name)
-			if pid > 0 {
-				a.startTimes[pid] = time.Now().UnixMilli()
-				a.fillTimes(&status, now)
+		if a.checkStart(name, item, &status, p.pid, p.pidAlive, now) {
+			statuses = append(statuses, status)
+			continue
+		}
+		if q.err != "" {
+			status.Err = q.err
+		}
+		if q.failures >= fatalLaunchFailures {
+			if a.last[name] != StatusFatal {
+				a.logger.Printf("%s %s: %d starts failed, giving up until START", LogTag, name, q.failures)
 			}
+			status.Status = StatusFatal
+			status.Reason = a.reasons[name]
+			a.last[name] = StatusFatal
 			delete(a.restartAt, name)
+			statuses = append(statuses, status)
+			continue
 		}
 
-		if status.Uptime == "" {
-			status.Uptime = formatCountdown(a.restartAt[name].Sub(now))
-			status.StartedAt = "-"
+		if _, ok := a.reasons[name]; !ok {
+			if a.last[name].alive() {
+				// Gone without a report from the exit watch.
+				a.reasons[name] = ReasonExited
+				a.lastExit[name] = ExitInfo{At: now, Pid: items[i].Pid}
+				status.LastExit = a.lastExit[name]
+			} else if a.firstStart[name] {
+				a.reasons[name] = ReasonFirstStart
+			}
+		}
+		status.Reason = a.reasons[name]
+		status.Status = StatusBackoff
+		if status.Reason == ReasonExited {
+			status.Status = StatusCrashed
 		}
+		a.last[name] = status.Status
 
+		if _, ok := a.restartAt[name]; !ok {
+			a.restartAt[name] = now.Add(a.restartDelay(name, item))
+		}
+		if doRestart && !a.restartAt[name].After(now) {
+			a.enqueue(context.Background(), name, opLaunch, nil)
+			a.fillPending(name, &status, item, false, opLaunch, now)
+			statuses = append(statuses, status)
+			continue
+		}
+		status.NextAttempt = a.restartAt[name]
 		statuses = append(statuses, status)
 	}
 
-	// Release the mutex before metric collection to avoid blocking other operations.
-	// gpuByPid is a local snapshot and statuses is only written here, so this is safe.
-	a.mu.Unlock()
-
-	if len(metricTasks) > 0 {
-		maxMetricWorkers := runtime.NumCPU()
-		if maxMetricWorkers < 4 {
-			maxMetricWorkers = 4
-		}
-		if len(metricTasks) < maxMetricWorkers {
-			maxMetricWorkers = len(metricTasks)
-		}
-		sem := make(chan struct{}, maxMetricWorkers)
-		results := make(chan metricResult, len(metricTasks))
-		var wg sync.WaitGroup
-
-		for _, task := range metricTasks {
-			task := task
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				sem <- struct{}{}
-				defer func() { <-sem }()
-
-				cpu, memMB := process.CPUAndMem(task.pid)
-				netByPID, ioByPID := process.NetIOKBs(task.pid)
-				res := metricResult{
-					idx:   task.idx,
-					cpu:   cpu,
-					memMB: memMB,
-				}
+	a.watchExits(running)
+	a.adaptCheck(statuses)
+	a.applyMetrics(statuses)
+	return statuses
+}
 
-				if task.typ == config.TypeExe && len(task.names) > 0 {
-					netByNames := process.NetKBsByNames(task.names)
-					if netByNames > netByPID {
-						res.netKBs = netByNames
-					} else {
-						res.netKBs = netByPID
-					}
+// UpdateConfig replaces the current config with a new one. Runtime state of
+// processes that did not change is preserved; removed processes are stopped
+// and, with settings.restartOnConfigChange, processes whose launch fields
+// changed are restarted through the monitor loop. Entries with instances
+// are expanded into NAME#1..NAME#N. The active profile is kept unless
+// settings.profile changed, in which case the new one is activated.
+func (a *App) UpdateConfig(cfg config.Config) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	profile := a.profile
+	if cfg.Settings.Profile != a.base.Settings.Profile {
+		profile = cfg.Settings.Profile
+	}
+	eff, err := cfg.WithProfile(profile)
+	if err != nil {
+		a.logger.Printf("%s %v, running without a profile", LogTag, err)
+		profile = ""
+		eff = cfg.Expanded()
+	}
+	switching := profile != a.profile
+	a.base = cfg
+	a.profile = profile
+	a.applyConfig(eff, switching)
+}
 
-					ioByNames := process.IOKBsByNames(task.names)
-					if ioByNames > ioByPID {
-						res.ioKBs = ioByNames
-					} else {
-						res.ioKBs = ioByPID
-					}
-				} else {
-					res.netKBs = netByPID
-					res.ioKBs = ioByPID
-				}
+// applyConfig switches to an effective config. With switching set, as when
+// a profile is activated, processes that became disabled are stopped and
+// changed ones are restarted regardless of settings.restartOnConfigChange.
+// The caller holds a.mu.
+func (a *App) applyConfig(cfg config.Config, switching bool) configDiff {
+	oldCfg := a.cfg
+	diff := diffConfig(oldCfg, cfg)
+	now := time.Now()
 
-				if gpu, ok := gpuByPid[task.pid]; ok {
-					res.gpu = gpu.Util
-					res.gpuMem = gpu.MemMB
-				}
-				results <- res
-			}()
+	for _, name := range diff.Removed {
+		a.cancelJob(name)
+		a.enqueue(context.Background(), name, opKill, oldCfg.Process[name])
+		a.forgetProcess(name)
+	}
+	for _, name := range diff.Unchanged {
+		cfg.Process[name].Pid = oldCfg.Process[name].Pid
+	}
+	for _, name := range diff.Updated {
+		oldItem, newItem := oldCfg.Process[name], cfg.Process[name]
+		newItem.Pid = oldItem.Pid
+		if oldItem.Disabled && !newItem.Disabled {
+			a.firstStart[name] = true
+			if switching {
+				delete(a.manualStop, name)
+			}
 		}
-
-		wg.Wait()
-		close(results)
-		for res := range results {
-			if res.idx < 0 || res.idx >= len(statuses) {
+		if !newItem.MonitorHang {
+			delete(a.hungSince, name)
+		}
+	}
+	if switching {
+		for _, name := range append(append([]string{}, diff.Updated...), diff.Relaunch...) {
+			oldItem, newItem := oldCfg.Process[name], cfg.Process[name]
+			if oldItem.Disabled || !newItem.Disabled {
 				continue
 			}
-			statuses[res.idx].Cpu = res.cpu
-			statuses[res.idx].MemMB = res.memMB
-			statuses[res.idx].NetKBs = res.netKBs
-			statuses[res.idx].IOKBs = res.ioKBs
-			statuses[res.idx].Gpu = res.gpu
-			statuses[res.idx].GpuMemMB = res.gpuMem
+			a.enqueue(context.Background(), name, opKill, oldItem)
+			delete(a.restartAt, name)
+			delete(a.hungSince, name)
+		}
+	}
+	for _, name := range diff.Relaunch {
+		oldItem, newItem := oldCfg.Process[name], cfg.Process[name]
+		if !(cfg.Settings.RestartOnConfigChange || switching) || newItem.Disabled || a.manualStop[name] {
+			newItem.Pid = oldItem.Pid
+			continue
+		}
+		a.enqueue(context.Background(), name, opKill, oldItem)
+		delete(a.hungSince, name)
+		a.restartAt[name] = now
+		a.reasons[name] = ReasonConfig
+	}
+	for _, name := range append(append([]string{}, diff.Updated...), diff.Relaunch...) {
+		// The new settings may fix what kept the launches failing.
+		a.queueFor(name).failures = 0
+	}
+	for _, name := range diff.Added {
+		a.forgetProcess(name)
+		if !cfg.Process[name].Disabled {
+			a.firstStart[name] = true
 		}
 	}
 
-	return statuses
-}
-
-// UpdateConfig replaces the current config with a new one.
-func (a *App) UpdateConfig(cfg config.Config) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-	oldManualStop := a.manualStop
 	a.cfg = cfg
-	a.last = make(map[string]Status)
+	a.configErr = ""
 	a.defaultDisabled = buildDefaultDisabledMap(cfg)
-	a.startTimes = make(map[int]int64)
-	a.restartAt = make(map[string]time.Time)
-	a.firstStart = buildFirstStartMap(cfg)
-	a.hungSince = make(map[string]time.Time)
-	a.manualStop = make(map[string]bool)
-	for name := range cfg.Process {
-		if oldManualStop[name] {
-			a.manualStop[name] = true
-		}
-	}
-	a.applyAutoRestartSettings(cfg)
+	switch {
+	case switching:
+		a.logger.Printf("%s profile %q activated: %s", LogTag, a.profile, diff)
+	case !diff.empty():
+		a.logger.Printf("%s config reloaded: %s", LogTag, diff)
+	}
+	if len(diff.Relaunch) > 0 && !cfg.Settings.RestartOnConfigChange && !switching {
+		a.logger.Printf("%s launch settings changed, restart to apply: %s", LogTag, joinNames(diff.Relaunch))
+	}
+	a.applySchedules(cfg, now, false)
+	a.applyWindows(cfg)
+	a.applyRecycles(cfg)
+	a.applyJobs(cfg, now)
 	if err := process.SetNetworkConfig(cfg.Settings.UseETWNetwork); err != nil {
 		a.logger.Printf("%s ETW network disabled: %v", LogTag, err)
 	}
 	process.SetNetworkScale(cfg.Settings.NetScale)
+	return diff
 }
 
-// StartProcess starts a process by config name.
+// StartProcess starts a process by config name and waits for it.
 func (a *App) StartProcess(name string) error {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-	item, ok := a.cfg.Process[name]
-	if !ok {
-		return fmt.Errorf("process %q not found", name)
-	}
-	alive, pid, err := isProcessItemAlive(item)
-	if err != nil {
-		return err
-	}
-	if alive {
-		if pid > 0 {
-			item.Pid = pid
-		}
-		// Keep state in sync when process is already running.
-		item.Disabled = false
-		delete(a.manualStop, name)
-		delete(a.restartAt, name)
-		delete(a.firstStart, name)
-		delete(a.hungSince, name)
-		a.last[name] = StatusRunning
-		return fmt.Errorf("process %q is already running", name)
-	}
-	// Manual START enables the process so it enters regular monitoring.
-	item.Disabled = false
-	pid, err = runner.Start(item, a.cfg.Settings.LaunchInNewConsole)
-	if err != nil {
-		return err
-	}
-	item.Pid = pid
-	a.last[name] = StatusStarted
-	delete(a.manualStop, name)
-	delete(a.firstStart, name)
-	return nil
+	return a.Start(context.Background(), name).Wait(context.Background())
 }
 
-// StopProcess stops a process by config name.
+// StopProcess stops a process by config name and waits for it.
 func (a *App) StopProcess(name string) error {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-	item, ok := a.cfg.Process[name]
-	if !ok {
-		return fmt.Errorf("process %q not found", name)
-	}
-	if a.defaultDisabled[name] {
-		item.Disabled = true
-	}
-	a.manualStop[name] = true
-	delete(a.restartAt, name)
-	delete(a.firstStart, name)
-	return stopProcessItem(item)
+	return a.Stop(context.Background(), name).Wait(context.Background())
 }
 
-// RestartProcess restarts a process by config name.
+// RestartProcess restarts a process by config name and waits for it.
 func (a *App) RestartProcess(name string) error {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	item, ok := a.cfg.Process[name]
-	if !ok {
-		return fmt.Errorf("process %q not found", name)
-	}
-
-	// Manual RESTART should not leave the process in manual-stop mode.
-	delete(a.manualStop, name)
-	delete(a.restartAt, name)
-	delete(a.firstStart, name)
-	delete(a.hungSince, name)
-	// Explicit restart enables the process for regular monitoring.
-	item.Disabled = false
-
-	if err := stopProcessItem(item); err != nil {
-		return err
-	}
-
-	pid, err := runner.Start(item, a.cfg.Settings.LaunchInNewConsole)
-	if err != nil {
-		a.last[name] = StatusStopped
-		return err
-	}
-
-	item.Pid = pid
-	a.last[name] = StatusStarted
-	delete(a.firstStart, name)
-	if pid > 0 {
-		a.startTimes[pid] = time.Now().UnixMilli()
-	}
-	return nil
+	return a.Restart(context.Background(), name).Wait(context.Background())
 }
 
-// RestartAll restarts all enabled processes.
+// RestartAll restarts all enabled processes, see Bulk. Jobs are left alone.
 func (a *App) RestartAll() error {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	var lastErr error
-	a.manualStop = make(map[string]bool)
-	// stop enabled
-	for _, item := range a.cfg.Process {
-		if item.Disabled {
-			continue
-		}
-		if err := stopProcessItem(item); err != nil {
-			lastErr = err
-		}
-	}
-	// start enabled
-	for name, item := range a.cfg.Process {
-		if item.Disabled {
-			continue
-		}
-		pid, err := runner.Start(item, a.cfg.Settings.LaunchInNewConsole)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-		item.Pid = pid
-		a.last[name] = StatusStarted
-		delete(a.firstStart, name)
-		if pid > 0 {
-			a.startTimes[pid] = time.Now().UnixMilli()
-		}
+	results, err := a.Bulk(context.Background(), OpRestart, Selector{All: true}, BulkOptions{})
+	if err != nil {
+		return err
 	}
-	return lastErr
+	return BulkError(results)
 }
 
 // restartAllDelayed stops all enabled processes and schedules their restart,
@@ -748,20 +716,11 @@ func (a *App) RestartAll() error {
 // scheduled to start immediately; actual launching happens in the monitor loop.
 func (a *App) restartAllDelayed(now time.Time) error {
 	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	var lastErr error
 	a.manualStop = make(map[string]bool)
-	for _, item := range a.cfg.Process {
-		if item.Disabled {
-			continue
-		}
-		if err := stopProcessItem(item); err != nil {
-			lastErr = err
-		}
-	}
-	for name, item := range a.cfg.Process {
-		if item.Disabled {
+	var ops []*Op
+	for _, name := range sortedProcessNames(a.cfg) {
+		item := a.cfg.Process[name]
+		if item.Disabled || item.Type == config.TypeJob {
 			continue
 		}
 		if d := item.DelayStartTime.Duration; d > 0 {
@@ -769,13 +728,16 @@ func (a *App) restartAllDelayed(now time.Time) error {
 		} else {
 			a.restartAt[name] = now
 		}
+		a.reasons[name] = ReasonRestartAll
+		ops = append(ops, a.enqueue(context.Background(), name, opKill, nil))
 	}
-	return lastErr
+	a.mu.Unlock()
+	return waitOps(context.Background(), ops)
 }
 
-// RestartAutoManual triggers the auto-restart sequence immediately,
-// bypassing the daily clock guard. Useful for testing the auto-restart
-// mechanics and DelayStartTime behaviour.
+// RestartAutoManual runs the restart-all schedule action immediately,
+// outside any schedule. Useful for testing the restart mechanics and
+// DelayStartTime behaviour.
 func (a *App) RestartAutoManual() error {
 	return a.restartAllDelayed(time.Now())
 }
@@ -795,42 +757,43 @@ func (a *App) GetProcessPath(name string) (string, error) {
 	return path, nil
 }
 
-// StopAll stops all configured processes (including disabled).
+// StopAll stops all configured processes (including disabled), see Bulk.
 func (a *App) StopAll() error {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-	var lastErr error
-	for name, item := range a.cfg.Process {
-		if err := stopProcessItem(item); err != nil {
-			lastErr = err
-		}
-		if a.defaultDisabled[name] {
-			item.Disabled = true
-		}
-		a.manualStop[name] = true
-		delete(a.restartAt, name)
-		delete(a.firstStart, name)
+	results, err := a.Bulk(context.Background(), OpStop, Selector{All: true}, BulkOptions{})
+	if err != nil {
+		return err
 	}
-	return lastErr
+	return BulkError(results)
 }
 
 type procStatus struct {
-	Name      string
-	Type      string
-	Status    Status
-	Disabled  bool
-	Target    string
-	Pid       int
-	StartedAt string
-	Uptime    string
-	Hung      bool
-	Cpu       float64
-	Gpu       float64
-	GpuMemMB  int
-	MemMB     int
-	NetKBs    float64
-	IOKBs     float64
-	Err       string
+	Name        string
+	Type        string
+	Groups      []string
+	Status      Status
+	Reason      Reason
+	NextAttempt time.Time // next launch, or when the run window opens
+	NextRun     time.Time // next cron run of an idle job
+	JobPaused   bool      // a job stopped by hand, its cron paused until START
+	LastExit    ExitInfo  // zero if the process never exited on its own
+	Disabled    bool
+	Maintenance bool // the process is in a maintenance, see maintenance.go
+	Target      string
+	Pid         int
+	StartedAt   string
+	Uptime      string
+	Recycle     string
+	Pending     string   // operation queued or running, see ops.go
+	metricsPid  int      // PID sampled for metrics, 0 for none
+	metricNames []string // exe process names whose network and I/O add up
+	Hung        bool
+	Cpu         float64
+	Gpu         float64
+	GpuMemMB    int
+	MemMB       int
+	NetKBs      float64
+	IOKBs       float64
+	Err         string
 }
 
 func (s procStatus) pidString() string {
@@ -892,14 +855,14 @@ func byProcessListAndCmdline(defaultProcess, checkProcess, checkCmdline, exclude
 	return false, 0, lastErr
 }
 
-func killByProcessListAndCmdline(defaultProcess, checkProcess, checkCmdline, exclude string) error {
+func killByProcessListAndCmdline(ctx context.Context, defaultProcess, checkProcess, checkCmdline, exclude string) error {
 	names := parseProcessList(defaultProcess, checkProcess)
 	if len(names) == 0 {
 		names = []string{""}
 	}
 	var lastErr error
 	for _, name := range names {
-		if err := process.KillByNameAndCmdlineArgsExactWithExclude(name, checkCmdline, exclude); err != nil {
+		if err := process.KillByNameAndCmdlineArgsExactWithExcludeContext(ctx, name, checkCmdline, exclude); err != nil {
 			lastErr = err
 		}
 	}
@@ -1031,6 +994,19 @@ func (a *App) getStartTime(pid int) (time.Time, bool) {
 	return t, true
 }
 
+// formatNext shows a coming time briefly: the clock for today, the weekday
+// within a week, the date otherwise.
+func formatNext(t, now time.Time) string {
+	switch {
+	case t.YearDay() == now.YearDay() && t.Year() == now.Year():
+		return t.Format("15:04")
+	case t.Sub(now) < 6*24*time.Hour:
+		return t.Format("Mon 15:04")
+	default:
+		return t.Format("01-02 15:04")
+	}
+}
+
 func formatUptime(d time.Duration) string {
 	if d < 0 {
 		return "-"
@@ -1069,98 +1045,6 @@ func buildExeTarget(item *config.ProcessItem, namesToCheck []string) string {
 	return item.Process
 }
 
-func (a *App) applyAutoRestartSettings(cfg config.Config) {
-	a.autoRestart.enabled = cfg.Settings.AutoRestart
-	a.autoRestart.lastDay = 0
-	a.autoRestart.parseErr = nil
-	a.autoRestart.clock = dayClock{}
-	if !a.autoRestart.enabled {
-		return
-	}
-	clock, err := parseDayClock(cfg.Settings.AutoRestartTime)
-	if err != nil {
-		a.autoRestart.parseErr = err
-		return
-	}
-	a.autoRestart.clock = clock
-}
-
-func (a *App) maybeAutoRestart(now time.Time) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	if !a.autoRestart.enabled {
-		return
-	}
-	if a.autoRestart.parseErr != nil {
-		a.logger.Printf("%s autorestart disabled: %v", LogTag, a.autoRestart.parseErr)
-		a.autoRestart.enabled = false
-		return
-	}
-	today := dateKey(now)
-	if a.autoRestart.lastDay == today {
-		return
-	}
-	target := time.Date(now.Year(), now.Month(), now.Day(), a.autoRestart.clock.hour, a.autoRestart.clock.min, a.autoRestart.clock.sec, 0, now.Location())
-	// Cold start: first check after launch. Must run BEFORE the
-	// now.Before(target) guard, otherwise lastDay stays 0 until the
-	// target time and then the cold-start skip fires instead of the restart.
-	if a.autoRestart.lastDay == 0 {
-		if now.Before(target) {
-			// Started before target — permit today's trigger.
-			a.autoRestart.lastDay = -1
-		} else {
-			// Started after target — skip today.
-			a.autoRestart.lastDay = today
-		}
-		return
-	}
-	if now.Before(target) {
-		return
-	}
-	// Release lock during restart to avoid holding it across process operations.
-	a.mu.Unlock()
-	if err := a.restartAllDelayed(now); err != nil {
-		a.logger.Printf("%s autorestart error: %v", LogTag, err)
-	}
-	a.mu.Lock()
-	a.autoRestart.lastDay = today
-}
-
-func parseDayClock(raw string) (dayClock, error) {
-	s := strings.TrimSpace(raw)
-	if s == "" {
-		return dayClock{}, fmt.Errorf("autoRestartTime is empty")
-	}
-	parts := strings.Split(s, ":")
-	if len(parts) != 2 && len(parts) != 3 {
-		return dayClock{}, fmt.Errorf("autoRestartTime must be HH:MM or HH:MM:SS")
-	}
-	h, err := strconv.Atoi(strings.TrimSpace(parts[0]))
-	if err != nil {
-		return dayClock{}, fmt.Errorf("autoRestartTime hour invalid")
-	}
-	m, err := strconv.Atoi(strings.TrimSpace(parts[1]))
-	if err != nil {
-		return dayClock{}, fmt.Errorf("autoRestartTime minute invalid")
-	}
-	sec := 0
-	if len(parts) == 3 {
-		sec, err = strconv.Atoi(strings.TrimSpace(parts[2]))
-		if err != nil {
-			return dayClock{}, fmt.Errorf("autoRestartTime second invalid")
-		}
-	}
-	if h < 0 || h > 23 || m < 0 || m > 59 || sec < 0 || sec > 59 {
-		return dayClock{}, fmt.Errorf("autoRestartTime out of range")
-	}
-	return dayClock{hour: h, min: m, sec: sec}, nil
-}
-
-func dateKey(t time.Time) int {
-	return t.Year()*10000 + int(t.Month())*100 + t.Day()
-}
-
 func hungProcessByNames(names []string) (bool, int) {
 	for _, n := range names {
 		n = strings.TrimSpace(n)
@@ -1184,6 +1068,10 @@ func validatePath(dir, name string) string {
 	if strings.TrimSpace(dir) == "" || strings.TrimSpace(name) == "" {
 		return ""
 	}
+	// A recorded table comes from another machine, its paths are not here.
+	if process.SandboxMode() == process.ModeSimulation {
+		return ""
+	}
 	full := filepath.Join(dir, name)
 	if _, err := os.Stat(full); err != nil {
 		if os.IsNotExist(err) {
@@ -1194,23 +1082,25 @@ func validatePath(dir, name string) string {
 	return ""
 }
 
-func stopProcessItem(item *config.ProcessItem) error {
+// stopProcessItem kills the processes of item. Once ctx is done the kills
+// not yet made are given up.
+func stopProcessItem(ctx context.Context, item *config.ProcessItem) error {
 	switch item.Type {
 	case config.TypeExe:
 		var lastErr error
 		// Prefer killing the tracked root pid first (tree kill on Windows).
 		if item.Pid > 0 {
-			if err := process.KillPid(item.Pid); err != nil {
+			if err := process.KillPidContext(ctx, item.Pid); err != nil {
 				lastErr = err
 			}
 		}
 		if strings.TrimSpace(item.CheckCmdline) != "" {
-			if err := killByProcessListAndCmdline(item.Process, item.CheckProcess, item.CheckCmdline, item.CheckCmdlineExclude); err != nil {
+			if err := killByProcessListAndCmdline(ctx, item.Process, item.CheckProcess, item.CheckCmdline, item.CheckCmdlineExclude); err != nil {
 				lastErr = err
 			}
 		} else {
 			names := parseProcessList(item.Process, item.CheckProcess)
-			if err := process.KillByNames(names); err != nil {
+			if err := process.KillByNamesContext(ctx, names); err != nil {
 				lastErr = err
 			}
 		}
@@ -1218,7 +1108,7 @@ func stopProcessItem(item *config.ProcessItem) error {
 		return lastErr
 	case config.TypeCmd, config.TypeBat:
 		if strings.TrimSpace(item.CheckCmdline) != "" {
-			if err := killByProcessListAndCmdline("", item.CheckProcess, item.CheckCmdline, item.CheckCmdlineExclude); err != nil {
+			if err := killByProcessListAndCmdline(ctx, "", item.CheckProcess, item.CheckCmdline, item.CheckCmdlineExclude); err != nil {
 				return err
 			}
 			item.Pid = 0
@@ -1226,24 +1116,194 @@ func stopProcessItem(item *config.ProcessItem) error {
 		}
 		if strings.TrimSpace(item.CheckProcess) != "" {
 			names := parseProcessList("", item.CheckProcess)
-			if err := process.KillByNames(names); err != nil {
+			if err := process.KillByNamesContext(ctx, names); err != nil {
 				return err
 			}
 			item.Pid = 0
 			return nil
 		}
 		if item.Pid > 0 {
-			if err := process.KillPid(item.Pid); err != nil {
+			if err := process.KillPidContext(ctx, item.Pid); err != nil {
 				return err
 			}
 			item.Pid = 0
 		}
 		return nil
+	case config.TypeJob:
+		// Job runs are killed through App.cancelJob.
+		return nil
 	default:
 		return fmt.Errorf("unknown type %q", item.Type)
 	}
 }
 
+// probe is what a check found out about a process. It is worked out on a
+// copy of the item, without a.mu held.
+type probe struct {
+	checked     bool
+	unknown     bool // unknown item type
+	alive       bool
+	pid         int // PID of the item after the check
+	err         string
+	target      string
+	names       []string // process names checked
+	hungChecked bool
+	hung        bool
+	hungPid     int
+	pidAlive    bool // the launched PID runs though the checks did not find it
+}
+
+// probeItem checks whether a process runs and, with monitorHang, whether
+// it responds. The PID found is stored in item.
+func probeItem(item *config.ProcessItem) probe {
+	p := probe{checked: true}
+	switch item.Type {
+	case config.TypeExe:
+		if pathErr := validatePath(item.Path, item.Process); pathErr != "" {
+			p.err = pathErr
+		}
+		p.names = parseProcessList(item.Process, item.CheckProcess)
+		checkCmdline := strings.TrimSpace(item.CheckCmdline)
+		if checkCmdline != "" {
+			ok, pid, err := byProcessListAndCmdline(item.Process, item.CheckProcess, checkCmdline, item.CheckCmdlineExclude)
+			if err != nil {
+				p.err = err.Error()
+			}
+			p.alive = ok
+			if pid > 0 {
+				item.Pid = pid
+			}
+		} else {
+			for _, procName := range p.names {
+				ok, pid, err := process.ByName(procName)
+				if err != nil {
+					p.err = err.Error()
+				}
+				if ok {
+					p.alive = true
+					if pid > 0 {
+						item.Pid = pid
+					}
+					break
+				}
+			}
+		}
+		p.target = buildExeTarget(item, p.names)
+		if p.alive && item.MonitorHang && item.HangTimeout.Duration > 0 {
+			p.hungChecked = true
+			if checkCmdline != "" {
+				if p.hung = isProcessHung(item.Pid); p.hung {
+					p.hungPid = item.Pid
+				}
+			} else {
+				p.hung, p.hungPid = hungProcessByNames(p.names)
+			}
+		}
+	case config.TypeCmd:
+		if strings.TrimSpace(item.CheckCmdline) != "" {
+			ok, pid, err := byProcessListAndCmdline("", item.CheckProcess, item.CheckCmdline, item.CheckCmdlineExclude)
+			if err != nil {
+				p.err = err.Error()
+			}
+			p.alive = ok
+			if pid > 0 {
+				item.Pid = pid
+			}
+		} else if strings.TrimSpace(item.CheckProcess) != "" {
+			for _, procName := range parseProcessList("", item.CheckProcess) {
+				ok, pid, err := process.ByName(procName)
+				if err != nil {
+					p.err = err.Error()
+				}
+				if ok {
+					p.alive = true
+					if pid > 0 {
+						item.Pid = pid
+					}
+					break
+				}
+			}
+		} else if item.Pid > 0 {
+			p.alive = process.IsPidAlive(item.Pid)
+		}
+		p.target = item.Command
+	case config.TypeBat:
+		if pathErr := validatePath(item.Path, item.Process); pathErr != "" {
+			p.err = pathErr
+		}
+		checkCmdline := strings.TrimSpace(item.CheckCmdline)
+		if checkCmdline != "" {
+			ok, pid, err := byProcessListAndCmdline("", item.CheckProcess, checkCmdline, item.CheckCmdlineExclude)
+			if err != nil {
+				p.err = err.Error()
+			}
+			p.alive = ok
+			if pid > 0 {
+				item.Pid = pid
+			}
+		} else if strings.TrimSpace(item.CheckProcess) != "" {
+			for _, procName := range parseProcessList("", item.CheckProcess) {
+				ok, pid, err := process.ByName(procName)
+				if err != nil {
+					p.err = err.Error()
+				}
+				if ok {
+					p.alive = true
+					if pid > 0 {
+						item.Pid = pid
+					}
+					break
+				}
+			}
+		} else if strings.TrimSpace(item.Process) != "" {
+			ok, pid, err := process.ByNameAndCmdlineArgsExact("", item.Process)
+			if err != nil {
+				p.err = err.Error()
+			}
+			p.alive = ok
+			if pid > 0 {
+				item.Pid = pid
+			}
+		} else if item.Pid > 0 {
+			p.alive = process.IsPidAlive(item.Pid)
+		}
+		p.target = buildBatTarget(item)
+	default:
+		p.unknown = true
+		p.err = "unknown type: " + item.Type
+	}
+	p.pid = item.Pid
+	return p
+}
+
+// fillPending fills the status of a process an operation is working on.
+// The caller holds a.mu.
+func (a *App) fillPending(name string, status *procStatus, item *config.ProcessItem, alive bool, action string, now time.Time) {
+	status.Pending = action
+	status.Reason = a.reasons[name]
+	status.StartedAt = "-"
+	status.Uptime = "-"
+	switch action {
+	case OpStart, opLaunch:
+		status.Status = StatusStarting
+	case OpStop, opKill, OpRestart:
+		status.Status = StatusStopping
+	default:
+		// Finished between the probe and now; the next check tells.
+		status.Status = a.last[name]
+		if status.Status == "" {
+			status.Status = StatusStopped
+			if alive {
+				status.Status = StatusRunning
+			}
+		}
+	}
+	if alive {
+		status.Pid = item.Pid
+		a.fillTimes(status, now)
+	}
+}
+
 func isProcessItemAlive(item *config.ProcessItem) (bool, int, error) {
 	switch item.Type {
 	case config.TypeExe:
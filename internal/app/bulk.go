@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"goRunFiles/internal/config"
+)
+
+// Selector picks the processes of a bulk operation.
+type Selector struct {
+	All bool `json:"all"`
+	// Names are config names; the name of an entry with instances selects
+	// all of its NAME#N copies.
+	Names []string `json:"names"`
+	// Group adds the processes listed in the group, see config groups.
+	Group string `json:"group"`
+}
+
+// BulkOptions tune a bulk operation. Zero values take settings.bulkParallel
+// and settings.bulkStagger.
+type BulkOptions struct {
+	Parallel int           // operations at a time
+	Stagger  time.Duration // pause before each next operation is started
+}
+
+// BulkResult is the outcome of a bulk operation for one process.
+type BulkResult struct {
+	Name       string `json:"name"`
+	Error      string `json:"error,omitempty"`
+	Skipped    string `json:"skipped,omitempty"` // why the process was left alone
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Bulk runs start, stop or restart on the selected processes, at most
+// Parallel at a time and Stagger apart, and reports each of them in
+// name order. For a selection of all processes or of a group, start and
+// restart leave disabled processes, jobs and processes outside their run
+// window alone, as does the monitor. ctx bounds the wait: operations
+// already handed to their process go on after it ends, the others are
+// dropped.
+func (a *App) Bulk(ctx context.Context, action string, sel Selector, opts BulkOptions) ([]BulkResult, error) {
+	switch action {
+	case OpStart, OpStop, OpRestart:
+	default:
+		return nil, fmt.Errorf("unknown operation %q", action)
+	}
+	a.mu.Lock()
+	names, err := a.selectProcesses(sel)
+	if err != nil {
+		a.mu.Unlock()
+		return nil, err
+	}
+	if opts.Parallel <= 0 {
+		opts.Parallel = a.cfg.Settings.BulkParallel
+	}
+	if opts.Stagger <= 0 {
+		opts.Stagger = a.cfg.Settings.BulkStagger.Duration
+	}
+	if sel.All && action == OpRestart {
+		// Restarting everything also lifts manual stops of the processes
+		// it skips, so they come back with their run window. Jobs stopped
+		// by hand stay paused until START.
+		for name := range a.manualStop {
+			if item := a.cfg.Process[name]; item == nil || item.Type != config.TypeJob {
+				delete(a.manualStop, name)
+			}
+		}
+	}
+	now := time.Now()
+	results := make([]BulkResult, len(names))
+	var todo []int
+	for i, name := range names {
+		results[i].Name = name
+		if sel.All || sel.Group != "" {
+			if results[i].Skipped = a.bulkSkip(name, action, now); results[i].Skipped != "" {
+				continue
+			}
+		}
+		todo = append(todo, i)
+	}
+	a.mu.Unlock()
+
+	if opts.Parallel <= 0 || opts.Parallel > len(todo) {
+		opts.Parallel = max(len(todo), 1)
+	}
+	sem := make(chan struct{}, opts.Parallel)
+	var wg sync.WaitGroup
+	for n, i := range todo {
+		if n > 0 && opts.Stagger > 0 {
+			select {
+			case <-time.After(opts.Stagger):
+			case <-ctx.Done():
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		wg.Add(1)
+		go func(r *BulkResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			start := time.Now()
+			err := a.submit(context.Background(), r.Name, action).Wait(ctx)
+			r.DurationMs = time.Since(start).Milliseconds()
+			switch {
+			case errors.Is(err, errAlreadyRunning):
+				r.Skipped = "already running"
+			case err != nil:
+				r.Error = err.Error()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// selectProcesses resolves a selector to sorted, distinct process names.
+// The caller holds a.mu.
+func (a *App) selectProcesses(sel Selector) ([]string, error) {
+	if sel.All {
+		return sortedProcessNames(a.cfg), nil
+	}
+	group := strings.TrimSpace(sel.Group)
+	if len(sel.Names) == 0 && group == "" {
+		return nil, fmt.Errorf("no processes selected")
+	}
+	seen := make(map[string]bool)
+	if group != "" {
+		for name, item := range a.cfg.Process {
+			if item.InGroup(group) {
+				seen[name] = true
+			}
+		}
+		if len(seen) == 0 {
+			return nil, fmt.Errorf("group %q not found", group)
+		}
+	}
+	for _, name := range sel.Names {
+		name = strings.TrimSpace(name)
+		if _, ok := a.cfg.Process[name]; ok {
+			seen[name] = true
+			continue
+		}
+		found := false
+		for n := range a.cfg.Process {
+			if base, _ := config.BaseName(n); base == name {
+				seen[n], found = true, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("process %q not found", name)
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Groups returns the sorted names of the process groups.
+func (a *App) Groups() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.cfg.Groups()
+}
+
+// describeGroups lists the process groups and their members for ctl.
+func (a *App) describeGroups() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	groups := a.cfg.Groups()
+	if len(groups) == 0 {
+		return "no groups defined"
+	}
+	var b strings.Builder
+	for _, g := range groups {
+		var members []string
+		for _, name := range sortedProcessNames(a.cfg) {
+			if a.cfg.Process[name].InGroup(g) {
+				members = append(members, name)
+			}
+		}
+		fmt.Fprintf(&b, "%-16s %s\n", g, strings.Join(members, ", "))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// bulkSkip tells why a bulk operation over all processes or a group leaves
+// one alone, "" to include it. The caller holds a.mu.
+func (a *App) bulkSkip(name, action string, now time.Time) string {
+	if action == OpStop {
+		return ""
+	}
+	item := a.cfg.Process[name]
+	switch {
+	case item.Type == config.TypeJob:
+		return "job"
+	case item.Disabled:
+		return "disabled"
+	case a.outsideWindow(name, now):
+		return "outside run window"
+	}
+	return ""
+}
+
+// BulkError sums up the failures in results, nil if there are none.
+func BulkError(results []BulkResult) error {
+	var failed []string
+	for _, r := range results {
+		if r.Error != "" {
+			failed = append(failed, r.Name+": "+r.Error)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// bulkTestINI has one process of each kind a bulk start over all
+// processes leaves alone, next to ones it starts. W only runs on
+// holidays and there are none, so its window stays closed.
+const bulkTestINI = `
+[process "A"]
+type=exe
+path=C:/apps
+process=a.exe
+groups=show
+
+[process "B"]
+type=exe
+path=C:/apps
+process=b.exe
+disabled=true
+groups=show
+
+[process "I"]
+type=exe
+path=C:/apps
+process=i.exe
+instances=2
+checkCmdline="--slot {{.Index}}"
+args="--slot {{.Index}}"
+
+[process "J"]
+type=job
+command=backup.cmd
+groups=show
+
+[process "W"]
+type=exe
+path=C:/apps
+process=w.exe
+runWindow="Hol 10:00-11:00"
+groups=show
+`
+
+// bulkOutcome is the part of a BulkResult the tests compare.
+type bulkOutcome struct {
+	name, err, skipped string
+}
+
+func outcomes(results []BulkResult) []bulkOutcome {
+	out := make([]bulkOutcome, len(results))
+	for i, r := range results {
+		out[i] = bulkOutcome{r.Name, r.Error, r.Skipped}
+	}
+	return out
+}
+
+func TestBulk(t *testing.T) {
+	a, l := newTestApp(t, bulkTestINI)
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		action string
+		sel    Selector
+		want   []bulkOutcome
+	}{
+		{"start all", OpStart, Selector{All: true}, []bulkOutcome{
+			{"A", "", ""},
+			{"B", "", "disabled"},
+			{"I#1", "", ""},
+			{"I#2", "", ""},
+			{"J", "", "job"},
+			{"W", "", "outside run window"},
+		}},
+		{"start all again", OpStart, Selector{All: true}, []bulkOutcome{
+			{"A", "", "already running"},
+			{"B", "", "disabled"},
+			{"I#1", "", "already running"},
+			{"I#2", "", "already running"},
+			{"J", "", "job"},
+			{"W", "", "outside run window"},
+		}},
+		{"restart group", OpRestart, Selector{Group: "show"}, []bulkOutcome{
+			{"A", "", ""},
+			{"B", "", "disabled"},
+			{"J", "", "job"},
+			{"W", "", "outside run window"},
+		}},
+		{"stop group skips nothing", OpStop, Selector{Group: "show"}, []bulkOutcome{
+			{"A", "", ""},
+			{"B", "", ""},
+			{"J", "", ""},
+			{"W", "", ""},
+		}},
+		{"named processes are not skipped", OpStart, Selector{Names: []string{"W", "I"}}, []bulkOutcome{
+			{"I#1", "", "already running"},
+			{"I#2", "", "already running"},
+			{"W", "", ""},
+		}},
+	}
+	for _, tt := range tests {
+		results, err := a.Bulk(ctx, tt.action, tt.sel, BulkOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := outcomes(results); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %v\nwant %v", tt.name, got, tt.want)
+		}
+		if err := BulkError(results); err != nil {
+			t.Errorf("%s: BulkError = %v", tt.name, err)
+		}
+	}
+	// A, I#1, I#2, A again, W.
+	if got := l.matching(`^would start`); len(got) != 5 {
+		t.Errorf("%d starts, want 5: %q", len(got), got)
+	}
+	if got := l.matching(`^would start b\.exe`); len(got) != 0 {
+		t.Errorf("disabled B started: %q", got)
+	}
+}
+
+func TestBulkErrors(t *testing.T) {
+	a, _ := newTestApp(t, bulkTestINI)
+	ctx := context.Background()
+	for _, tt := range []struct {
+		action string
+		sel    Selector
+		want   string
+	}{
+		{"kill", Selector{All: true}, `unknown operation "kill"`},
+		{OpStart, Selector{}, "no processes selected"},
+		{OpStart, Selector{Names: []string{"NOPE"}}, `process "NOPE" not found`},
+		{OpStart, Selector{Group: "nope"}, `group "nope" not found`},
+	} {
+		if _, err := a.Bulk(ctx, tt.action, tt.sel, BulkOptions{}); err == nil || err.Error() != tt.want {
+			t.Errorf("Bulk(%s, %+v) = %v, want %q", tt.action, tt.sel, err, tt.want)
+		}
+	}
+
+	err := BulkError([]BulkResult{{Name: "A"}, {Name: "B", Error: "boom"}, {Name: "C", Skipped: "job"}})
+	if err == nil || !strings.HasPrefix(err.Error(), "1 of 3 failed: B: boom") {
+		t.Errorf("BulkError = %v", err)
+	}
+}
+
+func TestBulkRestartAllLiftsManualStops(t *testing.T) {
+	a, _ := newTestApp(t, bulkTestINI)
+	ctx := context.Background()
+	for _, name := range []string{"J", "W"} {
+		if err := a.Stop(ctx, name).Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := a.Bulk(ctx, OpRestart, Selector{All: true}, BulkOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// W comes back with its window; the job stays paused until START.
+	if a.manualStop["W"] || !a.manualStop["J"] {
+		t.Errorf("manual stops after restart all: %v, want only J", a.manualStop)
+	}
+}
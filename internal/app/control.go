@@ -0,0 +1,284 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"goRunFiles/internal/control"
+	"goRunFiles/internal/process"
+)
+
+// ctlBulkWait bounds how long a ctl bulk command waits for its results,
+// within the round trip limit of the control channel.
+const ctlBulkWait = 25 * time.Second
+
+// ServeControl answers ctl commands on settings.controlAddr until ctx is
+// done. Nothing listens while the address is empty or "off". A changed
+// address takes effect after a restart. When the address is taken, the
+// instance answering on it is logged.
+func (a *App) ServeControl(ctx context.Context) {
+	a.mu.Lock()
+	configured := a.cfg.Settings.ControlAddr
+	a.mu.Unlock()
+	addr, ok := control.Addr(configured)
+	if !ok {
+		a.logger.Printf("%s control channel off (settings.controlAddr not set)", LogTag)
+		return
+	}
+	ln, err := control.Listen(addr)
+	if err != nil {
+		if resp, callErr := control.Call(addr, control.Request{Command: "instance"}); callErr == nil && resp.Error == "" {
+			a.logger.Printf("%s control channel disabled: %s is owned by %s", LogTag, addr, resp.Output)
+		} else {
+			a.logger.Printf("%s control channel disabled: %v", LogTag, err)
+		}
+		return
+	}
+	a.logger.Printf("%s control channel on %s (%s)", LogTag, addr, a.describeInstance())
+	if err := control.ServeListener(ctx, ln, a.handleControl); err != nil {
+		a.logger.Printf("%s control channel closed: %v", LogTag, err)
+	}
+}
+
+// describeInstance tells this monitor apart from others on the machine,
+// for instance a dry run next to the live monitor.
+func (a *App) describeInstance() string {
+	mode := process.SandboxMode()
+	if mode == "" {
+		mode = "live"
+	}
+	desc := fmt.Sprintf("%s monitor, pid %d", mode, os.Getpid())
+	if a.version != "" {
+		desc += ", version " + a.version
+	}
+	return desc
+}
+
+func (a *App) handleControl(req control.Request) control.Response {
+	switch req.Command {
+	case "profile":
+		switch len(req.Args) {
+		case 0:
+			return control.Response{Output: a.describeProfiles()}
+		case 1:
+			name := req.Args[0]
+			if name == "-" {
+				name = ""
+			}
+			if err := a.ActivateProfile(name); err != nil {
+				return control.Response{Error: err.Error()}
+			}
+			active, _ := a.Profiles()
+			if active == "" {
+				return control.Response{Output: "profile cleared"}
+			}
+			return control.Response{Output: fmt.Sprintf("profile %q active", active)}
+		}
+		return control.Response{Error: "usage: profile [NAME|-]"}
+	case "instance":
+		return control.Response{Output: a.describeInstance()}
+	case "groups":
+		return control.Response{Output: a.describeGroups()}
+	case OpStart, OpStop, OpRestart:
+		return a.ctlBulk(req.Command, req.Args)
+	case "rolling":
+		return a.ctlRolling(req.Args)
+	case "maintenance":
+		return a.ctlMaintenance(req.Args)
+	case "matches":
+		return a.ctlMatches(req.Args)
+	}
+	return control.Response{Error: fmt.Sprintf("unknown command %q", req.Command)}
+}
+
+// ctlBulk runs "start|stop|restart [-parallel N] [-stagger D] [-group G]
+// all|NAME...".
+func (a *App) ctlBulk(action string, args []string) control.Response {
+	usage := control.Response{Error: fmt.Sprintf("usage: %s [-parallel N] [-stagger D] all|NAME...|-group G", action)}
+	fs := flag.NewFlagSet(action, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var opts BulkOptions
+	var sel Selector
+	fs.IntVar(&opts.Parallel, "parallel", 0, "")
+	fs.DurationVar(&opts.Stagger, "stagger", 0, "")
+	fs.StringVar(&sel.Group, "group", "", "")
+	if err := fs.Parse(args); err != nil || (fs.NArg() == 0 && sel.Group == "") {
+		return usage
+	}
+	if fs.NArg() == 1 && fs.Arg(0) == "all" && sel.Group == "" {
+		sel.All = true
+	} else {
+		sel.Names = fs.Args()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ctlBulkWait)
+	defer cancel()
+	results, err := a.Bulk(ctx, action, sel, opts)
+	if err != nil {
+		return control.Response{Error: err.Error()}
+	}
+	return bulkResponse(results)
+}
+
+// ctlRolling runs "rolling [-batch N] [-timeout D] all|NAME...|-group G"
+// and waits for it up to ctlBulkWait; a longer one goes on in the
+// background. Without arguments it shows the progress of the last one.
+func (a *App) ctlRolling(args []string) control.Response {
+	usage := control.Response{Error: "usage: rolling [-batch N] [-timeout D] all|NAME...|-group G"}
+	fs := flag.NewFlagSet("rolling", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var opts RollingOptions
+	var sel Selector
+	fs.IntVar(&opts.Batch, "batch", 0, "")
+	fs.DurationVar(&opts.Timeout, "timeout", 0, "")
+	fs.StringVar(&sel.Group, "group", "", "")
+	if err := fs.Parse(args); err != nil {
+		return usage
+	}
+	if len(args) == 0 {
+		return control.Response{Output: describeRolling(a.RollingProgress())}
+	}
+	if fs.NArg() == 0 && sel.Group == "" {
+		return usage
+	}
+	if fs.NArg() == 1 && fs.Arg(0) == "all" && sel.Group == "" {
+		sel.All = true
+	} else {
+		sel.Names = fs.Args()
+	}
+	type outcome struct {
+		results []BulkResult
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		results, err := a.Rolling(context.Background(), sel, opts)
+		done <- outcome{results, err}
+	}()
+	select {
+	case o := <-done:
+		if o.err != nil {
+			return control.Response{Error: o.err.Error()}
+		}
+		return bulkResponse(o.results)
+	case <-time.After(ctlBulkWait):
+		return control.Response{Output: describeRolling(a.RollingProgress()) + "\n(still running, see \"rolling\")"}
+	}
+}
+
+// ctlMaintenance runs "maintenance [off | -for D [-reason TEXT]
+// all|NAME...|-group G]"; without arguments it lists the maintenances.
+func (a *App) ctlMaintenance(args []string) control.Response {
+	usage := control.Response{Error: "usage: maintenance [off | -for D [-reason TEXT] all|NAME...|-group G]"}
+	switch {
+	case len(args) == 0:
+		return control.Response{Output: describeMaintenance(a.Maintenances())}
+	case len(args) == 1 && args[0] == "off":
+		a.ExitMaintenance()
+		return control.Response{Output: "maintenance ended"}
+	}
+	fs := flag.NewFlagSet("maintenance", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var sel Selector
+	d := fs.Duration("for", 0, "")
+	reason := fs.String("reason", "", "")
+	fs.StringVar(&sel.Group, "group", "", "")
+	if err := fs.Parse(args); err != nil || *d <= 0 || (fs.NArg() == 0 && sel.Group == "") {
+		return usage
+	}
+	if fs.NArg() == 1 && fs.Arg(0) == "all" && sel.Group == "" {
+		sel.All = true
+	} else {
+		sel.Names = fs.Args()
+	}
+	if err := a.EnterMaintenance(*d, sel, *reason); err != nil {
+		return control.Response{Error: err.Error()}
+	}
+	return control.Response{Output: describeMaintenance(a.Maintenances())}
+}
+
+// ctlMatches runs "matches [all|NAME...|-group G]", all processes by
+// default.
+func (a *App) ctlMatches(args []string) control.Response {
+	fs := flag.NewFlagSet("matches", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var sel Selector
+	fs.StringVar(&sel.Group, "group", "", "")
+	if err := fs.Parse(args); err != nil {
+		return control.Response{Error: "usage: matches [all|NAME...|-group G]"}
+	}
+	if sel.Group == "" && (fs.NArg() == 0 || fs.NArg() == 1 && fs.Arg(0) == "all") {
+		sel.All = true
+	} else {
+		sel.Names = fs.Args()
+	}
+	out, err := a.Matches(sel)
+	if err != nil {
+		return control.Response{Error: err.Error()}
+	}
+	return control.Response{Output: out}
+}
+
+// describeMaintenance lists maintenances for ctl.
+func describeMaintenance(ms []Maintenance) string {
+	if len(ms) == 0 {
+		return "no maintenance"
+	}
+	var b strings.Builder
+	for _, m := range ms {
+		fmt.Fprintf(&b, "until %s  %-10s %s", m.Until.Format("2006-01-02 15:04:05"), m.Source, m.scope())
+		if m.Reason != "" {
+			fmt.Fprintf(&b, ": %s", m.Reason)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// describeRolling shows rolling restart progress for ctl.
+func describeRolling(p *RollingProgress) string {
+	if p == nil {
+		return "no rolling restart yet"
+	}
+	var b strings.Builder
+	state := "finished"
+	switch {
+	case p.Active:
+		state = "running, now " + strings.Join(p.Current, ", ")
+	case p.Failed != "":
+		state = "aborted on " + p.Failed
+	}
+	fmt.Fprintf(&b, "rolling restart %s: %d of %d running\n", state, p.Done, p.Total)
+	for _, e := range p.Events {
+		at := time.UnixMilli(e.At).Format("15:04:05")
+		fmt.Fprintf(&b, "%s %-24s %s %s\n", at, e.Name, e.Stage, e.Error)
+	}
+	return strings.TrimRight(b.String(), "\n ")
+}
+
+// bulkResponse reports per-process results for ctl and fails when any
+// operation did.
+func bulkResponse(results []BulkResult) control.Response {
+	var b strings.Builder
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			failed++
+			fmt.Fprintf(&b, "%-24s failed   %s\n", r.Name, r.Error)
+		case r.Skipped != "":
+			fmt.Fprintf(&b, "%-24s skipped  %s\n", r.Name, r.Skipped)
+		default:
+			fmt.Fprintf(&b, "%-24s ok       %s\n", r.Name, time.Duration(r.DurationMs)*time.Millisecond)
+		}
+	}
+	resp := control.Response{Output: strings.TrimRight(b.String(), "\n")}
+	if failed > 0 {
+		resp.Error = fmt.Sprintf("%d of %d failed", failed, len(results))
+	}
+	return resp
+}
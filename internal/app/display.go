@@ -2,42 +2,80 @@ package app
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 )
 
 // DisplayStatus is a stable, UI-friendly view of procStatus.
 type DisplayStatus struct {
-	Name      string `json:"name"`
-	Type      string `json:"type"`
-	Status    string `json:"status"`
-	Disabled  bool   `json:"disabled"`
-	Icon      string `json:"icon"`
-	Pid       string `json:"pid"`
-	StartedAt string `json:"started_at"`
-	Uptime    string `json:"uptime"`
-	Target    string `json:"target"`
-	Error     string `json:"error"`
-	Hung      bool   `json:"hung"`
-	Cpu       string `json:"cpu"`
-	Gpu       string `json:"gpu"`
-	GpuMemMB  string `json:"gpu_mem_mb"`
-	MemMB     string `json:"mem_mb"`
-	NetKBs    string `json:"net_kbs"`
-	IOKBs     string `json:"io_kbs"`
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Groups []string `json:"groups"`
+	Status string   `json:"status"`
+	Reason string   `json:"reason"`
+	// NextAttempt is when a backoff or crashed process is launched again,
+	// or when the run window of a scheduled-off one opens, in Unix ms; 0
+	// for none.
+	NextAttempt int64 `json:"next_attempt"`
+	// NextRun is the next cron run of an idle job in Unix ms, 0 for none.
+	// JobPaused marks a job stopped by hand, whose cron waits for START.
+	// An idle job with neither runs on demand only.
+	NextRun     int64        `json:"next_run"`
+	JobPaused   bool         `json:"job_paused"`
+	LastExit    *DisplayExit `json:"last_exit"`
+	Disabled    bool         `json:"disabled"`
+	Maintenance bool         `json:"maintenance"`
+	Icon        string       `json:"icon"`
+	Pid         string       `json:"pid"`
+	StartedAt   string       `json:"started_at"`
+	Uptime      string       `json:"uptime"`
+	Recycle     string       `json:"recycle"`
+	Pending     string       `json:"pending"`
+	Target      string       `json:"target"`
+	Error       string       `json:"error"`
+	Hung        bool         `json:"hung"`
+	Cpu         string       `json:"cpu"`
+	Gpu         string       `json:"gpu"`
+	GpuMemMB    string       `json:"gpu_mem_mb"`
+	MemMB       string       `json:"mem_mb"`
+	NetKBs      string       `json:"net_kbs"`
+	IOKBs       string       `json:"io_kbs"`
+}
+
+// DisplayExit is the last exit of a process on its own.
+type DisplayExit struct {
+	At    int64  `json:"at"` // Unix ms
+	Pid   int    `json:"pid"`
+	Error string `json:"error"`
+}
+
+// DisplayMaintenance is an active maintenance.
+type DisplayMaintenance struct {
+	Names  []string `json:"names"` // all processes when empty
+	Reason string   `json:"reason"`
+	Source string   `json:"source"`
+	Until  int64    `json:"until"` // Unix ms
 }
 
 // DisplaySnapshot is a UI-friendly snapshot of the current system state.
 type DisplaySnapshot struct {
-	Updated             string          `json:"updated"`
-	Version             string          `json:"version"`
-	CheckTimingMs       int             `json:"check_timing_ms"`
-	CheckProcessRunning bool            `json:"check_process_running"`
-	NetUnit             string          `json:"net_unit"`
-	NetMode             string          `json:"net_mode"`
-	NetErr              string          `json:"net_err"`
-	NetDbg              string          `json:"net_dbg"`
-	Items               []DisplayStatus `json:"items"`
+	Updated             string               `json:"updated"`
+	Version             string               `json:"version"`
+	CheckTimingMs       int                  `json:"check_timing_ms"`
+	CheckProcessRunning bool                 `json:"check_process_running"`
+	NetUnit             string               `json:"net_unit"`
+	NetMode             string               `json:"net_mode"`
+	NetErr              string               `json:"net_err"`
+	NetDbg              string               `json:"net_dbg"`
+	ConfigError         string               `json:"config_error"`
+	Profile             string               `json:"profile"`
+	Profiles            []string             `json:"profiles"`
+	Schedules           []ScheduleInfo       `json:"schedules"`
+	Groups              []string             `json:"groups"`
+	Rolling             *RollingProgress     `json:"rolling"`
+	Maintenance         []DisplayMaintenance `json:"maintenance"`
+	Items               []DisplayStatus      `json:"items"`
 }
 
 func buildDisplaySnapshot(version string, statuses []procStatus, now time.Time, checkTiming time.Duration, netUnit, netMode, netErr, netDbg string, checkProcessRunning bool) DisplaySnapshot {
@@ -47,23 +85,32 @@ func buildDisplaySnapshot(version string, statuses []procStatus, now time.Time,
 	items := make([]DisplayStatus, 0, len(statuses))
 	for _, s := range statuses {
 		items = append(items, DisplayStatus{
-			Name:      s.Name,
-			Type:      s.Type,
-			Status:    string(s.Status),
-			Disabled:  s.Disabled,
-			Icon:      s.Status.Icon(),
-			Pid:       s.pidString(),
-			StartedAt: s.StartedAt,
-			Uptime:    s.Uptime,
-			Target:    s.Target,
-			Error:     s.Err,
-			Hung:      s.Hung,
-			Cpu:       formatPercent(s.Cpu),
-			Gpu:       formatPercent(s.Gpu),
-			GpuMemMB:  formatMemMB(s.GpuMemMB),
-			MemMB:     formatMemMB(s.MemMB),
-			NetKBs:    formatRate(s.NetKBs, netUnit),
-			IOKBs:     formatRate(s.IOKBs, netUnit),
+			Name:        s.Name,
+			Type:        s.Type,
+			Groups:      s.Groups,
+			Status:      string(s.Status),
+			Reason:      string(s.Reason),
+			NextAttempt: unixMs(s.NextAttempt),
+			NextRun:     unixMs(s.NextRun),
+			JobPaused:   s.JobPaused,
+			LastExit:    displayExit(s.LastExit),
+			Disabled:    s.Disabled,
+			Maintenance: s.Maintenance,
+			Icon:        s.Status.Icon(),
+			Pid:         s.pidString(),
+			StartedAt:   s.StartedAt,
+			Uptime:      s.Uptime,
+			Recycle:     s.Recycle,
+			Pending:     s.Pending,
+			Target:      s.Target,
+			Error:       s.Err,
+			Hung:        s.Hung,
+			Cpu:         formatPercent(s.Cpu),
+			Gpu:         formatPercent(s.Gpu),
+			GpuMemMB:    formatMemMB(s.GpuMemMB),
+			MemMB:       formatMemMB(s.MemMB),
+			NetKBs:      formatRate(s.NetKBs, netUnit),
+			IOKBs:       formatRate(s.IOKBs, netUnit),
 		})
 	}
 	return DisplaySnapshot{
@@ -79,6 +126,44 @@ func buildDisplaySnapshot(version string, statuses []procStatus, now time.Time,
 	}
 }
 
+// InGroup returns the snapshot with only the processes of group; all of
+// them for "".
+func (s DisplaySnapshot) InGroup(group string) DisplaySnapshot {
+	if group == "" {
+		return s
+	}
+	items := make([]DisplayStatus, 0, len(s.Items))
+	for _, it := range s.Items {
+		if slices.Contains(it.Groups, group) {
+			items = append(items, it)
+		}
+	}
+	s.Items = items
+	return s
+}
+
+func displayMaintenance(ms []Maintenance) []DisplayMaintenance {
+	out := make([]DisplayMaintenance, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, DisplayMaintenance{Names: m.Names, Reason: m.Reason, Source: m.Source, Until: m.Until.UnixMilli()})
+	}
+	return out
+}
+
+func unixMs(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func displayExit(e ExitInfo) *DisplayExit {
+	if e.At.IsZero() {
+		return nil
+	}
+	return &DisplayExit{At: e.At.UnixMilli(), Pid: e.Pid, Error: e.Err}
+}
+
 func formatPercent(v float64) string {
 	if v <= 0 {
 		return "0"
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"goRunFiles/internal/process"
+	"goRunFiles/internal/runner"
+)
+
+// exitWatch watches one tracked PID for its exit.
+type exitWatch struct {
+	cancel   context.CancelFunc
+	reported bool // the exit has been handled
+}
+
+// poke asks the monitor loop for a check right away.
+func (a *App) poke() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// onExit returns the runner callback for a process launched as name.
+func (a *App) onExit(name string) func(runner.Exit) {
+	return func(e runner.Exit) {
+		a.exited(name, e.Pid, e.Err)
+	}
+}
+
+// exited handles the end of a tracked process: the monitor loop checks at
+// once instead of on its next tick. Exits caused by an operation on the
+// process and repeated reports of one exit are ignored.
+func (a *App) exited(name string, pid int, err error) {
+	a.mu.Lock()
+	item := a.cfg.Process[name]
+	if item == nil || item.Pid != pid || a.queueFor(name).pending() != "" {
+		a.mu.Unlock()
+		return
+	}
+	w := a.watches[pid]
+	if w == nil {
+		w = &exitWatch{}
+		a.watches[pid] = w
+	}
+	if w.reported {
+		a.mu.Unlock()
+		return
+	}
+	w.reported = true
+	if _, ok := a.reasons[name]; !ok && !a.manualStop[name] {
+		a.reasons[name] = ReasonExited
+		a.lastExit[name] = ExitInfo{At: time.Now(), Pid: pid, Err: errString(err)}
+	}
+	a.mu.Unlock()
+	if err != nil {
+		a.logger.Printf("%s %s exited (PID %d): %v", LogTag, name, pid, err)
+	} else {
+		a.logger.Printf("%s %s exited (PID %d)", LogTag, name, pid)
+	}
+	a.poke()
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// watchExits makes sure the PIDs of running processes, by process name,
+// are watched for their exit. A watch is dropped once its PID is no longer
+// tracked by any process. Where the OS can not watch a PID the regular
+// checks find the exit. The caller holds a.mu.
+func (a *App) watchExits(running map[string]int) {
+	for name, pid := range running {
+		if _, ok := a.watches[pid]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		a.watches[pid] = &exitWatch{cancel: cancel}
+		go func() {
+			if err := process.WaitExit(ctx, pid); err == nil {
+				a.exited(name, pid, nil)
+			}
+		}()
+	}
+	tracked := make(map[int]bool, len(a.cfg.Process))
+	for _, item := range a.cfg.Process {
+		tracked[item.Pid] = true
+	}
+	for pid, w := range a.watches {
+		if tracked[pid] {
+			continue
+		}
+		if w.cancel != nil {
+			w.cancel()
+		}
+		delete(a.watches, pid)
+	}
+}
+
+// nextWake returns the earliest time still to come when a check has work
+// to do: a process restart, a schedule or a job run. Zero if there is
+// none; overdue restarts are retried on the regular ticks.
+func (a *App) nextWake(now time.Time) time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var next time.Time
+	consider := func(t time.Time) {
+		if t.After(now) && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	for name, at := range a.restartAt {
+		if !a.manualStop[name] {
+			consider(at)
+		}
+	}
+	for _, s := range a.schedules {
+		consider(s.next)
+	}
+	for _, j := range a.jobs {
+		consider(j.next)
+	}
+	for _, m := range a.maintenance {
+		consider(m.Until)
+	}
+	return next
+}
+
+// armWake sets t to fire at the next wake time, so work is done when it is
+// due rather than on the following tick.
+func (a *App) armWake(t *time.Timer) {
+	t.Stop()
+	now := time.Now()
+	if next := a.nextWake(now); !next.IsZero() {
+		t.Reset(next.Sub(now))
+	}
+}
@@ -0,0 +1,227 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/runner"
+	"goRunFiles/internal/schedule"
+)
+
+// jobHistorySize is how many finished runs are kept per job.
+const jobHistorySize = 20
+
+// Job run triggers.
+const (
+	triggerSchedule = "schedule"
+	triggerManual   = "manual"
+)
+
+// JobRun is one run of a type=job process.
+type JobRun struct {
+	Started  string `json:"started"`
+	Duration string `json:"duration"`
+	Trigger  string `json:"trigger"`
+	Running  bool   `json:"running"`
+	ExitCode int    `json:"exit_code"`
+	TimedOut bool   `json:"timed_out"`
+	Error    string `json:"error"`
+	Output   string `json:"output"`
+}
+
+// jobState is the runtime state of one job.
+type jobState struct {
+	cronExpr string
+	cron     *schedule.Cron // nil for jobs run on demand only
+	next     time.Time
+	cancel   context.CancelFunc // set while a run is in progress
+	pid      int
+	started  time.Time
+	trigger  string
+	history  []JobRun // oldest first
+}
+
+// applyJobs prepares the state of the jobs in cfg. History and runs in
+// progress are kept; next runs of new or edited crons count from now. The
+// caller holds a.mu.
+func (a *App) applyJobs(cfg config.Config, now time.Time) {
+	names := make([]string, 0, len(cfg.Process))
+	for name, item := range cfg.Process {
+		if item.Type == config.TypeJob {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	jobs := make(map[string]*jobState, len(names))
+	for _, name := range names {
+		item := cfg.Process[name]
+		st := a.jobs[name]
+		if st == nil {
+			st = &jobState{}
+		}
+		jobs[name] = st
+		if st.cron != nil && st.cronExpr == item.Cron {
+			continue
+		}
+		st.cronExpr, st.cron, st.next = item.Cron, nil, time.Time{}
+		c, err := item.JobCron()
+		if err != nil {
+			a.logger.Printf("%s job %s runs on demand only: cron: %v", LogTag, name, err)
+			continue
+		}
+		if c != nil {
+			st.cron, st.next = c, c.Next(now)
+		}
+	}
+	a.jobs = jobs
+}
+
+// checkJob fills the status of a job and starts it when its cron is due.
+// A run due while the previous one is still going is skipped. The caller
+// holds a.mu.
+func (a *App) checkJob(name string, item *config.ProcessItem, status *procStatus, doRestart bool, now time.Time) {
+	st := a.jobs[name]
+	if st == nil {
+		st = &jobState{}
+		a.jobs[name] = st
+	}
+	status.Target = item.Command
+	if st.cron != nil && !st.next.IsZero() && !now.Before(st.next) {
+		st.next = st.cron.Next(now)
+		switch {
+		case !doRestart || item.Disabled || a.manualStop[name]:
+		case st.cancel != nil:
+			a.logger.Printf("%s job %s: previous run still going, skipped", LogTag, name)
+		default:
+			if err := a.startJob(name, item, triggerSchedule); err != nil {
+				status.Err = err.Error()
+			}
+		}
+	}
+
+	if st.cancel != nil {
+		status.Status = StatusRunning
+		status.Pid = st.pid
+		status.StartedAt = st.started.Format("2006-01-02 15:04:05")
+		status.Uptime = formatUptime(now.Sub(st.started))
+		a.last[name] = StatusRunning
+		return
+	}
+	status.Status = StatusIdle
+	if item.Disabled {
+		status.Status = StatusDisabled
+	}
+	a.last[name] = status.Status
+	status.StartedAt = "-"
+	if n := len(st.history); n > 0 {
+		last := st.history[n-1]
+		status.StartedAt = last.Started
+		if last.Error != "" && status.Err == "" {
+			status.Err = "last run: " + last.Error
+		}
+	}
+	status.Uptime = "-"
+	switch {
+	case item.Disabled:
+	case a.manualStop[name]:
+		status.JobPaused = true
+	case st.cron != nil:
+		status.NextRun = st.next
+	}
+}
+
+// startJob runs a job in the background. A job runs once at a time. The
+// caller holds a.mu.
+func (a *App) startJob(name string, item *config.ProcessItem, trigger string) error {
+	st := a.jobs[name]
+	if st == nil {
+		st = &jobState{}
+		a.jobs[name] = st
+	}
+	if st.cancel != nil {
+		return fmt.Errorf("job %q is %w", name, errAlreadyRunning)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	st.cancel = cancel
+	st.started = time.Now()
+	st.trigger = trigger
+	st.pid = 0
+	job := *item // the run must not see later config changes
+	a.logger.Printf("%s job %s started (%s)", LogTag, name, trigger)
+	go func() {
+		res := runner.RunJob(ctx, &job, func(pid int) {
+			a.mu.Lock()
+			st.pid = pid
+			a.mu.Unlock()
+		})
+		cancel()
+		a.finishJob(name, st, res)
+	}()
+	return nil
+}
+
+// finishJob records a finished run in the job's history.
+func (a *App) finishJob(name string, st *jobState, res runner.JobResult) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	run := JobRun{
+		Started:  st.started.Format("2006-01-02 15:04:05"),
+		Duration: now.Sub(st.started).Round(time.Millisecond).String(),
+		Trigger:  st.trigger,
+		ExitCode: res.ExitCode,
+		TimedOut: res.TimedOut,
+		Output:   res.Output,
+	}
+	if res.Err != nil {
+		run.Error = res.Err.Error()
+		a.logger.Printf("%s job %s failed after %s: %v", LogTag, name, run.Duration, res.Err)
+	} else {
+		a.logger.Printf("%s job %s finished in %s", LogTag, name, run.Duration)
+	}
+	st.history = append(st.history, run)
+	if over := len(st.history) - jobHistorySize; over > 0 {
+		st.history = append([]JobRun(nil), st.history[over:]...)
+	}
+	st.cancel = nil
+	st.pid = 0
+}
+
+// cancelJob kills the run of a job in progress, if any. The caller holds
+// a.mu.
+func (a *App) cancelJob(name string) {
+	if st := a.jobs[name]; st != nil && st.cancel != nil {
+		st.cancel()
+	}
+}
+
+// JobHistory returns the runs of a job, newest first, including one in
+// progress.
+func (a *App) JobHistory(name string) ([]JobRun, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	item, ok := a.cfg.Process[name]
+	if !ok || item.Type != config.TypeJob {
+		return nil, fmt.Errorf("job %q not found", name)
+	}
+	st := a.jobs[name]
+	if st == nil {
+		return nil, nil
+	}
+	out := make([]JobRun, 0, len(st.history)+1)
+	if st.cancel != nil {
+		out = append(out, JobRun{
+			Started:  st.started.Format("2006-01-02 15:04:05"),
+			Duration: time.Since(st.started).Round(time.Second).String(),
+			Trigger:  st.trigger,
+			Running:  true,
+		})
+	}
+	for i := len(st.history) - 1; i >= 0; i-- {
+		out = append(out, st.history[i])
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"goRunFiles/internal/config"
+)
+
+// Maintenance is a period in which the monitor keeps checking processes
+// but leaves them alone: it does not relaunch them, kill them for hanging,
+// outside their run window or after startTimeout, or recycle them, and the
+// UI does not report their errors. Manual operations and schedules still
+// work. A maintenance ends by itself at Until.
+type Maintenance struct {
+	Names  []string  `json:"names,omitempty"` // processes; all of them when empty
+	Reason string    `json:"reason,omitempty"`
+	Source string    `json:"source"` // "manual" or the name of the schedule
+	Until  time.Time `json:"until"`
+}
+
+// covers reports whether the maintenance applies to name at now.
+func (m Maintenance) covers(name string, now time.Time) bool {
+	return now.Before(m.Until) && (len(m.Names) == 0 || slices.Contains(m.Names, name))
+}
+
+// scope describes the processes of the maintenance for logs.
+func (m Maintenance) scope() string {
+	if len(m.Names) == 0 {
+		return "all processes"
+	}
+	return strings.Join(m.Names, ", ")
+}
+
+// EnterMaintenance puts the selected processes, or the whole monitor for a
+// selection of all, in maintenance for d. It adds to the maintenances
+// already active.
+func (a *App) EnterMaintenance(d time.Duration, sel Selector, reason string) error {
+	return a.enterMaintenance(d, sel, reason, "manual")
+}
+
+func (a *App) enterMaintenance(d time.Duration, sel Selector, reason, source string) error {
+	if d <= 0 {
+		return fmt.Errorf("maintenance duration must be positive")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	m := Maintenance{Reason: strings.TrimSpace(reason), Source: source, Until: time.Now().Add(d)}
+	if !sel.All {
+		names, err := a.selectProcesses(sel)
+		if err != nil {
+			return err
+		}
+		m.Names = names
+	}
+	a.maintenance = append(a.maintenance, m)
+	msg := fmt.Sprintf("maintenance of %s until %s (%s)", m.scope(), m.Until.Format("2006-01-02 15:04:05"), source)
+	if m.Reason != "" {
+		msg += ": " + m.Reason
+	}
+	a.logger.Printf("%s %s", LogTag, msg)
+	return nil
+}
+
+// ExitMaintenance ends all maintenances at once.
+func (a *App) ExitMaintenance() {
+	a.mu.Lock()
+	n := len(a.maintenance)
+	a.maintenance = nil
+	a.mu.Unlock()
+	if n > 0 {
+		a.logger.Printf("%s maintenance ended by hand", LogTag)
+		a.poke()
+	}
+}
+
+// Maintenances returns the active maintenances.
+func (a *App) Maintenances() []Maintenance {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireMaintenance(time.Now())
+	return slices.Clone(a.maintenance)
+}
+
+// inMaintenance reports whether name is left alone at now. The caller
+// holds a.mu.
+func (a *App) inMaintenance(name string, now time.Time) bool {
+	for _, m := range a.maintenance {
+		if m.covers(name, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// expireMaintenance drops the maintenances that are over. The caller holds
+// a.mu.
+func (a *App) expireMaintenance(now time.Time) {
+	a.maintenance = slices.DeleteFunc(a.maintenance, func(m Maintenance) bool {
+		if now.Before(m.Until) {
+			return false
+		}
+		a.logger.Printf("%s maintenance of %s ended", LogTag, m.scope())
+		return true
+	})
+}
+
+// maintenanceSchedule runs a maintenance schedule: its target processes,
+// or all of them, are left alone for its duration.
+func (a *App) maintenanceSchedule(s config.ScheduleEntry) error {
+	sel := Selector{Names: s.Targets()}
+	if len(sel.Names) == 0 {
+		sel = Selector{All: true}
+	}
+	return a.enterMaintenance(s.Duration.Duration, sel, "", "schedule "+s.Name)
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceExpires(t *testing.T) {
+	a, l := newTestApp(t, opsTestINI)
+	if err := a.EnterMaintenance(time.Hour, Selector{Names: []string{"A"}}, "upgrade"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for range 2 {
+		// Down and left alone, however long past restartTiming.
+		now = now.Add(a.cfg.Settings.RestartTiming.Duration)
+		if s := a.computeStatuses(true, now)[0]; s.Status != StatusMaintenance || !s.Maintenance {
+			t.Fatalf("status in maintenance = %s (maintenance %v), want %s", s.Status, s.Maintenance, StatusMaintenance)
+		}
+	}
+	waitFor(t, "checks", func() bool { return idle(a) })
+	if got := l.matching(`^would start`); len(got) != 0 {
+		t.Fatalf("started in maintenance: %q", got)
+	}
+
+	now = now.Add(time.Hour)
+	if s := a.computeStatuses(true, now)[0]; s.Status == StatusMaintenance || s.Maintenance {
+		t.Errorf("status after the maintenance = %s (maintenance %v)", s.Status, s.Maintenance)
+	}
+	if got := l.matching(`maintenance of A ended$`); len(got) != 1 {
+		t.Errorf("end of maintenance logged %d times", len(got))
+	}
+	if m := a.Maintenances(); len(m) != 0 {
+		t.Errorf("maintenances after the end = %+v", m)
+	}
+	// The launch waits for restartTiming as after any exit.
+	a.computeStatuses(true, now.Add(a.cfg.Settings.RestartTiming.Duration))
+	waitFor(t, "launch", func() bool { return idle(a) })
+	if got := l.matching(`^would start a\.exe`); len(got) != 1 {
+		t.Errorf("starts after the maintenance = %q, want 1", got)
+	}
+}
+
+func TestMaintenanceSurvivesRestart(t *testing.T) {
+	a, _ := newTestApp(t, opsTestINI)
+	if err := a.EnterMaintenance(time.Hour, Selector{Names: []string{"A"}}, "upgrade"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.EnterMaintenance(3*time.Hour, Selector{All: true}, ""); err != nil {
+		t.Fatal(err)
+	}
+	a.mu.Lock()
+	st, _ := a.buildState()
+	a.mu.Unlock()
+	data, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		want  []string // scopes of the restored maintenances
+	}{
+		{"right away", 0, []string{"A", "all processes"}},
+		{"after the first ended", 2 * time.Hour, []string{"all processes"}},
+		{"after both ended", 4 * time.Hour, nil},
+	}
+	for _, tt := range tests {
+		var saved runtimeState
+		if err := json.Unmarshal(data, &saved); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := newTestApp(t, opsTestINI)
+		b.restoreState(saved, time.Now().Add(tt.after))
+		b.mu.Lock()
+		var got []string
+		for _, m := range b.maintenance {
+			got = append(got, m.scope())
+		}
+		b.mu.Unlock()
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: restored %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// The restored maintenance keeps its reason and end.
+	b, _ := newTestApp(t, opsTestINI)
+	b.restoreState(st, time.Now())
+	if m := b.Maintenances(); len(m) != 2 || m[0].Reason != "upgrade" || !m[0].Until.Equal(st.Maintenance[0].Until) || m[0].Source != "manual" {
+		t.Errorf("restored maintenances = %+v, want those of %+v", m, st.Maintenance)
+	}
+}
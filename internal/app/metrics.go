@@ -0,0 +1,97 @@
+package app
+
+import (
+	"runtime"
+	"sync"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/process"
+)
+
+// metricSample is the last CPU/memory/network/GPU reading of a process.
+type metricSample struct {
+	pid    int
+	cpu    float64
+	memMB  int
+	netKBs float64
+	ioKBs  float64
+	gpu    float64
+	gpuMem int
+}
+
+// applyMetrics fills statuses with the last samples taken for the same
+// PIDs, so a check between two samplings shows the cached values. The
+// caller holds a.mu.
+func (a *App) applyMetrics(statuses []procStatus) {
+	for i := range statuses {
+		st := &statuses[i]
+		m, ok := a.metrics[st.Name]
+		if !ok || st.metricsPid <= 0 || m.pid != st.metricsPid {
+			continue
+		}
+		st.Cpu, st.MemMB, st.NetKBs, st.IOKBs, st.Gpu, st.GpuMemMB = m.cpu, m.memMB, m.netKBs, m.ioKBs, m.gpu, m.gpuMem
+	}
+}
+
+// sampleMetrics reads the metrics of the running processes in statuses,
+// which it updates, and caches them for later checks. It runs on
+// settings.metricsTiming, apart from the liveness checks; nvidia-smi and
+// the other samplers are only used here.
+func (a *App) sampleMetrics(statuses []procStatus) {
+	// Periodic cleanup of stale metric samples to prevent unbounded memory growth.
+	process.CleanupStaleSamples()
+	process.CleanupETWTotals()
+
+	var tasks []int
+	for i := range statuses {
+		if statuses[i].metricsPid > 0 {
+			tasks = append(tasks, i)
+		}
+	}
+	samples := make(map[string]metricSample, len(tasks))
+	if len(tasks) > 0 {
+		gpuByPid := process.GpuStatsByPid()
+		maxMetricWorkers := runtime.NumCPU()
+		if maxMetricWorkers < 4 {
+			maxMetricWorkers = 4
+		}
+		if len(tasks) < maxMetricWorkers {
+			maxMetricWorkers = len(tasks)
+		}
+		sem := make(chan struct{}, maxMetricWorkers)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+
+		for _, idx := range tasks {
+			st := statuses[idx]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				res := metricSample{pid: st.metricsPid}
+				res.cpu, res.memMB = process.CPUAndMem(st.metricsPid)
+				netByPID, ioByPID := process.NetIOKBs(st.metricsPid)
+				res.netKBs, res.ioKBs = netByPID, ioByPID
+				if st.Type == config.TypeExe && len(st.metricNames) > 0 {
+					res.netKBs = max(process.NetKBsByNames(st.metricNames), netByPID)
+					res.ioKBs = max(process.IOKBsByNames(st.metricNames), ioByPID)
+				}
+				if gpu, ok := gpuByPid[st.metricsPid]; ok {
+					res.gpu = gpu.Util
+					res.gpuMem = gpu.MemMB
+				}
+				mu.Lock()
+				samples[st.Name] = res
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+	}
+
+	a.mu.Lock()
+	a.metrics = samples
+	a.applyMetrics(statuses)
+	a.mu.Unlock()
+}
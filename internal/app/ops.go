@@ -0,0 +1,411 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/runner"
+)
+
+// Process operations. Start, stop and restart are the manual ones; the
+// monitor loop kills and launches processes through the same queue.
+const (
+	OpStart   = "start"
+	OpStop    = "stop"
+	OpRestart = "restart"
+	opKill    = "kill"   // stop without marking the process as stopped by hand
+	opLaunch  = "launch" // start once the restart delay is over
+)
+
+// Op is an operation queued on one process. The operations of a process
+// run one at a time, in the order they were queued, without App.mu held
+// while processes are killed or launched. The ctx the operation was queued
+// with cancels it: in the queue it is dropped, while running the kills not
+// yet made are given up and no process is launched anymore. A launch
+// already made is not undone.
+type Op struct {
+	Name   string
+	Action string
+	ctx    context.Context
+	item   *config.ProcessItem // kill this item instead of the configured one
+	done   chan struct{}
+	err    error
+}
+
+// Done is closed once the operation has finished.
+func (o *Op) Done() <-chan struct{} {
+	return o.done
+}
+
+// Err returns the result of a finished operation, nil before that.
+func (o *Op) Err() error {
+	select {
+	case <-o.done:
+		return o.err
+	default:
+		return nil
+	}
+}
+
+// Wait waits until the operation finishes or ctx ends. ctx only bounds
+// the wait: an operation still queued or running when it ends goes on in
+// the background, see Op for cancelling the operation itself.
+func (o *Op) Wait(ctx context.Context) error {
+	select {
+	case <-o.done:
+		return o.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// finishedOp returns an operation that is already over.
+func finishedOp(name, action string, err error) *Op {
+	op := &Op{Name: name, Action: action, done: make(chan struct{}), err: err}
+	close(op.done)
+	return op
+}
+
+// waitOps waits for all ops and returns the last error.
+func waitOps(ctx context.Context, ops []*Op) error {
+	var lastErr error
+	for _, op := range ops {
+		if err := op.Wait(ctx); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// opQueue holds the operations of one process.
+type opQueue struct {
+	ops      []*Op
+	current  *Op
+	running  bool   // a worker drains the queue
+	seq      uint64 // finished operations, to spot changes made during a check
+	err      string // error of the last launch by the monitor, until one succeeds
+	failures int    // failed launches and starts in a row, see fatalLaunchFailures
+}
+
+// pending returns the action running or next in line, "" when idle.
+func (q *opQueue) pending() string {
+	switch {
+	case q.current != nil:
+		return q.current.Action
+	case len(q.ops) > 0:
+		return q.ops[0].Action
+	}
+	return ""
+}
+
+// Start queues a manual start of a process. ctx cancels the operation,
+// see Op.
+func (a *App) Start(ctx context.Context, name string) *Op {
+	return a.submit(ctx, name, OpStart)
+}
+
+// Stop queues a manual stop of a process.
+func (a *App) Stop(ctx context.Context, name string) *Op {
+	return a.submit(ctx, name, OpStop)
+}
+
+// Restart queues a manual restart of a process.
+func (a *App) Restart(ctx context.Context, name string) *Op {
+	return a.submit(ctx, name, OpRestart)
+}
+
+func (a *App) submit(ctx context.Context, name, action string) *Op {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	item, ok := a.cfg.Process[name]
+	if !ok {
+		return finishedOp(name, action, fmt.Errorf("process %q not found", name))
+	}
+	if item.Type == config.TypeJob {
+		return finishedOp(name, action, a.jobOp(name, item, action))
+	}
+	return a.enqueue(ctx, name, action, nil)
+}
+
+// jobOp applies a manual operation to a job, which runs on its own. The
+// caller holds a.mu.
+func (a *App) jobOp(name string, item *config.ProcessItem, action string) error {
+	if action == OpStop {
+		// STOP kills a run in progress and pauses the cron until START.
+		a.manualStop[name] = true
+		a.cancelJob(name)
+		return nil
+	}
+	// Running a job by hand also resumes its cron after a STOP; a job has
+	// nothing to restart, so RESTART runs it like START.
+	delete(a.manualStop, name)
+	return a.startJob(name, item, triggerManual)
+}
+
+// queueFor returns the queue of a process, creating it. Queues are kept
+// for removed processes so a kill still in line finishes on the same
+// worker. The caller holds a.mu.
+func (a *App) queueFor(name string) *opQueue {
+	q := a.queues[name]
+	if q == nil {
+		q = &opQueue{}
+		a.queues[name] = q
+	}
+	return q
+}
+
+// enqueue adds an operation to the queue of a process and makes sure a
+// worker runs it. The caller holds a.mu.
+func (a *App) enqueue(ctx context.Context, name, action string, item *config.ProcessItem) *Op {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	op := &Op{Name: name, Action: action, ctx: ctx, item: item, done: make(chan struct{})}
+	q := a.queueFor(name)
+	q.ops = append(q.ops, op)
+	if !q.running {
+		q.running = true
+		go a.drain(name, q)
+	}
+	return op
+}
+
+// drain runs the operations of one process until its queue is empty.
+func (a *App) drain(name string, q *opQueue) {
+	a.mu.Lock()
+	for len(q.ops) > 0 {
+		op := q.ops[0]
+		q.ops = q.ops[1:]
+		q.current = op
+		a.mu.Unlock()
+		op.err = a.runOp(op)
+		if op.err != nil && op.Action == opKill {
+			a.logger.Printf("%s %s: kill: %v", LogTag, name, op.err)
+		}
+		a.mu.Lock()
+		q.current = nil
+		q.seq++
+		close(op.done)
+		if op.err == nil {
+			a.poke() // check the outcome at once
+		}
+	}
+	q.running = false
+	a.mu.Unlock()
+}
+
+func (a *App) runOp(op *Op) error {
+	if err := op.ctx.Err(); err != nil {
+		return err
+	}
+	switch op.Action {
+	case OpStart:
+		return a.startOp(op.ctx, op.Name)
+	case OpStop:
+		return a.stopOp(op.ctx, op.Name)
+	case OpRestart:
+		return a.restartOp(op.ctx, op.Name)
+	case opKill:
+		return a.killOp(op.ctx, op.Name, op.item)
+	case opLaunch:
+		return a.launchOp(op.ctx, op.Name)
+	}
+	return fmt.Errorf("unknown operation %q", op.Action)
+}
+
+func (a *App) startOp(ctx context.Context, name string) error {
+	a.mu.Lock()
+	item, ok := a.cfg.Process[name]
+	if !ok {
+		a.mu.Unlock()
+		return fmt.Errorf("process %q not found", name)
+	}
+	// Manual START enables the process so it enters regular monitoring.
+	item.Disabled = false
+	a.overrideWindow(name, time.Now())
+	delete(a.manualStop, name)
+	delete(a.firstStart, name)
+	a.queueFor(name).failures = 0
+	target, console := *item, a.cfg.Settings.LaunchInNewConsole
+	a.mu.Unlock()
+
+	alive, pid, err := isProcessItemAlive(&target)
+	if err != nil {
+		return err
+	}
+	if alive {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if pid > 0 {
+			a.commitPid(name, target.Pid, pid)
+		}
+		// Keep state in sync when process is already running.
+		delete(a.restartAt, name)
+		delete(a.hungSince, name)
+		a.last[name] = StatusRunning
+		delete(a.reasons, name)
+		return fmt.Errorf("process %q is %w", name, errAlreadyRunning)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	pid, err = runner.Start(&target, console, a.onExit(name))
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.markStarted(name, pid)
+	return nil
+}
+
+func (a *App) stopOp(ctx context.Context, name string) error {
+	a.mu.Lock()
+	item, ok := a.cfg.Process[name]
+	if !ok {
+		a.mu.Unlock()
+		return fmt.Errorf("process %q not found", name)
+	}
+	if a.defaultDisabled[name] {
+		item.Disabled = true
+	}
+	a.manualStop[name] = true
+	a.reasons[name] = ReasonManual
+	delete(a.restartAt, name)
+	delete(a.firstStart, name)
+	target := *item
+	a.mu.Unlock()
+
+	before := target.Pid
+	err := stopProcessItem(ctx, &target)
+	a.mu.Lock()
+	a.commitPid(name, before, target.Pid)
+	a.mu.Unlock()
+	return err
+}
+
+func (a *App) restartOp(ctx context.Context, name string) error {
+	a.mu.Lock()
+	item, ok := a.cfg.Process[name]
+	if !ok {
+		a.mu.Unlock()
+		return fmt.Errorf("process %q not found", name)
+	}
+	// Manual RESTART should not leave the process in manual-stop mode.
+	delete(a.manualStop, name)
+	delete(a.restartAt, name)
+	delete(a.firstStart, name)
+	delete(a.hungSince, name)
+	// Explicit restart enables the process for regular monitoring.
+	item.Disabled = false
+	a.overrideWindow(name, time.Now())
+	a.queueFor(name).failures = 0
+	target, console := *item, a.cfg.Settings.LaunchInNewConsole
+	a.mu.Unlock()
+
+	before := target.Pid
+	if err := stopProcessItem(ctx, &target); err != nil || ctx.Err() != nil {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.commitPid(name, before, target.Pid)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// Cancelled after the kill, which may have stopped the process:
+			// not started again, leave it to the monitor like a failed
+			// launch.
+			a.last[name] = StatusBackoff
+			return ctxErr
+		}
+		return err
+	}
+	pid, err := runner.Start(&target, console, a.onExit(name))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		a.commitPid(name, before, 0)
+		a.last[name] = StatusBackoff
+		a.reasons[name] = ReasonLaunchFailed
+		return err
+	}
+	a.markStarted(name, pid)
+	return nil
+}
+
+// killOp stops a process for the monitor: outside its run window, when it
+// hangs, is recycled, was relaunched after a manual stop or left the
+// config. item, when set, is killed instead of the configured process.
+func (a *App) killOp(ctx context.Context, name string, item *config.ProcessItem) error {
+	a.mu.Lock()
+	if item == nil {
+		item = a.cfg.Process[name]
+	}
+	if item == nil {
+		a.mu.Unlock()
+		return nil
+	}
+	target := *item
+	a.mu.Unlock()
+
+	before := target.Pid
+	err := stopProcessItem(ctx, &target)
+	a.mu.Lock()
+	a.commitPid(name, before, target.Pid)
+	a.mu.Unlock()
+	return err
+}
+
+// launchOp starts a stopped process for the monitor, unless it was
+// disabled, stopped by hand or left its window while the launch waited.
+func (a *App) launchOp(ctx context.Context, name string) error {
+	a.mu.Lock()
+	item, ok := a.cfg.Process[name]
+	if !ok || item.Disabled || a.manualStop[name] || a.outsideWindow(name, time.Now()) {
+		a.mu.Unlock()
+		return nil
+	}
+	target, console := *item, a.cfg.Settings.LaunchInNewConsole
+	a.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	pid, err := runner.Start(&target, console, a.onExit(name))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		q := a.queueFor(name)
+		q.err = err.Error()
+		q.failures++
+		a.reasons[name] = ReasonLaunchFailed
+		a.restartAt[name] = time.Now().Add(a.cfg.Settings.RestartTiming.Duration)
+		return err
+	}
+	a.markStarted(name, pid)
+	return nil
+}
+
+// markStarted records a successful launch. The caller holds a.mu.
+func (a *App) markStarted(name string, pid int) {
+	if item := a.cfg.Process[name]; item != nil {
+		item.Pid = pid
+	}
+	// Failures are reset once the process outlives its start window.
+	a.queueFor(name).err = ""
+	a.last[name] = StatusStarting
+	a.launchedAt[name] = time.Now()
+	delete(a.reasons, name)
+	delete(a.restartAt, name)
+	delete(a.firstStart, name)
+	if pid > 0 {
+		a.startTimes[pid] = time.Now().UnixMilli()
+	}
+}
+
+// commitPid stores the PID an operation ended with, unless the process
+// has been tracked under another PID meanwhile. The caller holds a.mu.
+func (a *App) commitPid(name string, before, after int) {
+	if item := a.cfg.Process[name]; item != nil && item.Pid == before {
+		item.Pid = after
+	}
+}
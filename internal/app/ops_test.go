@@ -0,0 +1,304 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/process"
+)
+
+// testSettings heads the configs of newTestApp.
+const testSettings = `[settings]
+checkTiming=1s
+restartTiming=1s
+autoRestartOnExit=true
+`
+
+// sandboxLog collects the lines logged by the sandbox and the app. hook,
+// when set, runs on every sandbox note on the goroutine that took the
+// action, before the action returns.
+type sandboxLog struct {
+	mu    sync.Mutex
+	lines []string
+	hook  func(note string)
+}
+
+func (l *sandboxLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	l.lines = append(l.lines, strings.TrimSuffix(string(p), "\n"))
+	l.mu.Unlock()
+	return len(p), nil
+}
+
+// note is the log function of the sandbox.
+func (l *sandboxLog) note(format string, args ...any) {
+	msg := strings.TrimPrefix(fmt.Sprintf(format, args...), process.ModeSimulation+": ")
+	l.Write([]byte(msg))
+	l.mu.Lock()
+	hook := l.hook
+	l.mu.Unlock()
+	if hook != nil {
+		hook(msg)
+	}
+}
+
+func (l *sandboxLog) setHook(hook func(note string)) {
+	l.mu.Lock()
+	l.hook = hook
+	l.mu.Unlock()
+}
+
+// matching returns the logged lines matching the regexp expr, in order.
+func (l *sandboxLog) matching(expr string) []string {
+	re := regexp.MustCompile(expr)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []string
+	for _, line := range l.lines {
+		if re.MatchString(line) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// newTestApp returns an App for the processes of ini that runs in a
+// simulation of an empty process table: launches add to the table and
+// kills remove from it, so checks see what the monitor did. Processes of
+// type exe are found by their process name. All operations are over when
+// the test ends.
+func newTestApp(t *testing.T, ini string) (*App, *sandboxLog) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(path, []byte(testSettings+ini), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &sandboxLog{}
+	process.Simulate(&process.Recording{Frames: []process.Frame{{}}}, l.note)
+	a := New(cfg, log.New(l, "", 0), "test")
+	t.Cleanup(func() {
+		l.setHook(nil)
+		waitFor(t, "operations to finish", func() bool { return idle(a) })
+	})
+	return a, l
+}
+
+// idle reports whether no process has an operation queued or running.
+func idle(a *App) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, q := range a.queues {
+		if q.running {
+			return false
+		}
+	}
+	return true
+}
+
+// waitFor polls cond until it holds, failing the test after a while.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// statusOf checks all processes without launching any and returns the
+// status of name.
+func statusOf(t *testing.T, a *App, name string) procStatus {
+	t.Helper()
+	for _, s := range a.computeStatuses(false, time.Now()) {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no status for %s", name)
+	return procStatus{}
+}
+
+func pidOf(a *App, name string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.cfg.Process[name].Pid
+}
+
+// blockOn makes the first sandbox note matching expr wait until the
+// returned release is called. reached is closed once it waits.
+func blockOn(l *sandboxLog, expr string) (reached <-chan struct{}, release func()) {
+	re := regexp.MustCompile(expr)
+	hit, gate := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	l.setHook(func(note string) {
+		if !re.MatchString(note) {
+			return
+		}
+		once.Do(func() {
+			close(hit)
+			<-gate
+		})
+	})
+	var released sync.Once
+	return hit, func() { released.Do(func() { close(gate) }) }
+}
+
+const opsTestINI = `
+[process "A"]
+type=exe
+path=C:/apps
+process=a.exe
+`
+
+func TestOpsRunInQueueOrder(t *testing.T) {
+	a, l := newTestApp(t, opsTestINI)
+	ctx := context.Background()
+	ops := []*Op{a.Start(ctx, "A"), a.Stop(ctx, "A"), a.Restart(ctx, "A")}
+	for _, op := range ops {
+		if err := op.Wait(ctx); err != nil {
+			t.Fatalf("%s: %v", op.Action, err)
+		}
+	}
+	got := l.matching(`^would (start|kill)`)
+	want := []string{`^would start a\.exe .* as PID 900001$`, `^would kill PID 900001 `, `^would start a\.exe .* as PID 900002$`}
+	if len(got) != len(want) {
+		t.Fatalf("actions = %q, want %d", got, len(want))
+	}
+	for i, expr := range want {
+		if !regexp.MustCompile(expr).MatchString(got[i]) {
+			t.Errorf("action %d = %q, want %s", i, got[i], expr)
+		}
+	}
+	if s := statusOf(t, a, "A"); s.Status != StatusStarting || s.Pid != 900002 {
+		t.Errorf("status = %s PID %d, want %s PID 900002", s.Status, s.Pid, StatusStarting)
+	}
+}
+
+func TestOpsQueuedOpCancelled(t *testing.T) {
+	a, l := newTestApp(t, opsTestINI)
+	reached, release := blockOn(l, `^would start`)
+	defer release()
+	start := a.Start(context.Background(), "A")
+	<-reached
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := a.Stop(ctx, "A")
+	cancel()
+	release()
+	if err := start.Wait(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := stop.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Errorf("stop = %v, want %v", err, context.Canceled)
+	}
+	if got := l.matching(`^would kill`); len(got) != 0 {
+		t.Errorf("cancelled stop killed: %q", got)
+	}
+	a.mu.Lock()
+	manual := a.manualStop["A"]
+	a.mu.Unlock()
+	if manual || !process.IsPidAlive(pidOf(a, "A")) {
+		t.Errorf("cancelled stop took effect: manual stop %v, PID %d alive %v", manual, pidOf(a, "A"), process.IsPidAlive(pidOf(a, "A")))
+	}
+}
+
+func TestOpsRestartCancelledAfterKill(t *testing.T) {
+	a, l := newTestApp(t, opsTestINI)
+	if err := a.Start(context.Background(), "A").Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l.setHook(func(note string) {
+		if strings.HasPrefix(note, "would kill") {
+			cancel()
+		}
+	})
+	if err := a.Restart(ctx, "A").Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("restart = %v, want %v", err, context.Canceled)
+	}
+	if got := l.matching(`^would start`); len(got) != 1 {
+		t.Errorf("starts = %q, want only the first", got)
+	}
+	if pid := pidOf(a, "A"); pid != 0 {
+		t.Errorf("PID = %d after the kill, want 0", pid)
+	}
+	if s := statusOf(t, a, "A"); s.Status != StatusBackoff {
+		t.Errorf("status = %s, want %s", s.Status, StatusBackoff)
+	}
+}
+
+func TestExitedIgnoredWhileOpPending(t *testing.T) {
+	a, l := newTestApp(t, opsTestINI)
+	if err := a.Start(context.Background(), "A").Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	pid := pidOf(a, "A")
+	reached, release := blockOn(l, `^would kill`)
+	defer release()
+	restart := a.Restart(context.Background(), "A")
+	<-reached
+
+	a.exited("A", pid, nil)
+	a.mu.Lock()
+	reason, pending := a.reasons["A"], a.queueFor("A").pending()
+	_, exit := a.lastExit["A"]
+	a.mu.Unlock()
+	if pending != OpRestart || reason != "" || exit {
+		t.Errorf("exit during %q recorded: reason %q, last exit %v", pending, reason, exit)
+	}
+	release()
+	if err := restart.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an operation the same report counts.
+	pid = pidOf(a, "A")
+	a.exited("A", pid, nil)
+	a.mu.Lock()
+	reason = a.reasons["A"]
+	a.mu.Unlock()
+	if reason != ReasonExited {
+		t.Errorf("reason = %q after an exit, want %q", reason, ReasonExited)
+	}
+}
+
+func TestOpWaitTimeout(t *testing.T) {
+	a, l := newTestApp(t, opsTestINI)
+	reached, release := blockOn(l, `^would start`)
+	defer release()
+	op := a.Start(context.Background(), "A")
+	<-reached
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := op.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := op.Err(); err != nil {
+		t.Errorf("Err = %v before the op finished, want nil", err)
+	}
+	release()
+	if err := op.Wait(context.Background()); err != nil {
+		t.Fatalf("op failed after the wait timed out: %v", err)
+	}
+	if pid := pidOf(a, "A"); !process.IsPidAlive(pid) {
+		t.Errorf("PID %d not started", pid)
+	}
+}
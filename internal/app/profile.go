@@ -0,0 +1,54 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ActivateProfile switches to a profile from the config, or back to the
+// plain config for "". Only processes whose state changes are touched:
+// newly disabled ones are stopped, newly enabled ones are started by the
+// monitor loop and ones with overridden launch fields are restarted. The
+// choice lasts until the next switch or a change of settings.profile.
+func (a *App) ActivateProfile(name string) error {
+	name = strings.TrimSpace(name)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cfg, err := a.base.WithProfile(name)
+	if err != nil {
+		return err
+	}
+	if name == a.profile {
+		return nil
+	}
+	a.profile = name
+	a.applyConfig(cfg, true)
+	return nil
+}
+
+// Profiles returns the active profile and all defined ones.
+func (a *App) Profiles() (string, []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.profile, a.base.ProfileNames()
+}
+
+// describeProfiles lists profiles for ctl, marking the active one.
+func (a *App) describeProfiles() string {
+	active, names := a.Profiles()
+	if len(names) == 0 {
+		return "no profiles defined"
+	}
+	var b strings.Builder
+	for _, name := range names {
+		mark := " "
+		if name == active {
+			mark = "*"
+		}
+		fmt.Fprintf(&b, "%s %s\n", mark, name)
+	}
+	if active == "" {
+		b.WriteString("(no profile active)\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
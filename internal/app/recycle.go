@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/schedule"
+)
+
+// recycleState is the planned recycle of one run of a process.
+type recycleState struct {
+	pid    int
+	at     time.Time
+	reason string
+}
+
+// applyRecycles parses the restartAt times of cfg. Planned recycles are
+// dropped and worked out again on the next check. The caller holds a.mu.
+func (a *App) applyRecycles(cfg config.Config) {
+	names := make([]string, 0, len(cfg.Process))
+	for name := range cfg.Process {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	a.recycleTimes = make(map[string]*schedule.Times)
+	for _, name := range names {
+		t, err := cfg.Process[name].RecycleTimes()
+		if err != nil {
+			a.logger.Printf("%s %s: restartAt ignored: %v", LogTag, name, err)
+			continue
+		}
+		if t != nil {
+			a.recycleTimes[name] = t
+		}
+	}
+	a.recycles = make(map[string]recycleState)
+}
+
+// nextRecycle returns when the running process name is recycled: after
+// maxUptime counted from its start time or at the next restartAt time,
+// whichever comes first. The plan is kept until the PID changes. The
+// caller holds a.mu.
+func (a *App) nextRecycle(name string, item *config.ProcessItem, now time.Time) (recycleState, bool) {
+	times := a.recycleTimes[name]
+	if item.MaxUptime.Duration <= 0 && times == nil {
+		return recycleState{}, false
+	}
+	if r, ok := a.recycles[name]; ok && r.pid == item.Pid {
+		return r, true
+	}
+	r := recycleState{pid: item.Pid}
+	if d := item.MaxUptime.Duration; d > 0 {
+		start := now
+		if t, ok := a.getStartTime(item.Pid); ok {
+			start = t
+		}
+		r.at, r.reason = start.Add(d), "maxUptime "+d.String()
+	}
+	if times != nil {
+		if next := times.Next(now); !next.IsZero() && (r.at.IsZero() || next.Before(r.at)) {
+			r.at, r.reason = next, "restartAt "+next.Format("15:04")
+		}
+	}
+	a.recycles[name] = r
+	return r, !r.at.IsZero()
+}
+
+// recycle stops a process and schedules its relaunch after DelayStartTime,
+// the same way a restart-all schedule does for every process. The caller
+// holds a.mu.
+func (a *App) recycle(name string, item *config.ProcessItem, r recycleState, now time.Time) {
+	a.logger.Printf("%s %s recycled: %s", LogTag, name, r.reason)
+	delete(a.recycles, name)
+	delete(a.hungSince, name)
+	a.reasons[name] = ReasonRecycle
+	a.restartAt[name] = now.Add(item.DelayStartTime.Duration)
+	a.enqueue(context.Background(), name, opKill, nil)
+}
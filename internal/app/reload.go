@@ -0,0 +1,233 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/display"
+	"goRunFiles/internal/process"
+)
+
+// configDiff describes how processes differ between two configs.
+type configDiff struct {
+	Added     []string
+	Removed   []string
+	Relaunch  []string // launch fields changed, process must be restarted to apply
+	Updated   []string // only monitoring fields changed
+	Unchanged []string
+}
+
+func (d configDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Relaunch) == 0 && len(d.Updated) == 0
+}
+
+func (d configDiff) String() string {
+	return fmt.Sprintf("+%d -%d ~%d (relaunch %d)", len(d.Added), len(d.Removed), len(d.Updated)+len(d.Relaunch), len(d.Relaunch))
+}
+
+func diffConfig(oldCfg, newCfg config.Config) configDiff {
+	var d configDiff
+	for _, name := range sortedProcessNames(newCfg) {
+		newItem := newCfg.Process[name]
+		oldItem, ok := oldCfg.Process[name]
+		switch {
+		case !ok || oldItem == nil:
+			d.Added = append(d.Added, name)
+		case launchChanged(oldItem, newItem):
+			d.Relaunch = append(d.Relaunch, name)
+		case monitorChanged(oldItem, newItem):
+			d.Updated = append(d.Updated, name)
+		default:
+			d.Unchanged = append(d.Unchanged, name)
+		}
+	}
+	for _, name := range sortedProcessNames(oldCfg) {
+		if _, ok := newCfg.Process[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	return d
+}
+
+// launchChanged reports whether fields used by runner.Start differ.
+func launchChanged(a, b *config.ProcessItem) bool {
+	return a.Type != b.Type ||
+		a.Process != b.Process ||
+		a.Path != b.Path ||
+		a.Command != b.Command ||
+		a.Args != b.Args ||
+		a.Screen != b.Screen
+}
+
+// monitorChanged reports whether fields used only for checks differ.
+func monitorChanged(a, b *config.ProcessItem) bool {
+	return a.Disabled != b.Disabled ||
+		a.CheckProcess != b.CheckProcess ||
+		a.CheckCmdline != b.CheckCmdline ||
+		a.CheckCmdlineExclude != b.CheckCmdlineExclude ||
+		a.DelayStartTime != b.DelayStartTime ||
+		a.StartTimeout != b.StartTimeout ||
+		a.MinUptime != b.MinUptime ||
+		a.MonitorHang != b.MonitorHang ||
+		a.HangTimeout != b.HangTimeout ||
+		a.RunWindow != b.RunWindow ||
+		a.MaxUptime != b.MaxUptime ||
+		a.RestartAt != b.RestartAt
+}
+
+// forgetProcess drops all runtime state kept for a process name.
+func (a *App) forgetProcess(name string) {
+	delete(a.last, name)
+	delete(a.restartAt, name)
+	delete(a.firstStart, name)
+	delete(a.hungSince, name)
+	delete(a.manualStop, name)
+	delete(a.windowOverride, name)
+	delete(a.recycles, name)
+	delete(a.defaultDisabled, name)
+	delete(a.reasons, name)
+	delete(a.lastExit, name)
+	delete(a.launchedAt, name)
+	if q := a.queues[name]; q != nil {
+		q.err, q.failures = "", 0
+	}
+}
+
+// LoadConfig loads the config from path with config.Load. In a dry run or
+// simulation the files are left as they are: a repair Load would write is
+// made in memory and logged.
+func LoadConfig(path string, logger *log.Logger) (config.Config, error) {
+	mode := process.SandboxMode()
+	if mode == "" {
+		return config.Load(path)
+	}
+	cfg, repaired, err := config.LoadReadOnly(path)
+	for _, file := range repaired {
+		logger.Printf("%s %s: would repair unquoted backslashes in %s", LogTag, mode, file)
+	}
+	return cfg, err
+}
+
+// WatchConfig reloads the config from path whenever the file changes and
+// settings.watchConfig is enabled in the edited file, so an edit that turns
+// it on is applied too. A broken edit keeps the last good config and is
+// reported in the snapshot until the file is fixed, as long as the current
+// config enables watching.
+func (a *App) WatchConfig(ctx context.Context, path string) {
+	load := func(path string) (config.Config, error) { return LoadConfig(path, a.logger) }
+	config.Watch(ctx, path, config.DefaultWatchInterval, load, func(cfg config.Config, err error) {
+		enabled := cfg.Settings.WatchConfig
+		if err != nil {
+			a.mu.Lock()
+			enabled = a.cfg.Settings.WatchConfig
+			a.mu.Unlock()
+		}
+		if !enabled {
+			return
+		}
+		if err != nil {
+			a.logger.Printf("%s config reload failed, keeping previous config: %v", LogTag, err)
+			a.setConfigError(err)
+			return
+		}
+		if ds := config.Validate(cfg, ValidateOptions()); ds.HasErrors() {
+			a.logger.Printf("%s config reload rejected, keeping previous config: %v", LogTag, ds.Err())
+			a.setConfigError(ds.Err())
+			return
+		}
+		a.UpdateConfig(cfg)
+	})
+}
+
+// ValidateOptions returns validation options for the current machine.
+func ValidateOptions() config.ValidateOptions {
+	screens, _ := display.ListScreens()
+	return config.ValidateOptions{Screens: len(screens)}
+}
+
+func (a *App) setConfigError(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.configErr = err.Error()
+}
+
+// ConfigError returns the last config reload error, if any.
+func (a *App) ConfigError() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.configErr
+}
+
+// timings returns the current check, restart and metrics intervals. The
+// check interval is the adaptive one, see adaptCheck.
+func (a *App) timings() (time.Duration, time.Duration, time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.cfg.Settings
+	check := s.CheckTiming.Duration
+	if a.checkEvery > 0 && s.CheckTimingMax.Duration > check {
+		check = min(a.checkEvery, s.CheckTimingMax.Duration)
+	}
+	metrics := s.MetricsTiming.Duration
+	if metrics <= 0 {
+		metrics = s.CheckTiming.Duration
+	}
+	return check, s.RestartTiming.Duration, metrics
+}
+
+// adaptCheck works out the next check interval: checkTiming while a
+// process is down, starting, hung or being worked on, doubling up to
+// checkTimingMax while everything stays settled. Exits of tracked
+// processes are caught in between anyway. The caller holds a.mu.
+func (a *App) adaptCheck(statuses []procStatus) {
+	a.settled = true
+	for _, st := range statuses {
+		switch st.Status {
+		case StatusStarting, StatusStopping, StatusBackoff, StatusUnhealthy, StatusCrashed:
+			a.settled = false
+		}
+		if st.Pending != "" || st.Hung {
+			a.settled = false
+		}
+	}
+	base := a.cfg.Settings.CheckTiming.Duration
+	if !a.settled || a.checkEvery < base {
+		a.checkEvery = base
+		return
+	}
+	a.checkEvery = min(2*a.checkEvery, max(a.cfg.Settings.CheckTimingMax.Duration, base))
+}
+
+// isSettled reports whether the last check found nothing to wait for.
+func (a *App) isSettled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.settled
+}
+
+// resetTicker applies a changed interval after a config reload.
+func resetTicker(t *time.Ticker, cur *time.Duration, next time.Duration) {
+	if next <= 0 || next == *cur {
+		return
+	}
+	*cur = next
+	t.Reset(next)
+}
+
+func sortedProcessNames(cfg config.Config) []string {
+	names := make([]string, 0, len(cfg.Process))
+	for name := range cfg.Process {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func joinNames(names []string) string {
+	return strings.Join(names, ", ")
+}
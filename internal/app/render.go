@@ -7,14 +7,20 @@ import (
 	"time"
 
 	"goRunFiles/internal/config"
+	"goRunFiles/internal/process"
 
 	"github.com/mattn/go-runewidth"
 )
 
+// renderSandboxActions is the number of recent dry-run or simulation
+// actions shown on the console.
+const renderSandboxActions = 5
+
 func (a *App) render(statuses []procStatus) {
 	enableANSI()
 
-	now := time.Now().Format("2006-01-02 15:04:05")
+	t := time.Now()
+	now := t.Format("2006-01-02 15:04:05")
 	clearConsole()
 	var b strings.Builder
 	b.Grow(1024)
@@ -28,9 +34,53 @@ func (a *App) render(statuses []procStatus) {
 			fmt.Fprintf(&b, "Version: %s\n", a.version)
 		}
 	}
+	if profile, _ := a.Profiles(); profile != "" {
+		fmt.Fprintf(&b, "Profile: %s\n", profile)
+	}
+	if mode := process.SandboxMode(); mode != "" {
+		line := fmt.Sprintf("Mode: %s, nothing is started or killed", mode)
+		if ansiEnabled {
+			fmt.Fprintf(&b, "\x1b[36m%s\x1b[0m\n", line)
+		} else {
+			fmt.Fprintf(&b, "%s\n", line)
+		}
+		actions := process.SandboxActions()
+		for _, act := range actions[max(len(actions)-renderSandboxActions, 0):] {
+			fmt.Fprintf(&b, "  %s\n", act)
+		}
+	}
+	for _, s := range a.Schedules() {
+		next := s.Next
+		if next == "" {
+			next = "never"
+		}
+		fmt.Fprintf(&b, "Schedule %s: next %s, %s", s.Name, next, s.Action)
+		if s.Target != "" {
+			fmt.Fprintf(&b, " %s", s.Target)
+		}
+		b.WriteString("\n")
+	}
+	for _, m := range a.Maintenances() {
+		line := fmt.Sprintf("Maintenance of %s until %s", m.scope(), m.Until.Format("2006-01-02 15:04:05"))
+		if m.Reason != "" {
+			line += ": " + m.Reason
+		}
+		if ansiEnabled {
+			fmt.Fprintf(&b, "\x1b[33m%s\x1b[0m\n", line)
+		} else {
+			fmt.Fprintf(&b, "%s\n", line)
+		}
+	}
+	if cfgErr := a.ConfigError(); cfgErr != "" {
+		if ansiEnabled {
+			fmt.Fprintf(&b, "Config: \x1b[31m%s\x1b[0m\n", cfgErr)
+		} else {
+			fmt.Fprintf(&b, "Config: %s\n", cfgErr)
+		}
+	}
 	b.WriteString("\n")
 
-	headers := []string{"NAME", "TYPE", "STATUS", "PID", "STARTED", "UPTIME", "TARGET", "ERROR"}
+	headers := []string{"NAME", "TYPE", "STATUS", "PID", "STARTED", "UPTIME", "RECYCLE", "TARGET", "ERROR"}
 	widths := make([]int, len(headers))
 	for i, h := range headers {
 		widths[i] = len(h)
@@ -42,7 +92,8 @@ func (a *App) render(statuses []procStatus) {
 			s.Status.Icon(),
 			s.pidString(),
 			s.StartedAt,
-			s.Uptime,
+			s.uptimeText(t),
+			orDash(s.Recycle),
 			s.Target,
 			s.Err,
 		}
@@ -54,8 +105,8 @@ func (a *App) render(statuses []procStatus) {
 	}
 
 	maxErr := 60
-	if widths[7] > maxErr {
-		widths[7] = maxErr
+	if widths[8] > maxErr {
+		widths[8] = maxErr
 	}
 
 	// PID
@@ -77,9 +128,10 @@ func (a *App) render(statuses []procStatus) {
 			s.Status.Icon(),
 			truncateDisplay(s.pidString(), widths[3]),
 			truncateDisplay(s.StartedAt, widths[4]),
-			truncateDisplay(s.Uptime, widths[5]),
+			truncateDisplay(s.uptimeText(t), widths[5]),
+			orDash(s.Recycle),
 			s.Target,
-			truncateDisplay(s.Err, widths[7]),
+			truncateDisplay(s.Err, widths[8]),
 		}
 		line := formatRowWithColors(row, widths, func(col int, text string) string {
 			if !ansiEnabled {
@@ -168,13 +220,39 @@ func colorizeStatus(s Status, text string) string {
 	switch s {
 	case StatusRunning:
 		return "\x1b[32m" + text + "\x1b[39m"
-	case StatusStarted:
+	case StatusStarting, StatusStopping, StatusBackoff, StatusUnhealthy:
 		return "\x1b[33m" + text + "\x1b[39m"
-	case StatusStopped, StatusUnknown:
+	case StatusStopped, StatusCrashed, StatusFatal, StatusUnknown:
 		return "\x1b[31m" + text + "\x1b[39m"
-	case StatusDisabled:
+	case StatusDisabled, StatusScheduledOff:
 		return "\x1b[90m" + text + "\x1b[39m"
 	default:
 		return text
 	}
 }
+
+// uptimeText fills the uptime column: the uptime of a running process, the
+// countdown to the next launch or when the run window opens, and for an
+// idle job its next run.
+func (s procStatus) uptimeText(now time.Time) string {
+	switch {
+	case s.Status == StatusScheduledOff:
+		return formatOpens(s.NextAttempt, now)
+	case !s.NextAttempt.IsZero():
+		return formatCountdown(s.NextAttempt.Sub(now))
+	case s.Status != StatusIdle:
+		return s.Uptime
+	case s.JobPaused:
+		return "paused"
+	case !s.NextRun.IsZero():
+		return "next " + formatNext(s.NextRun, now)
+	}
+	return "on demand"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
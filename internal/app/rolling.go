@@ -0,0 +1,255 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultRollingTimeout is how long a rolling restart waits for a process
+// to run when settings.rollingTimeout is 0.
+const defaultRollingTimeout = 2 * time.Minute
+
+// rollingEvents is the number of recent events RollingProgress keeps.
+const rollingEvents = 50
+
+// RollingOptions tune a rolling restart. Zero values take
+// settings.rollingBatch and settings.rollingTimeout.
+type RollingOptions struct {
+	Batch   int           // processes restarted at a time
+	Timeout time.Duration // how long a restarted process may take to run
+}
+
+// Rolling restart stages, see RollingEvent.
+const (
+	RollingRestarting = "restarting"
+	RollingRunning    = "running"
+	RollingFailed     = "failed"
+	RollingAborted    = "aborted"
+	RollingDone       = "done"
+)
+
+// RollingEvent is one step of a rolling restart.
+type RollingEvent struct {
+	At    int64  `json:"at"` // Unix ms
+	Name  string `json:"name"`
+	Stage string `json:"stage"`
+	Error string `json:"error,omitempty"`
+}
+
+// RollingProgress is the state of the running or last rolling restart.
+type RollingProgress struct {
+	Active  bool           `json:"active"`
+	Total   int            `json:"total"`
+	Done    int            `json:"done"`    // processes restarted and running
+	Current []string       `json:"current"` // processes of the batch in progress
+	Failed  string         `json:"failed"`  // process the restart was aborted on
+	Events  []RollingEvent `json:"events"`
+}
+
+// Rolling restarts the selected processes a batch at a time. Each batch
+// must be running again, past its start window and responding, within
+// the timeout before the next one is restarted; when a process fails, the
+// rest are left alone and reported as aborted. As with Bulk, a selection
+// of all processes or of a group skips disabled processes, jobs and
+// processes outside their run window. Only one rolling restart runs at a
+// time; its progress is part of the snapshot. ctx ends the wait, which
+// aborts the batches not yet restarted.
+func (a *App) Rolling(ctx context.Context, sel Selector, opts RollingOptions) ([]BulkResult, error) {
+	a.mu.Lock()
+	if a.rolling != nil && a.rolling.Active {
+		a.mu.Unlock()
+		return nil, fmt.Errorf("a rolling restart is already running")
+	}
+	names, err := a.selectProcesses(sel)
+	if err != nil {
+		a.mu.Unlock()
+		return nil, err
+	}
+	if opts.Batch <= 0 {
+		opts.Batch = max(a.cfg.Settings.RollingBatch, 1)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = a.cfg.Settings.RollingTimeout.Duration
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultRollingTimeout
+	}
+	now := time.Now()
+	results := make([]BulkResult, len(names))
+	var todo []int
+	for i, name := range names {
+		results[i].Name = name
+		if sel.All || sel.Group != "" {
+			if results[i].Skipped = a.bulkSkip(name, OpRestart, now); results[i].Skipped != "" {
+				continue
+			}
+		}
+		todo = append(todo, i)
+	}
+	a.rolling = &RollingProgress{Active: true, Total: len(todo)}
+	a.mu.Unlock()
+	a.logger.Printf("%s rolling restart of %d processes, %d at a time", LogTag, len(todo), opts.Batch)
+
+	failed := ""
+	for len(todo) > 0 {
+		n := min(opts.Batch, len(todo))
+		batch := todo[:n]
+		if failed != "" || ctx.Err() != nil {
+			for _, i := range todo {
+				results[i].Skipped = "aborted"
+				a.rollingEvent(results[i].Name, RollingAborted, "")
+			}
+			break
+		}
+		todo = todo[n:]
+		a.mu.Lock()
+		a.rolling.Current = a.rolling.Current[:0]
+		for _, i := range batch {
+			a.rolling.Current = append(a.rolling.Current, results[i].Name)
+		}
+		a.mu.Unlock()
+
+		var wg sync.WaitGroup
+		for _, i := range batch {
+			wg.Add(1)
+			go func(r *BulkResult) {
+				defer wg.Done()
+				start := time.Now()
+				err := a.rollOne(ctx, r.Name, opts.Timeout)
+				r.DurationMs = time.Since(start).Milliseconds()
+				if err != nil {
+					r.Error = err.Error()
+				}
+			}(&results[i])
+		}
+		wg.Wait()
+		for _, i := range batch {
+			if results[i].Error != "" && failed == "" {
+				failed = results[i].Name
+			}
+		}
+	}
+
+	a.mu.Lock()
+	a.rolling.Active = false
+	a.rolling.Current = nil
+	a.rolling.Failed = failed
+	done, total := a.rolling.Done, a.rolling.Total
+	a.mu.Unlock()
+	if failed != "" {
+		a.logger.Printf("%s rolling restart aborted on %s after %d of %d", LogTag, failed, done, total)
+	} else {
+		a.rollingEvent("", RollingDone, "")
+		a.logger.Printf("%s rolling restart done: %d restarted", LogTag, done)
+	}
+	return results, nil
+}
+
+// rollOne restarts one process and waits until it runs.
+func (a *App) rollOne(ctx context.Context, name string, timeout time.Duration) error {
+	a.rollingEvent(name, RollingRestarting, "")
+	err := a.submit(context.Background(), name, OpRestart).Wait(ctx)
+	if err == nil {
+		a.poke()
+		err = a.awaitRunning(ctx, name, timeout)
+	}
+	if err != nil {
+		a.logger.Printf("%s rolling restart: %s failed: %v", LogTag, name, err)
+		a.rollingEvent(name, RollingFailed, err.Error())
+		return err
+	}
+	a.mu.Lock()
+	a.rolling.Done++
+	a.mu.Unlock()
+	a.rollingEvent(name, RollingRunning, "")
+	return nil
+}
+
+// awaitRunning waits until the checks find a restarted process running:
+// alive past its start window and responding. It fails as soon as they
+// find it down or not responding, or after timeout.
+func (a *App) awaitRunning(ctx context.Context, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	tick := time.NewTicker(200 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		a.mu.Lock()
+		_, starting := a.launchedAt[name]
+		status := a.last[name]
+		msg := a.queueFor(name).err
+		a.mu.Unlock()
+		switch {
+		case status == StatusUnhealthy:
+			return errors.New("not responding")
+		case status == StatusRunning && !starting:
+			return nil
+		case !starting && status != StatusStarting:
+			if msg == "" {
+				msg = string(status)
+			}
+			return errors.New(msg)
+		}
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("not running within %s", timeout)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// rollingEvent records a step of the rolling restart.
+func (a *App) rollingEvent(name, stage, msg string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.rolling == nil {
+		return
+	}
+	a.rolling.Events = append(a.rolling.Events, RollingEvent{At: time.Now().UnixMilli(), Name: name, Stage: stage, Error: msg})
+	if n := len(a.rolling.Events); n > rollingEvents {
+		a.rolling.Events = append([]RollingEvent(nil), a.rolling.Events[n-rollingEvents:]...)
+	}
+}
+
+// RollingProgress returns the state of the running or last rolling
+// restart, nil if there was none.
+func (a *App) RollingProgress() *RollingProgress {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.rolling == nil {
+		return nil
+	}
+	p := *a.rolling
+	p.Current = append([]string(nil), p.Current...)
+	p.Events = append([]RollingEvent(nil), p.Events...)
+	return &p
+}
+
+// runRollingSchedule runs a rolling-restart schedule. It waits for checks
+// of the monitor loop, so the loop starts it on its own goroutine.
+func (a *App) runRollingSchedule(s *scheduleState, now time.Time) {
+	sel := Selector{Names: s.Targets()}
+	if len(sel.Names) == 0 {
+		sel = Selector{All: true}
+	}
+	results, err := a.Rolling(context.Background(), sel, RollingOptions{})
+	if err == nil {
+		err = BulkError(results)
+	}
+	a.mu.Lock()
+	s.lastRun = now
+	s.lastErr = ""
+	if err != nil {
+		s.lastErr = err.Error()
+	}
+	a.mu.Unlock()
+	if err != nil {
+		a.logger.Printf("%s schedule %s error: %v", LogTag, s.Name, err)
+	}
+}
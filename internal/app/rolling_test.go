@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// runChecks stands in for the monitor loop while a rolling restart waits:
+// it checks the processes every few ms as if their start window were
+// over, without launching any, until the returned stop is called.
+func runChecks(a *App) (stop func()) {
+	done, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(2 * time.Millisecond):
+				a.computeStatuses(false, time.Now().Add(time.Minute))
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// B has no batch file to launch, so restarting it fails.
+const rollingTestINI = `
+[process "A"]
+type=exe
+path=C:/apps
+process=a.exe
+
+[process "B"]
+type=bat
+path=C:/apps
+
+[process "C"]
+type=exe
+path=C:/apps
+process=c.exe
+`
+
+func TestRollingAbortsAfterFailure(t *testing.T) {
+	a, l := newTestApp(t, rollingTestINI)
+	stop := runChecks(a)
+	defer stop()
+	results, err := a.Rolling(context.Background(), Selector{All: true}, RollingOptions{Batch: 1, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bulkOutcome{
+		{"A", "", ""},
+		{"B", "bat process is empty", ""},
+		{"C", "", "aborted"},
+	}
+	if got := outcomes(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("results:\n got %v\nwant %v", got, want)
+	}
+	if got := l.matching(`^would start c\.exe`); len(got) != 0 {
+		t.Errorf("C restarted after the abort: %q", got)
+	}
+	p := a.RollingProgress()
+	if p.Active || p.Total != 3 || p.Done != 1 || p.Failed != "B" {
+		t.Errorf("progress = active %v, %d of %d done, failed %q; want 1 of 3, failed B", p.Active, p.Done, p.Total, p.Failed)
+	}
+	var stages []string
+	for _, e := range p.Events {
+		stages = append(stages, e.Name+" "+e.Stage)
+	}
+	wantStages := []string{"A restarting", "A running", "B restarting", "B failed", "C aborted"}
+	if !reflect.DeepEqual(stages, wantStages) {
+		t.Errorf("events = %q, want %q", stages, wantStages)
+	}
+}
+
+func TestRollingRunsOnce(t *testing.T) {
+	a, l := newTestApp(t, rollingTestINI)
+	stop := runChecks(a)
+	defer stop()
+	reached, release := blockOn(l, `^would start a\.exe`)
+	defer release()
+	first := make(chan error, 1)
+	go func() {
+		_, err := a.Rolling(context.Background(), Selector{Names: []string{"A"}}, RollingOptions{Timeout: 5 * time.Second})
+		first <- err
+	}()
+	<-reached
+
+	if _, err := a.Rolling(context.Background(), Selector{Names: []string{"C"}}, RollingOptions{}); err == nil || err.Error() != "a rolling restart is already running" {
+		t.Errorf("second rolling restart = %v, want it refused", err)
+	}
+	release()
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+	if p := a.RollingProgress(); p.Active || p.Done != 1 || p.Failed != "" {
+		t.Errorf("progress = active %v, %d done, failed %q; want 1 done", p.Active, p.Done, p.Failed)
+	}
+	// Once it is over, the next one may run.
+	if _, err := a.Rolling(context.Background(), Selector{Names: []string{"C"}}, RollingOptions{Timeout: 5 * time.Second}); err != nil {
+		t.Errorf("rolling restart after the first: %v", err)
+	}
+}
+
+func TestRollingKeepsManualStops(t *testing.T) {
+	a, _ := newTestApp(t, bulkTestINI)
+	ctx := context.Background()
+	for _, name := range []string{"J", "W"} {
+		if err := a.Stop(ctx, name).Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stop := runChecks(a)
+	results, err := a.Rolling(ctx, Selector{All: true}, RollingOptions{Timeout: 5 * time.Second})
+	stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := BulkError(results); err != nil {
+		t.Fatal(err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.manualStop["J"] || !a.manualStop["W"] {
+		t.Errorf("manual stops after a rolling restart of all: %v, want J and W kept", a.manualStop)
+	}
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/process"
+)
+
+// matchQuery is one process lookup of a check, see probeItem.
+type matchQuery struct {
+	name, args, exclude string
+}
+
+// matchQueries returns the lookups probeItem makes for item, or a note
+// when it does not look processes up.
+func matchQueries(item *config.ProcessItem) ([]matchQuery, string) {
+	cmdline := strings.TrimSpace(item.CheckCmdline)
+	var names []string
+	switch item.Type {
+	case config.TypeExe:
+		names = parseProcessList(item.Process, item.CheckProcess)
+	case config.TypeCmd, config.TypeBat:
+		switch {
+		case cmdline != "", strings.TrimSpace(item.CheckProcess) != "":
+			names = parseProcessList("", item.CheckProcess)
+		case item.Type == config.TypeBat && strings.TrimSpace(item.Process) != "":
+			return []matchQuery{{args: item.Process}}, ""
+		default:
+			return nil, "checked by its launched PID only"
+		}
+	case config.TypeJob:
+		return nil, "job, not checked"
+	default:
+		return nil, "unknown type " + item.Type
+	}
+	queries := make([]matchQuery, 0, len(names))
+	for _, n := range names {
+		q := matchQuery{name: n}
+		if cmdline != "" {
+			q.args, q.exclude = cmdline, item.CheckCmdlineExclude
+		}
+		queries = append(queries, q)
+	}
+	return queries, ""
+}
+
+func (q matchQuery) String() string {
+	name := q.name
+	if name == "" {
+		name = "any process"
+	}
+	if q.args == "" {
+		return name
+	}
+	return fmt.Sprintf("%s with %q", name, q.args)
+}
+
+// Matches describes, for the selected processes, the PID their check
+// finds, every process its lookups match and the ones checkCmdlineExclude
+// leaves out. It looks at the table the checks see: the live one or the
+// one of a dry run or simulation.
+func (a *App) Matches(sel Selector) (string, error) {
+	a.mu.Lock()
+	names, err := a.selectProcesses(sel)
+	items := make([]config.ProcessItem, len(names))
+	for i, name := range names {
+		items[i] = *a.cfg.Process[name]
+	}
+	a.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i, name := range names {
+		item := &items[i]
+		queries, note := matchQueries(item)
+		switch item.Type {
+		case config.TypeExe, config.TypeCmd, config.TypeBat:
+		default:
+			fmt.Fprintf(&b, "%s: %s\n", name, note)
+			continue
+		}
+		switch p := probeItem(item); {
+		case p.alive:
+			fmt.Fprintf(&b, "%s: running, PID %d\n", name, p.pid)
+		case p.err != "":
+			fmt.Fprintf(&b, "%s: not running (%s)\n", name, p.err)
+		default:
+			fmt.Fprintf(&b, "%s: not running\n", name)
+		}
+		if note != "" {
+			fmt.Fprintf(&b, "  %s\n", note)
+		}
+		for _, q := range queries {
+			matched, excluded, err := process.Explain(q.name, q.args, q.exclude)
+			if err != nil {
+				return "", err
+			}
+			if len(matched) == 0 && len(excluded) == 0 {
+				fmt.Fprintf(&b, "  %s: no process\n", q)
+			}
+			for _, p := range matched {
+				fmt.Fprintf(&b, "  %s: PID %d %s\n", q, p.Pid, p.Describe())
+			}
+			for _, p := range excluded {
+				fmt.Fprintf(&b, "  %s: PID %d excluded by checkCmdlineExclude, %s\n", q, p.Pid, p.Describe())
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
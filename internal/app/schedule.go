@@ -0,0 +1,204 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/process"
+	"goRunFiles/internal/schedule"
+)
+
+var errAlreadyRunning = errors.New("already running")
+
+// scheduleState is the runtime state of one config schedule.
+type scheduleState struct {
+	config.ScheduleEntry
+	cron    *schedule.Cron
+	loc     *time.Location
+	next    time.Time
+	lastRun time.Time
+	lastErr string
+	due     bool // a missed run is made up on the next check
+}
+
+// ScheduleInfo is a UI-friendly view of a schedule.
+type ScheduleInfo struct {
+	Name      string `json:"name"`
+	Cron      string `json:"cron"`
+	Action    string `json:"action"`
+	Target    string `json:"target"`
+	Next      string `json:"next"`
+	LastRun   string `json:"last_run"`
+	LastError string `json:"last_error"`
+}
+
+// applySchedules rebuilds the schedules from cfg, keeping the state of
+// unchanged ones. Next runs are counted from now, so a new or edited
+// schedule never fires for a time that has already passed. At startup a
+// run missed while the PC was off is made up if it is within catchUp.
+func (a *App) applySchedules(cfg config.Config, now time.Time, startup bool) {
+	prev := make(map[string]*scheduleState, len(a.schedules))
+	for _, s := range a.schedules {
+		prev[s.Name] = s
+	}
+	a.schedules = nil
+	for _, entry := range cfg.Schedules() {
+		if old := prev[entry.Name]; old != nil && old.Schedule == entry.Schedule {
+			a.schedules = append(a.schedules, old)
+			continue
+		}
+		c, loc, err := entry.Parse()
+		if err != nil {
+			a.logger.Printf("%s schedule %s disabled: %v", LogTag, entry.Name, err)
+			continue
+		}
+		s := &scheduleState{ScheduleEntry: entry, cron: c, loc: loc, next: c.Next(now.In(loc))}
+		if s.next.IsZero() {
+			a.logger.Printf("%s schedule %s never runs: %s", LogTag, entry.Name, entry.Cron)
+		}
+		if startup && entry.CatchUp.Duration > 0 {
+			if boot, ok := process.BootTime(); ok {
+				if missed := c.Last(now.Add(-entry.CatchUp.Duration).In(loc), boot); !missed.IsZero() {
+					a.logger.Printf("%s schedule %s: run at %s was missed, running now", LogTag, entry.Name, missed.Format("2006-01-02 15:04:05"))
+					s.due = true
+				}
+			}
+		}
+		a.schedules = append(a.schedules, s)
+	}
+}
+
+// runSchedules runs the actions of schedules that are due.
+func (a *App) runSchedules(now time.Time) {
+	a.mu.Lock()
+	var due []*scheduleState
+	for _, s := range a.schedules {
+		if s.due || !s.next.IsZero() && !now.Before(s.next) {
+			s.due = false
+			s.next = s.cron.Next(now.In(s.loc))
+			due = append(due, s)
+		}
+	}
+	a.mu.Unlock()
+
+	// Actions lock a.mu themselves.
+	for _, s := range due {
+		a.logger.Printf("%s schedule %s: %s", LogTag, s.Name, describeAction(s.Schedule))
+		if s.ActionName() == config.ActionRollingRestart {
+			go a.runRollingSchedule(s, now)
+			continue
+		}
+		err := a.runScheduleAction(s.ScheduleEntry, now)
+		a.mu.Lock()
+		s.lastRun = now
+		s.lastErr = ""
+		if err != nil {
+			s.lastErr = err.Error()
+		}
+		a.mu.Unlock()
+		if err != nil {
+			a.logger.Printf("%s schedule %s error: %v", LogTag, s.Name, err)
+		}
+	}
+}
+
+func (a *App) runScheduleAction(s config.ScheduleEntry, now time.Time) error {
+	switch action := s.ActionName(); action {
+	case config.ActionRestartAll:
+		return a.restartAllDelayed(now)
+	case config.ActionMaintenance:
+		return a.maintenanceSchedule(s)
+	}
+	action := s.ActionName()
+	var errs []error
+	for _, name := range a.scheduleTargets(s.Targets()) {
+		var err error
+		switch action {
+		case config.ActionStart:
+			if err = a.StartProcess(name); errors.Is(err, errAlreadyRunning) {
+				err = nil
+			}
+		case config.ActionStop:
+			err = a.StopProcess(name)
+		case config.ActionRestart:
+			err = a.RestartProcess(name)
+		default:
+			return fmt.Errorf("unknown action %q", s.Action)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scheduleTargets resolves target names: an entry with instances stands
+// for all of them. Unknown names are kept so the action reports them.
+func (a *App) scheduleTargets(targets []string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var out []string
+	for _, t := range targets {
+		if _, ok := a.cfg.Process[t]; ok {
+			out = append(out, t)
+			continue
+		}
+		var instances []string
+		for name := range a.cfg.Process {
+			if base, index := config.BaseName(name); index > 0 && base == t {
+				instances = append(instances, name)
+			}
+		}
+		if len(instances) == 0 {
+			out = append(out, t)
+			continue
+		}
+		sort.Strings(instances)
+		out = append(out, instances...)
+	}
+	return out
+}
+
+func describeAction(s config.Schedule) string {
+	if action := s.ActionName(); action != config.ActionRestartAll {
+		target := s.Target
+		if len(s.Targets()) == 0 {
+			target = "all"
+		}
+		if action == config.ActionMaintenance {
+			return fmt.Sprintf("%s %s for %s", action, target, s.Duration.Duration)
+		}
+		return action + " " + target
+	}
+	return config.ActionRestartAll
+}
+
+// Schedules returns the state of the active schedules.
+func (a *App) Schedules() []ScheduleInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]ScheduleInfo, 0, len(a.schedules))
+	for _, s := range a.schedules {
+		info := ScheduleInfo{
+			Name:      s.Name,
+			Cron:      s.Cron,
+			Action:    s.ActionName(),
+			Target:    s.Target,
+			LastError: s.lastErr,
+		}
+		if !s.next.IsZero() {
+			info.Next = s.next.Format("2006-01-02 15:04:05")
+			if s.Timezone != "" {
+				info.Next += " " + s.Timezone
+			}
+		}
+		if !s.lastRun.IsZero() {
+			info.LastRun = s.lastRun.Format("2006-01-02 15:04:05")
+		}
+		out = append(out, info)
+	}
+	return out
+}
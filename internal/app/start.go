@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"goRunFiles/internal/config"
+)
+
+// startWindow is how long a launched process shows as starting: its
+// minUptime, at least startGrace.
+func startWindow(item *config.ProcessItem) time.Duration {
+	return max(item.MinUptime.Duration, startGrace)
+}
+
+// checkStart follows a launched process its checks do not find. While
+// startTimeout runs and the launched PID lives, the process is still
+// starting and checkStart fills status and returns true. A PID still not
+// found after startTimeout is killed, and one gone within minUptime is a
+// failed start; both count towards fatalLaunchFailures. The caller holds
+// a.mu.
+func (a *App) checkStart(name string, item *config.ProcessItem, status *procStatus, pid int, pidAlive bool, now time.Time) bool {
+	launched, ok := a.launchedAt[name]
+	if !ok {
+		return false
+	}
+	up := now.Sub(launched)
+	if pidAlive {
+		d := item.StartTimeout.Duration
+		if d <= 0 {
+			delete(a.launchedAt, name)
+			return false
+		}
+		if up < d {
+			status.Status = StatusStarting
+			status.Pid = pid
+			status.StartedAt = launched.Format("2006-01-02 15:04:05")
+			status.Uptime = formatUptime(up)
+			a.last[name] = StatusStarting
+			return true
+		}
+		// Kill the launched PID only; the checks did not find anything else.
+		a.enqueue(context.Background(), name, opKill, &config.ProcessItem{Type: item.Type, Pid: pid})
+		a.failStart(name, fmt.Sprintf("not found within startTimeout %s", d), now)
+		return false
+	}
+
+	delete(a.launchedAt, name)
+	d := item.MinUptime.Duration
+	lived := up
+	e := a.lastExit[name]
+	if e.Pid == pid && !e.At.Before(launched) {
+		lived = e.At.Sub(launched)
+	}
+	if d <= 0 || lived >= d {
+		return false
+	}
+	msg := fmt.Sprintf("exited %s after launch", lived.Round(100*time.Millisecond))
+	if e.Pid == pid && e.Err != "" {
+		msg += ": " + e.Err
+	}
+	a.failStart(name, msg, now)
+	return false
+}
+
+// failStart counts a failed start and puts the process in backoff for
+// restartTiming. The caller holds a.mu.
+func (a *App) failStart(name, reason string, now time.Time) {
+	q := a.queueFor(name)
+	q.failures++
+	q.err = reason
+	a.logger.Printf("%s %s failed to start: %s", LogTag, name, reason)
+	delete(a.launchedAt, name)
+	a.reasons[name] = ReasonStartFailed
+	a.restartAt[name] = now.Add(a.cfg.Settings.RestartTiming.Duration)
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"goRunFiles/internal/process"
+)
+
+func TestFailedStartsReachFatal(t *testing.T) {
+	a, l := newTestApp(t, `
+[process "A"]
+type=exe
+path=C:/apps
+process=a.exe
+minUptime=1m
+`)
+	now := time.Now()
+	a.computeStatuses(true, now) // plans the first launch
+	for i := 1; i <= fatalLaunchFailures; i++ {
+		// Launch as soon as the restart is due, then let the process die
+		// within minUptime.
+		now = now.Add(a.cfg.Settings.RestartTiming.Duration)
+		a.computeStatuses(true, now)
+		waitFor(t, fmt.Sprintf("launch %d", i), func() bool { return idle(a) })
+		pid := pidOf(a, "A")
+		if !process.IsPidAlive(pid) {
+			t.Fatalf("launch %d: PID %d not started", i, pid)
+		}
+		if err := process.KillPidContext(context.Background(), pid); err != nil {
+			t.Fatal(err)
+		}
+		statuses := a.computeStatuses(false, now)
+		a.mu.Lock()
+		failures := a.queueFor("A").failures
+		a.mu.Unlock()
+		if failures != i {
+			t.Fatalf("after start %d: %d failures counted", i, failures)
+		}
+		want := StatusBackoff
+		if i == fatalLaunchFailures {
+			want = StatusFatal
+		}
+		if s := statuses[0]; s.Status != want || s.Reason != ReasonStartFailed {
+			t.Fatalf("after start %d: %s (%s), want %s (%s)", i, s.Status, s.Reason, want, ReasonStartFailed)
+		}
+	}
+	if got := l.matching(`A: 5 starts failed, giving up until START$`); len(got) != 1 {
+		t.Errorf("giving up logged %d times", len(got))
+	}
+
+	// FATAL is final: no more launches, however long the monitor waits.
+	a.computeStatuses(true, now.Add(time.Hour))
+	waitFor(t, "checks", func() bool { return idle(a) })
+	if got := l.matching(`^would start`); len(got) != fatalLaunchFailures {
+		t.Errorf("%d launches, want %d", len(got), fatalLaunchFailures)
+	}
+	if s := statusOf(t, a, "A"); s.Status != StatusFatal {
+		t.Errorf("status = %s, want %s", s.Status, StatusFatal)
+	}
+
+	// START clears the failures.
+	if err := a.Start(context.Background(), "A").Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := statusOf(t, a, "A"); s.Status != StatusStarting {
+		t.Errorf("status after START = %s, want %s", s.Status, StatusStarting)
+	}
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"time"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/process"
+)
+
+// stateVersion is written to the state file; files of another version are
+// ignored.
+const stateVersion = 1
+
+// pidStartSlack is how far the start time of a PID may drift from the saved
+// one and still be the same process.
+const pidStartSlack = time.Second
+
+// runtimeState is what the monitor keeps across its own restarts.
+type runtimeState struct {
+	Version         int                      `json:"version"`
+	SettingsProfile string                   `json:"settings_profile,omitempty"`
+	Profile         string                   `json:"profile,omitempty"`
+	ChecksPaused    bool                     `json:"checks_paused,omitempty"`
+	Processes       map[string]savedProcess  `json:"processes,omitempty"`
+	Schedules       map[string]savedSchedule `json:"schedules,omitempty"`
+	Jobs            map[string][]JobRun      `json:"jobs,omitempty"`
+	Maintenance     []Maintenance            `json:"maintenance,omitempty"`
+}
+
+type savedProcess struct {
+	Pid            int       `json:"pid,omitempty"`
+	PidStarted     int64     `json:"pid_started,omitempty"` // Unix ms
+	ManualStop     bool      `json:"manual_stop,omitempty"`
+	RestartAt      time.Time `json:"restart_at,omitzero"`
+	WindowOverride bool      `json:"window_override,omitempty"`
+}
+
+type savedSchedule struct {
+	LastRun   time.Time `json:"last_run,omitzero"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// UseStateFile keeps the runtime state of the monitor in path: manual stops,
+// pending restarts, tracked PIDs, the active profile, maintenances and the
+// last runs of schedules and jobs. The state saved there by the previous run is restored;
+// a saved PID is taken back only if the process still has the same start
+// time. A missing file is not an error.
+func (a *App) UseStateFile(path string) error {
+	a.mu.Lock()
+	a.statePath = path
+	a.mu.Unlock()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var st runtimeState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if st.Version != stateVersion {
+		return fmt.Errorf("%s: unknown version %d", path, st.Version)
+	}
+	a.restoreState(st, time.Now())
+	a.stateMu.Lock()
+	a.stateSaved = data
+	a.stateMu.Unlock()
+	return nil
+}
+
+// restoreState applies a saved state to the app.
+func (a *App) restoreState(st runtimeState, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if st.Profile != a.profile && st.SettingsProfile == a.base.Settings.Profile {
+		if cfg, err := a.base.WithProfile(st.Profile); err == nil {
+			a.profile = st.Profile
+			a.applyConfig(cfg, true)
+		}
+	}
+	a.checkProcess = !st.ChecksPaused
+	for _, m := range st.Maintenance {
+		if now.Before(m.Until) {
+			a.maintenance = append(a.maintenance, m)
+		}
+	}
+
+	for name, p := range st.Processes {
+		item, ok := a.cfg.Process[name]
+		if !ok {
+			continue
+		}
+		if p.ManualStop {
+			a.manualStop[name] = true
+		}
+		if p.WindowOverride && a.windows[name] != nil {
+			a.windowOverride[name] = true
+		}
+		if !p.RestartAt.IsZero() && item.Type != config.TypeJob {
+			a.restartAt[name] = p.RestartAt
+		}
+		if p.Pid <= 0 || item.Type == config.TypeJob {
+			continue
+		}
+		start, ok := process.StartTime(p.Pid)
+		if !ok || start.Sub(time.UnixMilli(p.PidStarted)).Abs() > pidStartSlack {
+			a.logger.Printf("%s %s: saved PID %d is gone", LogTag, name, p.Pid)
+			continue
+		}
+		item.Pid = p.Pid
+		a.startTimes[p.Pid] = start.UnixMilli()
+		a.pidCreatedMs[p.Pid] = start.UnixMilli()
+	}
+
+	for _, s := range a.schedules {
+		saved, ok := st.Schedules[s.Name]
+		if !ok || saved.LastRun.IsZero() {
+			continue
+		}
+		s.lastRun, s.lastErr = saved.LastRun, saved.LastError
+		if s.CatchUp.Duration <= 0 || s.due {
+			continue
+		}
+		from := now.Add(-s.CatchUp.Duration)
+		if saved.LastRun.After(from) {
+			from = saved.LastRun.Add(time.Second)
+		}
+		if missed := s.cron.Last(from.In(s.loc), now); !missed.IsZero() {
+			a.logger.Printf("%s schedule %s: run at %s was missed, running now", LogTag, s.Name, missed.Format("2006-01-02 15:04:05"))
+			s.due = true
+		}
+	}
+
+	for name, runs := range st.Jobs {
+		if j := a.jobs[name]; j != nil && len(j.history) == 0 {
+			j.history = runs
+		}
+	}
+}
+
+// SaveState writes the runtime state to the state file if it changed since
+// the last write. It does nothing without UseStateFile.
+func (a *App) SaveState() error {
+	a.mu.Lock()
+	path := a.statePath
+	if path == "" {
+		a.mu.Unlock()
+		return nil
+	}
+	st, pids := a.buildState()
+	a.mu.Unlock()
+	if len(pids) > 0 {
+		a.addPidStarts(st, pids)
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	if bytes.Equal(data, a.stateSaved) {
+		return nil
+	}
+	if err := config.WriteFileAtomic(path, data); err != nil {
+		return err
+	}
+	a.stateSaved = data
+	return nil
+}
+
+// saveState saves the state from the monitor loop, logging failures.
+func (a *App) saveState() {
+	if err := a.SaveState(); err != nil {
+		a.logger.Printf("%s state: %v", LogTag, err)
+	}
+}
+
+// buildState collects the runtime state worth keeping. Job output is left
+// out to keep the file small. Tracked PIDs whose create time is not cached
+// yet are returned by process name for addPidStarts. The caller holds a.mu.
+func (a *App) buildState() (runtimeState, map[string]int) {
+	st := runtimeState{
+		Version:         stateVersion,
+		SettingsProfile: a.base.Settings.Profile,
+		Profile:         a.profile,
+		ChecksPaused:    !a.checkProcess,
+		Processes:       make(map[string]savedProcess),
+		Schedules:       make(map[string]savedSchedule),
+		Jobs:            make(map[string][]JobRun),
+	}
+	pids := make(map[string]int)
+	for name, item := range a.cfg.Process {
+		p := savedProcess{
+			ManualStop:     a.manualStop[name],
+			RestartAt:      a.restartAt[name],
+			WindowOverride: a.windowOverride[name],
+		}
+		if item.Type != config.TypeJob && item.Pid > 0 {
+			if ms, ok := a.pidCreatedMs[item.Pid]; ok {
+				p.Pid, p.PidStarted = item.Pid, ms
+			} else {
+				pids[name] = item.Pid
+			}
+		}
+		if p != (savedProcess{}) {
+			st.Processes[name] = p
+		}
+	}
+	for _, s := range a.schedules {
+		if !s.lastRun.IsZero() {
+			st.Schedules[s.Name] = savedSchedule{LastRun: s.lastRun, LastError: s.lastErr}
+		}
+	}
+	for name, j := range a.jobs {
+		if len(j.history) == 0 {
+			continue
+		}
+		runs := make([]JobRun, len(j.history))
+		for i, run := range j.history {
+			run.Output = ""
+			runs[i] = run
+		}
+		st.Jobs[name] = runs
+	}
+	for _, m := range a.maintenance {
+		if time.Now().Before(m.Until) {
+			st.Maintenance = append(st.Maintenance, m)
+		}
+	}
+	return st, pids
+}
+
+// addPidStarts adds the PIDs buildState left out to st with their create
+// times as the OS reports them, and caches those times. The time noted when
+// the monitor launched a process is only close to it, so it is looked up
+// once per PID, without holding a.mu as it takes a syscall.
+func (a *App) addPidStarts(st runtimeState, pids map[string]int) {
+	created := make(map[int]int64, len(pids))
+	for name, pid := range pids {
+		t, ok := process.StartTime(pid)
+		if !ok {
+			continue
+		}
+		ms := t.UnixMilli()
+		created[pid] = ms
+		p := st.Processes[name]
+		p.Pid, p.PidStarted = pid, ms
+		st.Processes[name] = p
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.pidCreatedMs)+len(created) > 4*len(a.cfg.Process)+16 {
+		clear(a.pidCreatedMs)
+	}
+	maps.Copy(a.pidCreatedMs, created)
+}
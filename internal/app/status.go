@@ -1,28 +1,117 @@
 package app
 
+import "time"
+
 // Status represents the runtime state we display for a process.
+//
+// A process moves between the states as follows:
+//
+//	stopped/backoff/crashed -> starting    launch queued or just launched
+//	starting -> running                    alive for minUptime, at least startGrace
+//	running/starting -> unhealthy          not responding, killed after hangTimeout
+//	running/unhealthy -> stopping          stop, kill, recycle or restart queued
+//	running/starting -> crashed            exited on its own, relaunched after restartTiming
+//	stopping -> backoff                    stopped by the monitor, relaunched later
+//	starting -> backoff                    launch or start failed, retried after restartTiming
+//	backoff -> fatal                       fatalLaunchFailures starts failed in a row
+//	any -> stopped                         STOP by hand, kept down until START
+//	any -> scheduled-off                   outside the runWindow
+//	any -> disabled                        disabled in the config
+//	any -> maintenance                     down during a maintenance, left alone until it ends
+//
+// Jobs are idle between runs and running during one.
 type Status string
 
 const (
 	StatusUnknown  Status = "unknown"
+	StatusStarting Status = "starting"
 	StatusRunning  Status = "running"
-	StatusStarted  Status = "started"
+	StatusStopping Status = "stopping"
+	// StatusBackoff marks a process waiting for its next launch attempt.
+	StatusBackoff Status = "backoff"
+	// StatusUnhealthy marks a running process that does not respond.
+	StatusUnhealthy Status = "unhealthy"
+	// StatusCrashed marks a process that exited on its own and waits to be
+	// relaunched.
+	StatusCrashed Status = "crashed"
+	// StatusFatal marks a process the monitor gave up launching.
+	StatusFatal    Status = "fatal"
 	StatusStopped  Status = "stopped"
 	StatusDisabled Status = "disabled"
+	// StatusScheduledOff marks a process kept stopped outside its runWindow.
+	StatusScheduledOff Status = "scheduled-off"
+	// StatusIdle marks a job waiting for its next run.
+	StatusIdle Status = "idle"
+	// StatusMaintenance marks a process down during a maintenance, which
+	// the monitor does not relaunch until the maintenance ends.
+	StatusMaintenance Status = "maintenance"
 )
 
+// alive reports whether the process runs in this state.
+func (s Status) alive() bool {
+	return s == StatusStarting || s == StatusRunning || s == StatusUnhealthy
+}
+
 // Icon returns the user-facing marker for a status.
 func (s Status) Icon() string {
 	switch s {
 	case StatusRunning:
 		return "★ RUN    ︎ "
-	case StatusStarted:
-		return "☆︎ START   "
+	case StatusStarting:
+		return "☆︎ STARTING"
+	case StatusStopping:
+		return "◐︎ STOPPING"
+	case StatusBackoff:
+		return "↻︎ BACKOFF "
+	case StatusUnhealthy:
+		return "⚠︎ HUNG    "
+	case StatusCrashed:
+		return "✗︎ CRASHED "
+	case StatusFatal:
+		return "✖︎ FATAL   "
 	case StatusStopped:
 		return "✗︎ STOPPED "
 	case StatusDisabled:
 		return "⛔︎ DISABLED"
+	case StatusScheduledOff:
+		return "☾︎ OFFHOURS"
+	case StatusIdle:
+		return "◌︎ IDLE    "
+	case StatusMaintenance:
+		return "⚒︎ MAINT   "
 	default:
 		return "☠︎ UNKNOWN "
 	}
 }
+
+// Reason tells why a process is in its state.
+type Reason string
+
+const (
+	ReasonManual        Reason = "manual"         // STOP by hand
+	ReasonFirstStart    Reason = "first-start"    // delayStartTime before the first launch
+	ReasonExited        Reason = "exited"         // exited on its own
+	ReasonLaunchFailed  Reason = "launch-failed"  // the last launch returned an error
+	ReasonStartFailed   Reason = "start-failed"   // gone within minUptime or not found within startTimeout
+	ReasonNotResponding Reason = "not-responding" // hung, or killed for hanging
+	ReasonRecycle       Reason = "recycle"        // maxUptime or restartAt
+	ReasonRestartAll    Reason = "restart-all"    // restart-all schedule or command
+	ReasonRunWindow     Reason = "run-window"     // outside the runWindow
+	ReasonConfig        Reason = "config"         // relaunched for changed settings
+	ReasonDisabled      Reason = "disabled"
+)
+
+// startGrace is how long a launched process shows as starting.
+const startGrace = 5 * time.Second
+
+// fatalLaunchFailures is the number of failed launches or starts in a row
+// after which the monitor stops relaunching a process until START or
+// RESTART.
+const fatalLaunchFailures = 5
+
+// ExitInfo describes the last time a process exited on its own.
+type ExitInfo struct {
+	At  time.Time
+	Pid int
+	Err string // exit status or error as reported for a child, "" if unknown
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestStatusIconWidth(t *testing.T) {
+	want := utf8.RuneCountInString(StatusRunning.Icon())
+	for _, s := range []Status{
+		StatusUnknown, StatusStarting, StatusRunning, StatusStopping, StatusBackoff,
+		StatusUnhealthy, StatusCrashed, StatusFatal, StatusStopped, StatusDisabled,
+		StatusScheduledOff, StatusIdle, StatusMaintenance,
+	} {
+		if got := utf8.RuneCountInString(s.Icon()); got != want {
+			t.Errorf("%s: icon %q is %d runes, want %d", s, s.Icon(), got, want)
+		}
+	}
+}
+
+func TestJobUptimeText(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		name   string
+		status procStatus
+		want   string
+	}{
+		{"running", procStatus{Status: StatusRunning, Uptime: "00:01:05"}, "00:01:05"},
+		{"disabled", procStatus{Status: StatusDisabled, Uptime: "-"}, "-"},
+		{"paused", procStatus{Status: StatusIdle, JobPaused: true, NextRun: now.Add(time.Hour)}, "paused"},
+		{"next run", procStatus{Status: StatusIdle, NextRun: now.Add(3 * time.Hour)}, "next 12:00"},
+		{"on demand", procStatus{Status: StatusIdle}, "on demand"},
+	}
+	for _, tt := range tests {
+		if got := tt.status.uptimeText(now); got != tt.want {
+			t.Errorf("%s: uptimeText = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/schedule"
+)
+
+// applyWindows parses the run windows of cfg and takes its holidays. An
+// invalid window is logged and ignored, so that process runs at any time.
+// The caller holds a.mu.
+func (a *App) applyWindows(cfg config.Config) {
+	names := make([]string, 0, len(cfg.Process))
+	for name := range cfg.Process {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	a.windows = make(map[string]*schedule.Window)
+	for _, name := range names {
+		w, err := cfg.Process[name].Window()
+		if err != nil {
+			a.logger.Printf("%s %s: runWindow ignored: %v", LogTag, name, err)
+			continue
+		}
+		if w != nil {
+			a.windows[name] = w
+		}
+	}
+	for name := range a.windowOverride {
+		if a.windows[name] == nil {
+			delete(a.windowOverride, name)
+		}
+	}
+	hol, err := cfg.Holidays()
+	if err != nil {
+		a.logger.Printf("%s holidays ignored: %v", LogTag, err)
+	}
+	a.holidays = hol
+}
+
+// outsideWindow reports whether name must be kept stopped at now. A manual
+// start outside the window holds until the window next opens. The caller
+// holds a.mu.
+func (a *App) outsideWindow(name string, now time.Time) bool {
+	w := a.windows[name]
+	if w == nil {
+		return false
+	}
+	if w.Open(now, a.holidays) {
+		delete(a.windowOverride, name)
+		return false
+	}
+	return !a.windowOverride[name]
+}
+
+// overrideWindow lets a manual start run name outside its window. The
+// caller holds a.mu.
+func (a *App) overrideWindow(name string, now time.Time) {
+	if w := a.windows[name]; w != nil && !w.Open(now, a.holidays) {
+		a.windowOverride[name] = true
+	}
+}
+
+// keepScheduledOff stops a process outside its run window and fills its
+// status. When the window opens the process starts after DelayStartTime,
+// as on the first start. The caller holds a.mu.
+func (a *App) keepScheduledOff(name string, item *config.ProcessItem, status *procStatus, alive bool, now time.Time) {
+	if alive {
+		a.enqueue(context.Background(), name, opKill, nil)
+		if a.last[name] != StatusScheduledOff {
+			a.logger.Printf("%s %s stopped outside its run window", LogTag, name)
+		}
+	}
+	status.Status = StatusScheduledOff
+	status.Reason = ReasonRunWindow
+	a.last[name] = StatusScheduledOff
+	status.StartedAt = "-"
+	status.Uptime = "-"
+	status.NextAttempt = a.windows[name].NextOpen(now, a.holidays)
+	delete(a.reasons, name)
+	delete(a.launchedAt, name)
+	delete(a.restartAt, name)
+	delete(a.hungSince, name)
+	a.firstStart[name] = true
+}
+
+// formatOpens describes when a closed window opens, briefly enough for the
+// uptime column.
+func formatOpens(next, now time.Time) string {
+	if next.IsZero() {
+		return "closed"
+	}
+	return "opens " + formatNext(next, now)
+}
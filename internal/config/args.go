@@ -0,0 +1,63 @@
+package config
+
+import "strings"
+
+// SplitArgs splits an args string on whitespace, honouring single and double
+// quotes.
+func SplitArgs(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	var args []string
+	var current strings.Builder
+	inQuote := false
+	quoteChar := byte(0)
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		if inQuote {
+			if ch == quoteChar {
+				inQuote = false
+				continue
+			}
+			current.WriteByte(ch)
+		} else {
+			if ch == '"' || ch == '\'' {
+				inQuote = true
+				quoteChar = ch
+				continue
+			}
+			if ch == ' ' || ch == '\t' {
+				if current.Len() > 0 {
+					args = append(args, current.String())
+					current.Reset()
+				}
+				continue
+			}
+			current.WriteByte(ch)
+		}
+	}
+	if current.Len() > 0 {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// JoinArgs is the inverse of SplitArgs: arguments with whitespace or quotes
+// are wrapped in quotes so they split back unchanged.
+func JoinArgs(args []string) string {
+	out := make([]string, 0, len(args))
+	for _, a := range args {
+		switch {
+		case a == "":
+			continue
+		case !strings.ContainsAny(a, " \t\"'"):
+			out = append(out, a)
+		case !strings.Contains(a, `"`):
+			out = append(out, `"`+a+`"`)
+		default:
+			out = append(out, `'`+a+`'`)
+		}
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,409 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// BackupDirName is the folder next to the config that keeps old versions.
+	BackupDirName = "config.backups"
+	// DefaultBackupCount is used when settings.backupCount is not set.
+	DefaultBackupCount = 20
+
+	backupTimeLayout = "20060102-150405.000"
+)
+
+// Backup describes one archived config version.
+type Backup struct {
+	ID      string `json:"id"`
+	Created string `json:"created"`
+	Size    int64  `json:"size"`
+}
+
+// BackupDir returns the backup folder for a config path.
+func BackupDir(path string) string {
+	return filepath.Join(filepath.Dir(path), BackupDirName)
+}
+
+// StatePath returns the runtime state file kept next to a config:
+// config.ini -> config.state.json.
+func StatePath(path string) string {
+	prefix, _ := backupNameParts(path)
+	return filepath.Join(filepath.Dir(path), prefix+"state.json")
+}
+
+// backupFile archives the current content of path before it is overwritten
+// and prunes archives beyond keep. A missing file is not an error.
+func backupFile(path string, keep int) error {
+	if keep < 0 {
+		return nil
+	}
+	if keep == 0 {
+		keep = DefaultBackupCount
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	dir := BackupDir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("backup dir: %w", err)
+	}
+	if list, err := ListBackups(path); err == nil && len(list) > 0 {
+		if prev, err := os.ReadFile(backupPath(path, list[0].ID)); err == nil && bytes.Equal(prev, data) {
+			return nil
+		}
+	}
+	id := time.Now().Format(backupTimeLayout)
+	if err := WriteFileAtomic(backupPath(path, id), data); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	return pruneBackups(path, keep)
+}
+
+// ListBackups returns archived versions of path, newest first.
+func ListBackups(path string) ([]Backup, error) {
+	entries, err := os.ReadDir(BackupDir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	prefix, ext := backupNameParts(path)
+	out := make([]Backup, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		created, err := time.ParseInLocation(backupTimeLayout, id, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, Backup{
+			ID:      id,
+			Created: created.Format("2006-01-02 15:04:05"),
+			Size:    info.Size(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out, nil
+}
+
+// ReadBackup returns the content of an archived version.
+func ReadBackup(path, id string) ([]byte, error) {
+	if err := checkBackupID(id); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(backupPath(path, id))
+}
+
+// backupSet archives the current content of path and its included files
+// under one id, so a save that touches several files can be rolled back
+// as a whole. Nothing is archived when every file still matches the newest
+// set. Missing files are skipped.
+func backupSet(path string, files []string, keep int) error {
+	if keep < 0 {
+		return nil
+	}
+	if keep == 0 {
+		keep = DefaultBackupCount
+	}
+	targets := []string{path}
+	for _, file := range files {
+		targets = append(targets, IncludePath(path, file))
+	}
+	data := make(map[string][]byte, len(targets))
+	for _, target := range targets {
+		b, err := os.ReadFile(target)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		data[target] = b
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if list, err := ListBackups(path); err == nil && len(list) > 0 && sameSet(data, list[0].ID) {
+		return nil
+	}
+	id := time.Now().Format(backupTimeLayout)
+	for fileExists(backupPath(path, id)) { // one set per id
+		time.Sleep(time.Millisecond)
+		id = time.Now().Format(backupTimeLayout)
+	}
+	for _, target := range targets {
+		b, ok := data[target]
+		if !ok {
+			continue
+		}
+		if err := os.MkdirAll(BackupDir(target), 0755); err != nil {
+			return fmt.Errorf("backup dir: %w", err)
+		}
+		if err := WriteFileAtomic(backupPath(target, id), b); err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+		if err := pruneBackups(target, keep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameSet reports whether every file in data has a copy under id with the
+// same content.
+func sameSet(data map[string][]byte, id string) bool {
+	for target, b := range data {
+		prev, err := os.ReadFile(backupPath(target, id))
+		if err != nil || !bytes.Equal(prev, b) {
+			return false
+		}
+	}
+	return true
+}
+
+// backupFiles returns the included files archived with the set id: those
+// the archived config includes that have a copy under id.
+func backupFiles(path, id string) ([]string, error) {
+	f, err := decodeFile(backupPath(path, id))
+	if err != nil {
+		return nil, err
+	}
+	files, err := IncludedFiles(path, f.cfg.Settings.Include)
+	if err != nil {
+		return nil, err
+	}
+	out := files[:0]
+	for _, file := range files {
+		if fileExists(backupPath(IncludePath(path, file), id)) {
+			out = append(out, file)
+		}
+	}
+	return out, nil
+}
+
+// currentFiles returns the files the config at path includes now. A main
+// file that no longer decodes still has conf.d.
+func currentFiles(path string) []string {
+	include := ""
+	if f, err := decodeFile(path); err == nil {
+		include = f.cfg.Settings.Include
+	}
+	files, _ := IncludedFiles(path, include)
+	return files
+}
+
+// LoadBackup decodes an archived version of the config at path without
+// changing any file. Included files archived with it are read from the
+// set; the others as they are now.
+func LoadBackup(path, id string) (Config, error) {
+	if err := checkBackupID(id); err != nil {
+		return Config{}, err
+	}
+	return load(path, func(p string) (configFile, error) {
+		if p == path {
+			return decodeFile(backupPath(path, id))
+		}
+		if b := backupPath(p, id); fileExists(b) {
+			return decodeFile(b)
+		}
+		return decodeFile(p)
+	})
+}
+
+// RestoreBackup replaces path and the included files archived with it by
+// the archived versions. The current files are archived first as one set
+// so a restore can itself be rolled back. Included files without a copy
+// in the set are left as they are.
+func RestoreBackup(path, id string, keep int) error {
+	main, err := ReadBackup(path, id)
+	if err != nil {
+		return err
+	}
+	files, err := backupFiles(path, id)
+	if err != nil {
+		return err
+	}
+	data := make([][]byte, len(files))
+	for i, file := range files {
+		if data[i], err = os.ReadFile(backupPath(IncludePath(path, file), id)); err != nil {
+			return err
+		}
+	}
+	if err := backupSet(path, mergeFiles(currentFiles(path), files), keep); err != nil {
+		return err
+	}
+	if err := WriteFileAtomic(path, main); err != nil {
+		return err
+	}
+	for i, file := range files {
+		if err := WriteFileAtomic(IncludePath(path, file), data[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DiffBackup returns a line diff from an archived version to the current
+// files: the main config first, then every included file of the set that
+// changed since.
+func DiffBackup(path, id string) (string, error) {
+	old, err := ReadBackup(path, id)
+	if err != nil {
+		return "", err
+	}
+	cur, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", id, filepath.Base(path))
+	writeDiff(&b, old, cur)
+	files, err := backupFiles(path, id)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		target := IncludePath(path, file)
+		old, err := os.ReadFile(backupPath(target, id))
+		if err != nil {
+			return "", err
+		}
+		cur, err := os.ReadFile(target)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if bytes.Equal(old, cur) {
+			continue
+		}
+		fmt.Fprintf(&b, "--- %s %s\n+++ %s\n", id, file, file)
+		writeDiff(&b, old, cur)
+	}
+	return b.String(), nil
+}
+
+func writeDiff(b *strings.Builder, old, cur []byte) {
+	for _, l := range diffLines(splitLines(old), splitLines(cur)) {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+}
+
+// mergeFiles returns a followed by the names in b it lacks.
+func mergeFiles(a, b []string) []string {
+	out := append([]string(nil), a...)
+	for _, f := range b {
+		if !slices.Contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func pruneBackups(path string, keep int) error {
+	list, err := ListBackups(path)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for i := keep; i < len(list); i++ {
+		if err := os.Remove(backupPath(path, list[i].ID)); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func backupPath(path, id string) string {
+	prefix, ext := backupNameParts(path)
+	return filepath.Join(BackupDir(path), prefix+id+ext)
+}
+
+func backupNameParts(path string) (string, string) {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + ".", ext
+}
+
+func checkBackupID(id string) error {
+	if _, err := time.Parse(backupTimeLayout, id); err != nil {
+		return fmt.Errorf("invalid backup id %q", id)
+	}
+	return nil
+}
+
+func splitLines(data []byte) []string {
+	s := strings.ReplaceAll(string(data), "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines builds a minimal line diff using the longest common subsequence.
+// Lines are prefixed with "-", "+" or " ".
+func diffLines(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	out := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "-"+a[i])
+			i++
+		default:
+			out = append(out, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		out = append(out, "+"+b[j])
+	}
+	return out
+}
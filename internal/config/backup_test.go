@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackupSet(t *testing.T) {
+	path := writeConfig(t, map[string]string{
+		"config.ini":     "[settings]\ncheckTiming=1s\nrestartTiming=5s\nautoRestartOnExit=false\n\n[process \"UE\"]\ntype=exe\nprocess=Game.exe\n",
+		"conf.d/web.ini": "[process \"WEB\"]\ntype=cmd\ncommand=start.cmd\ncheckProcess=node.exe\n",
+	})
+	web := IncludePath(path, "conf.d/web.ini")
+	read := func(p string) string {
+		t.Helper()
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	mainBefore, webBefore := read(path), read(web)
+
+	// Saving a change to the included file alone archives both files.
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dto := ToDTO(cfg)
+	for i := range dto.Processes {
+		if dto.Processes[i].Name == "WEB" {
+			dto.Processes[i].Command = "serve.cmd"
+		}
+	}
+	if err := WriteFromDTO(path, dto); err != nil {
+		t.Fatal(err)
+	}
+	list, err := ListBackups(path)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListBackups = %v, %v; want one set", list, err)
+	}
+	id := list[0].ID
+	if got := read(backupPath(web, id)); got != webBefore {
+		t.Errorf("web.ini in set %s = %q, want %q", id, got, webBefore)
+	}
+	if got := read(path); got != mainBefore {
+		t.Errorf("main config rewritten:\n%s", got)
+	}
+
+	// Saving the same content again archives nothing.
+	if err := WriteFromDTO(path, dto); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := ListBackups(path); len(list) != 1 {
+		t.Errorf("unchanged save added a backup: %v", list)
+	}
+
+	diff, err := DiffBackup(path, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+++ conf.d/web.ini\n") || !strings.Contains(diff, "-command=start.cmd\n+command=serve.cmd\n") {
+		t.Errorf("diff lacks the included file:\n%s", diff)
+	}
+
+	old, err := LoadBackup(path, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := old.Process["WEB"].Command; got != "start.cmd" {
+		t.Errorf("LoadBackup WEB command = %q, want start.cmd", got)
+	}
+
+	if err := RestoreBackup(path, id, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(web); got != webBefore {
+		t.Errorf("web.ini after restore = %q, want %q", got, webBefore)
+	}
+	if got := read(path); got != mainBefore {
+		t.Errorf("config.ini after restore = %q, want %q", got, mainBefore)
+	}
+	// The restore archived the state it replaced as a set of its own.
+	list, _ = ListBackups(path)
+	if len(list) != 2 {
+		t.Fatalf("ListBackups after restore = %v, want 2", list)
+	}
+	if got := read(backupPath(web, list[0].ID)); !strings.Contains(got, "serve.cmd") {
+		t.Errorf("restore did not archive the replaced web.ini: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(web), BackupDirName)); err != nil {
+		t.Errorf("included backups not next to the included file: %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"goRunFiles/internal/schedule"
+
 	"gopkg.in/gcfg.v1"
 )
 
@@ -18,6 +20,7 @@ const (
 	TypeExe = "exe"
 	TypeCmd = "cmd"
 	TypeBat = "bat"
+	TypeJob = "job" // one-shot command run on Cron or on demand, never restarted
 )
 
 // ProcessItem Один процесс
@@ -28,21 +31,42 @@ type ProcessItem struct {
 	CheckCmdline        string
 	CheckCmdlineExclude string
 	DelayStartTime      Duration
+	StartTimeout        Duration // a launched process must become matchable within this
+	MinUptime           Duration // a process gone sooner after launch failed to start
 	MonitorHang         bool
 	HangTimeout         Duration
+	RunWindow           string   // e.g. "Mon-Fri 09:00-21:00; Sat-Sun 10:00-18:00", see schedule.ParseWindow
+	MaxUptime           Duration // recycle the process after running this long
+	RestartAt           string   // daily HH:MM recycle times, comma-separated
+	Cron                string   // when a job runs, see schedule.Parse; on demand only when empty
+	Timeout             Duration // a job run still going after this long is killed
 	Path                string
 	Command             string
 	Args                string
-	Screen              int
-	Type                string // exe | cmd
+	Screen              ScreenSpec
+	Instances           int
+	Type                string // exe | cmd | bat | job
+	Extends             string // template name
+	Groups              string // comma-separated group names, see GroupList
 	Pid                 int
+
+	inherited  map[string]string // field key -> template the value came from
+	overridden map[string]string // field key -> template whose value is replaced
+	raw        map[string]string // field key -> text before ${VAR} expansion
+	source     string            // included file defining the entry, "" for the main config
 }
 
 type Settings struct {
 	CheckTiming           Duration
+	CheckTimingMax        Duration // checks back off up to this while nothing changes, 0 to keep checkTiming
 	RestartTiming         Duration
-	AutoRestart           bool
-	AutoRestartTime       string
+	MetricsTiming         Duration // CPU/memory/network/GPU sampling, 0 for checkTiming
+	BulkParallel          int      // processes a bulk start/stop/restart works on at once, 0 for all
+	BulkStagger           Duration // pause between the operations of a bulk start/stop/restart
+	RollingBatch          int      // processes a rolling restart restarts at once, 0 for 1
+	RollingTimeout        Duration // how long a rolling restart waits for a process to run, 0 for 2m
+	AutoRestart           bool     // deprecated, see LegacyScheduleName
+	AutoRestartTime       string   // HH:MM[:SS], deprecated
 	AutoRestartOnExit     bool
 	LaunchInNewConsole    bool
 	AutoCloseErrorDialogs bool
@@ -51,34 +75,177 @@ type Settings struct {
 	NetDebug              bool
 	NetUnit               string
 	NetScale              float64
+	WatchConfig           bool
+	RestartOnConfigChange bool
+	BackupCount           int
+	Include               string // comma-separated files or globs, relative to the config
+	Profile               string // profile applied at startup, see Config.Profile
+	ControlAddr           string // loopback host:port for ctl commands; empty or "off" leaves them off
+	Holidays              string // .ics or date list file, relative to the config, see schedule.LoadHolidays
+
+	raw map[string]string // field key -> text before ${VAR} expansion
 }
 
 // Config Вся конфигурация
 type Config struct {
 	Process  map[string]*ProcessItem
+	Template map[string]*ProcessItem
 	Settings Settings
+	Vars     map[string]string // [vars] section, used in ${NAME}
+	Profile  map[string]*Profile
+	Schedule map[string]*Schedule
+
+	files       []string          // included files, see IncludedFiles
+	holidays    schedule.Holidays // read from Settings.Holidays by Load
+	holidaysErr error
 }
 
+// Load reads the config file together with the files it includes (see
+// IncludedFiles). The syntax is chosen by extension: INI by default, or
+// JSON, YAML and TOML (see DetectFormat).
 func Load(path string) (Config, error) {
-	var cfg Config
-	if err := gcfg.ReadFileInto(&cfg, path); err != nil {
-		if _, repErr := RepairFile(path); repErr == nil {
-			if err2 := gcfg.ReadFileInto(&cfg, path); err2 != nil {
-				return Config{}, err2
-			}
-		} else {
-			return Config{}, err
+	return load(path, readFile)
+}
+
+// LoadReadOnly is Load for a monitor that must not write the config, such
+// as a dry run: INI files that RepairFile would fix are repaired in memory
+// only and returned in repaired.
+func LoadReadOnly(path string) (cfg Config, repaired []string, err error) {
+	cfg, err = load(path, func(path string) (configFile, error) {
+		f, err := decodeFile(path)
+		if err == nil || DetectFormat(path) != FormatINI {
+			return f, err
+		}
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return configFile{}, err
 		}
+		doc := parseINI(data)
+		if !repairDoc(doc) {
+			return configFile{}, err
+		}
+		repaired = append(repaired, path)
+		return decodeINI(doc.bytes())
+	})
+	return cfg, repaired, err
+}
+
+// load reads the config at path and its included files through read.
+func load(path string, read func(path string) (configFile, error)) (Config, error) {
+	f, err := read(path)
+	if err != nil {
+		return Config{}, err
 	}
-	if data, err := os.ReadFile(path); err == nil {
-		lower := bytes.ToLower(data)
-		if !bytes.Contains(lower, []byte("autorestartonexit")) {
-			cfg.Settings.AutoRestartOnExit = true
+	files, err := IncludedFiles(path, f.cfg.Settings.Include)
+	if err != nil {
+		return Config{}, err
+	}
+	for _, file := range files {
+		part, err := read(IncludePath(path, file))
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", file, err)
+		}
+		if err := f.merge(part, file); err != nil {
+			return Config{}, err
 		}
 	}
+	cfg, err := f.resolve()
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.loadHolidays(path)
 	return cfg, nil
 }
 
+// loadHolidays reads the settings.holidays file. A broken file does not
+// fail the load: run windows then see no holidays and Validate reports it.
+func (cfg *Config) loadHolidays(path string) {
+	if file := strings.TrimSpace(cfg.Settings.Holidays); file != "" {
+		cfg.holidays, cfg.holidaysErr = schedule.LoadHolidays(IncludePath(path, file))
+	}
+}
+
+// Holidays returns the days off read from settings.holidays by Load, or
+// the error reading them.
+func (cfg Config) Holidays() (schedule.Holidays, error) {
+	return cfg.holidays, cfg.holidaysErr
+}
+
+// configFile is one decoded file before templates and variables are resolved.
+type configFile struct {
+	cfg      Config
+	set      sectionKeys
+	sections map[string]bool // top-level sections present in the file
+}
+
+// resolve applies templates and variables.
+func (f configFile) resolve() (Config, error) {
+	cfg := f.cfg
+	if err := cfg.resolveTemplates(f.set); err != nil {
+		return Config{}, err
+	}
+	cfg.expandVars()
+	return cfg, nil
+}
+
+// readFile decodes one file. Unquoted backslashes in INI files are repaired
+// on disk when they break parsing.
+func readFile(path string) (configFile, error) {
+	f, err := decodeFile(path)
+	if err == nil || DetectFormat(path) != FormatINI {
+		return f, err
+	}
+	if _, repErr := RepairFile(path); repErr != nil {
+		return configFile{}, err
+	}
+	return decodeFile(path)
+}
+
+// decodeFile decodes one file without touching it.
+func decodeFile(path string) (configFile, error) {
+	if format := DetectFormat(path); format != FormatINI {
+		return decodeStructured(path, format)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return configFile{}, err
+	}
+	return decodeINI(data)
+}
+
+// loadINI decodes INI data that includes no other files.
+func loadINI(data []byte) (Config, error) {
+	f, err := decodeINI(data)
+	if err != nil {
+		return Config{}, err
+	}
+	return f.resolve()
+}
+
+// decodeINI decodes INI data. autoRestartOnExit defaults to true when absent.
+func decodeINI(data []byte) (configFile, error) {
+	doc := parseINI(data)
+	sections := make(map[string]bool)
+	for _, l := range doc.lines {
+		if l.kind == iniSection {
+			sections[l.section] = true
+		}
+	}
+	set := iniSectionKeys(doc)
+	vars := iniVars(doc)
+	profiles := iniProfiles(doc)
+	var cfg Config
+	if err := gcfg.ReadStringInto(&cfg, string(doc.bytes())); err != nil {
+		return configFile{}, err
+	}
+	if !bytes.Contains(bytes.ToLower(data), []byte("autorestartonexit")) {
+		cfg.Settings.AutoRestartOnExit = true
+	}
+	cfg.Vars = vars
+	cfg.Profile = profiles
+	return configFile{cfg: cfg, set: set, sections: sections}, nil
+}
+
 // Duration supports values like "100ms", "0.1s", "1s", "2m", or plain numbers (seconds).
 type Duration struct {
 	time.Duration
@@ -99,6 +266,45 @@ func (d *Duration) UnmarshalText(text []byte) error {
 	if err != nil {
 		return fmt.Errorf("invalid duration %q", s)
 	}
-	d.Duration = time.Duration(float64(time.Second) * f)
+	d.Duration = secondsToDuration(f)
 	return nil
 }
+
+// ScreenSpec is the 1-based monitor a process is placed on, 0 to leave the
+// window alone. Entries with instances may use a template like "{{.Index}}".
+type ScreenSpec struct {
+	N    int
+	Expr string
+}
+
+func (s *ScreenSpec) UnmarshalText(text []byte) error {
+	v := strings.TrimSpace(string(text))
+	*s = ScreenSpec{}
+	if strings.Contains(v, "{{") {
+		if _, err := parseInstanceTemplate(v); err != nil {
+			return err
+		}
+		s.Expr = v
+		return nil
+	}
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid screen %q", v)
+	}
+	s.N = n
+	return nil
+}
+
+func (s ScreenSpec) String() string {
+	if s.Expr != "" {
+		return s.Expr
+	}
+	return strconv.Itoa(s.N)
+}
+
+func secondsToDuration(secs float64) time.Duration {
+	return time.Duration(float64(time.Second) * secs)
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,18 +19,63 @@ type ProcessDTO struct {
 	Command             string `json:"command"`
 	Args                string `json:"args"`
 	Screen              int    `json:"screen"`
+	ScreenExpr          string `json:"screenExpr"`
+	Instances           int    `json:"instances"`
 	CheckProcess        string `json:"checkProcess"`
 	CheckCmdline        string `json:"checkCmdline"`
 	CheckCmdlineExclude string `json:"checkCmdlineExclude"`
 	DelayStartTime      string `json:"delayStartTime"`
+	StartTimeout        string `json:"startTimeout"`
+	MinUptime           string `json:"minUptime"`
 	MonitorHang         bool   `json:"monitorHang"`
 	HangTimeout         string `json:"hangTimeout"`
+	RunWindow           string `json:"runWindow"`
+	MaxUptime           string `json:"maxUptime"`
+	RestartAt           string `json:"restartAt"`
+	Cron                string `json:"cron"`
+	Timeout             string `json:"timeout"`
+	Extends             string `json:"extends"`
+	Groups              string `json:"groups"`
+
+	// Source is the included file the entry is saved to, "" for the main
+	// config. It must be listed in ConfigDTO.Files.
+	Source string `json:"source,omitempty"`
+
+	// Inherited maps field keys whose values come from a template to that
+	// template; Overridden lists fields set here that a template also sets.
+	Inherited  map[string]string `json:"inherited,omitempty"`
+	Overridden map[string]string `json:"overridden,omitempty"`
+
+	// Raw holds the text before ${VAR} expansion of fields that use
+	// variables. It is written back instead of the shown value, so clients
+	// drop a key once the user edits that field.
+	Raw map[string]string `json:"raw,omitempty"`
+}
+
+// Override marks an inherited field as set on the entry itself so it is
+// written to the config instead of following the template.
+func (p *ProcessDTO) Override(key string) {
+	t, ok := p.Inherited[key]
+	if !ok {
+		return
+	}
+	delete(p.Inherited, key)
+	if p.Overridden == nil {
+		p.Overridden = make(map[string]string)
+	}
+	p.Overridden[key] = t
 }
 
 // SettingsDTO is a UI-friendly view of Settings.
 type SettingsDTO struct {
 	CheckTiming           string `json:"checkTiming"`
+	CheckTimingMax        string `json:"checkTimingMax"`
 	RestartTiming         string `json:"restartTiming"`
+	MetricsTiming         string `json:"metricsTiming"`
+	BulkParallel          int    `json:"bulkParallel"`
+	BulkStagger           string `json:"bulkStagger"`
+	RollingBatch          int    `json:"rollingBatch"`
+	RollingTimeout        string `json:"rollingTimeout"`
 	AutoRestart           bool   `json:"autoRestart"`
 	AutoRestartTime       string `json:"autoRestartTime"`
 	AutoRestartOnExit     bool   `json:"autoRestartOnExit"`
@@ -39,12 +86,115 @@ type SettingsDTO struct {
 	NetDebug              bool   `json:"netDebug"`
 	NetUnit               string `json:"netUnit"`
 	NetScale              string `json:"netScale"`
+	WatchConfig           bool   `json:"watchConfig"`
+	RestartOnConfigChange bool   `json:"restartOnConfigChange"`
+	BackupCount           int    `json:"backupCount"`
+	Include               string `json:"include"`
+	Profile               string `json:"profile"`
+	ControlAddr           string `json:"controlAddr"`
+	Holidays              string `json:"holidays"`
+
+	Raw map[string]string `json:"raw,omitempty"`
+}
+
+// ProfileDTO is a UI-friendly view of Profile. Overrides maps process
+// names to field keys and their values.
+type ProfileDTO struct {
+	Name      string                       `json:"name"`
+	Processes []string                     `json:"processes"`
+	Overrides map[string]map[string]string `json:"overrides"`
+}
+
+func (p ProfileDTO) profile() *Profile {
+	out := &Profile{}
+	for _, name := range p.Processes {
+		if name = strings.TrimSpace(name); name != "" {
+			out.Processes = append(out.Processes, name)
+		}
+	}
+	for name, fields := range p.Overrides {
+		for field, value := range fields {
+			out.set(overrideKey{Name: strings.TrimSpace(name), Field: strings.TrimSpace(field)}.String(), value)
+		}
+	}
+	return out
+}
+
+// ScheduleDTO is a UI-friendly view of Schedule.
+type ScheduleDTO struct {
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled"`
+	Cron     string `json:"cron"`
+	Action   string `json:"action"`
+	Target   string `json:"target"`
+	Timezone string `json:"timezone"`
+	CatchUp  string `json:"catchUp"`
+	Duration string `json:"duration"`
+}
+
+func (s ScheduleDTO) schedule() (*Schedule, error) {
+	var catchUp Duration
+	if err := catchUp.UnmarshalText([]byte(s.CatchUp)); err != nil {
+		return nil, fmt.Errorf("catchUp for schedule %s: %w", strings.TrimSpace(s.Name), err)
+	}
+	var duration Duration
+	if err := duration.UnmarshalText([]byte(s.Duration)); err != nil {
+		return nil, fmt.Errorf("duration for schedule %s: %w", strings.TrimSpace(s.Name), err)
+	}
+	return &Schedule{
+		Disabled: s.Disabled,
+		Cron:     strings.TrimSpace(s.Cron),
+		Action:   strings.TrimSpace(s.Action),
+		Target:   strings.TrimSpace(s.Target),
+		Timezone: strings.TrimSpace(s.Timezone),
+		CatchUp:  catchUp,
+		Duration: duration,
+	}, nil
 }
 
 // ConfigDTO is a UI-friendly view of Config.
 type ConfigDTO struct {
-	Processes []ProcessDTO `json:"processes"`
-	Settings  SettingsDTO  `json:"settings"`
+	Processes []ProcessDTO      `json:"processes"`
+	Templates []ProcessDTO      `json:"templates"`
+	Profiles  []ProfileDTO      `json:"profiles"`
+	Schedules []ScheduleDTO     `json:"schedules"`
+	Settings  SettingsDTO       `json:"settings"`
+	Vars      map[string]string `json:"vars"`
+	Files     []string          `json:"files"` // included files, see IncludedFiles
+}
+
+// unexpanded returns a copy of dto with fields that use variables set back
+// to their raw text.
+func (dto ConfigDTO) unexpanded() ConfigDTO {
+	unexpand := func(items []ProcessDTO) []ProcessDTO {
+		out := make([]ProcessDTO, len(items))
+		for i, p := range items {
+			for key, raw := range p.Raw {
+				switch key {
+				case "path":
+					p.Path = raw
+				case "process":
+					p.Process = raw
+				case "command":
+					p.Command = raw
+				case "args":
+					p.Args = raw
+				case "checkCmdline":
+					p.CheckCmdline = raw
+				}
+			}
+			p.Raw = nil
+			out[i] = p
+		}
+		return out
+	}
+	dto.Processes = unexpand(dto.Processes)
+	dto.Templates = unexpand(dto.Templates)
+	if raw, ok := dto.Settings.Raw["errorWindowTitles"]; ok {
+		dto.Settings.ErrorWindowTitles = raw
+	}
+	dto.Settings.Raw = nil
+	return dto
 }
 
 // ToDTO converts Config to ConfigDTO.
@@ -59,7 +209,13 @@ func ToDTO(cfg Config) ConfigDTO {
 		Processes: make([]ProcessDTO, 0, len(names)),
 		Settings: SettingsDTO{
 			CheckTiming:           durString(cfg.Settings.CheckTiming),
+			CheckTimingMax:        durString(cfg.Settings.CheckTimingMax),
 			RestartTiming:         durString(cfg.Settings.RestartTiming),
+			MetricsTiming:         durString(cfg.Settings.MetricsTiming),
+			BulkParallel:          cfg.Settings.BulkParallel,
+			BulkStagger:           durString(cfg.Settings.BulkStagger),
+			RollingBatch:          cfg.Settings.RollingBatch,
+			RollingTimeout:        durString(cfg.Settings.RollingTimeout),
 			AutoRestart:           cfg.Settings.AutoRestart,
 			AutoRestartTime:       cfg.Settings.AutoRestartTime,
 			AutoRestartOnExit:     cfg.Settings.AutoRestartOnExit,
@@ -70,35 +226,96 @@ func ToDTO(cfg Config) ConfigDTO {
 			NetDebug:              cfg.Settings.NetDebug,
 			NetUnit:               cfg.Settings.NetUnit,
 			NetScale:              floatToString(cfg.Settings.NetScale),
+			WatchConfig:           cfg.Settings.WatchConfig,
+			RestartOnConfigChange: cfg.Settings.RestartOnConfigChange,
+			BackupCount:           cfg.Settings.BackupCount,
+			Include:               cfg.Settings.Include,
+			Profile:               cfg.Settings.Profile,
+			ControlAddr:           cfg.Settings.ControlAddr,
+			Holidays:              cfg.Settings.Holidays,
+			Raw:                   maps.Clone(cfg.Settings.raw),
 		},
+		Vars:  maps.Clone(cfg.Vars),
+		Files: append([]string{}, cfg.files...),
 	}
 
 	for _, name := range names {
-		p := cfg.Process[name]
-		out.Processes = append(out.Processes, ProcessDTO{
-			Name:                name,
-			Disabled:            p.Disabled,
-			Type:                p.Type,
-			Process:             p.Process,
-			Path:                p.Path,
-			Command:             p.Command,
-			Args:                p.Args,
-			Screen:              p.Screen,
-			CheckProcess:        p.CheckProcess,
-			CheckCmdline:        p.CheckCmdline,
-			CheckCmdlineExclude: p.CheckCmdlineExclude,
-			DelayStartTime:      durStringZero(p.DelayStartTime),
-			MonitorHang:         p.MonitorHang,
-			HangTimeout:         durString(p.HangTimeout),
+		out.Processes = append(out.Processes, processToDTO(name, cfg.Process[name]))
+	}
+	out.Templates = make([]ProcessDTO, 0, len(cfg.Template))
+	for _, name := range sortedKeys(cfg.Template) {
+		out.Templates = append(out.Templates, processToDTO(name, cfg.Template[name]))
+	}
+	out.Profiles = make([]ProfileDTO, 0, len(cfg.Profile))
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profile[name]
+		dto := ProfileDTO{Name: name, Processes: append([]string{}, p.Processes...)}
+		for procName, fields := range p.Overrides {
+			if dto.Overrides == nil {
+				dto.Overrides = make(map[string]map[string]string)
+			}
+			dto.Overrides[procName] = maps.Clone(fields)
+		}
+		out.Profiles = append(out.Profiles, dto)
+	}
+	out.Schedules = make([]ScheduleDTO, 0, len(cfg.Schedule))
+	for _, name := range sortedKeys(cfg.Schedule) {
+		s := cfg.Schedule[name]
+		out.Schedules = append(out.Schedules, ScheduleDTO{
+			Name:     name,
+			Disabled: s.Disabled,
+			Cron:     s.Cron,
+			Action:   s.Action,
+			Target:   s.Target,
+			Timezone: s.Timezone,
+			CatchUp:  durString(s.CatchUp),
+			Duration: durString(s.Duration),
 		})
 	}
 	return out
 }
 
+func processToDTO(name string, p *ProcessItem) ProcessDTO {
+	return ProcessDTO{
+		Name:                name,
+		Disabled:            p.Disabled,
+		Type:                p.Type,
+		Process:             p.Process,
+		Path:                p.Path,
+		Command:             p.Command,
+		Args:                p.Args,
+		Screen:              p.Screen.N,
+		ScreenExpr:          p.Screen.Expr,
+		Instances:           p.Instances,
+		CheckProcess:        p.CheckProcess,
+		CheckCmdline:        p.CheckCmdline,
+		CheckCmdlineExclude: p.CheckCmdlineExclude,
+		DelayStartTime:      durStringZero(p.DelayStartTime),
+		StartTimeout:        durString(p.StartTimeout),
+		MinUptime:           durString(p.MinUptime),
+		MonitorHang:         p.MonitorHang,
+		HangTimeout:         durString(p.HangTimeout),
+		RunWindow:           p.RunWindow,
+		MaxUptime:           durString(p.MaxUptime),
+		RestartAt:           p.RestartAt,
+		Cron:                p.Cron,
+		Timeout:             durString(p.Timeout),
+		Extends:             p.Extends,
+		Groups:              p.Groups,
+		Source:              p.source,
+		Inherited:           maps.Clone(p.inherited),
+		Overridden:          maps.Clone(p.overridden),
+		Raw:                 maps.Clone(p.raw),
+	}
+}
+
 // FromDTO converts ConfigDTO into Config.
 func FromDTO(dto ConfigDTO) (Config, error) {
+	dto = dto.unexpanded()
 	cfg := Config{
+		Vars:    maps.Clone(dto.Vars),
 		Process: make(map[string]*ProcessItem),
+		files:   append([]string{}, dto.Files...),
 	}
 
 	var d Duration
@@ -112,6 +329,28 @@ func FromDTO(dto ConfigDTO) (Config, error) {
 	}
 	cfg.Settings.RestartTiming = d
 
+	if err := d.UnmarshalText([]byte(dto.Settings.CheckTimingMax)); err != nil {
+		return Config{}, fmt.Errorf("checkTimingMax: %w", err)
+	}
+	cfg.Settings.CheckTimingMax = d
+
+	if err := d.UnmarshalText([]byte(dto.Settings.MetricsTiming)); err != nil {
+		return Config{}, fmt.Errorf("metricsTiming: %w", err)
+	}
+	cfg.Settings.MetricsTiming = d
+
+	cfg.Settings.BulkParallel = dto.Settings.BulkParallel
+	if err := d.UnmarshalText([]byte(dto.Settings.BulkStagger)); err != nil {
+		return Config{}, fmt.Errorf("bulkStagger: %w", err)
+	}
+	cfg.Settings.BulkStagger = d
+
+	cfg.Settings.RollingBatch = dto.Settings.RollingBatch
+	if err := d.UnmarshalText([]byte(dto.Settings.RollingTimeout)); err != nil {
+		return Config{}, fmt.Errorf("rollingTimeout: %w", err)
+	}
+	cfg.Settings.RollingTimeout = d
+
 	cfg.Settings.AutoRestart = dto.Settings.AutoRestart
 	cfg.Settings.AutoRestartTime = strings.TrimSpace(dto.Settings.AutoRestartTime)
 	cfg.Settings.AutoRestartOnExit = dto.Settings.AutoRestartOnExit
@@ -127,6 +366,63 @@ func FromDTO(dto ConfigDTO) (Config, error) {
 	cfg.Settings.NetDebug = dto.Settings.NetDebug
 	cfg.Settings.NetUnit = strings.TrimSpace(dto.Settings.NetUnit)
 	cfg.Settings.NetScale = parseFloatOrZero(dto.Settings.NetScale)
+	cfg.Settings.WatchConfig = dto.Settings.WatchConfig
+	cfg.Settings.RestartOnConfigChange = dto.Settings.RestartOnConfigChange
+	cfg.Settings.BackupCount = dto.Settings.BackupCount
+	cfg.Settings.Include = strings.TrimSpace(dto.Settings.Include)
+	cfg.Settings.Profile = strings.TrimSpace(dto.Settings.Profile)
+	cfg.Settings.ControlAddr = strings.TrimSpace(dto.Settings.ControlAddr)
+	cfg.Settings.Holidays = strings.TrimSpace(dto.Settings.Holidays)
+
+	for _, p := range dto.Profiles {
+		name := strings.TrimSpace(p.Name)
+		if name == "" {
+			return Config{}, fmt.Errorf("profile name is empty")
+		}
+		if _, exists := cfg.Profile[name]; exists {
+			return Config{}, fmt.Errorf("duplicate profile name: %s", name)
+		}
+		if cfg.Profile == nil {
+			cfg.Profile = make(map[string]*Profile)
+		}
+		cfg.Profile[name] = p.profile()
+	}
+
+	for _, s := range dto.Schedules {
+		name := strings.TrimSpace(s.Name)
+		if name == "" {
+			return Config{}, fmt.Errorf("schedule name is empty")
+		}
+		if _, exists := cfg.Schedule[name]; exists {
+			return Config{}, fmt.Errorf("duplicate schedule name: %s", name)
+		}
+		item, err := s.schedule()
+		if err != nil {
+			return Config{}, err
+		}
+		if cfg.Schedule == nil {
+			cfg.Schedule = make(map[string]*Schedule)
+		}
+		cfg.Schedule[name] = item
+	}
+
+	set := make(sectionKeys)
+	cfg.Template = make(map[string]*ProcessItem, len(dto.Templates))
+	for _, p := range dto.Templates {
+		name := strings.TrimSpace(p.Name)
+		if name == "" {
+			return Config{}, fmt.Errorf("template name is empty")
+		}
+		if _, exists := cfg.Template[name]; exists {
+			return Config{}, fmt.Errorf("duplicate template name: %s", name)
+		}
+		item, err := processFromDTO(p, dto.Files)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Template[name] = item
+		dtoSectionKeys(iniSectionRef{Name: "template", Sub: name}, p, item, set)
+	}
 
 	for _, p := range dto.Processes {
 		name := strings.TrimSpace(p.Name)
@@ -136,33 +432,81 @@ func FromDTO(dto ConfigDTO) (Config, error) {
 		if _, exists := cfg.Process[name]; exists {
 			return Config{}, fmt.Errorf("duplicate process name: %s", name)
 		}
-
-		var ht Duration
-		if err := ht.UnmarshalText([]byte(p.HangTimeout)); err != nil {
-			return Config{}, fmt.Errorf("hangTimeout for %s: %w", name, err)
-		}
-		var dst Duration
-		if err := dst.UnmarshalText([]byte(p.DelayStartTime)); err != nil {
-			return Config{}, fmt.Errorf("delayStartTime for %s: %w", name, err)
+		item, err := processFromDTO(p, dto.Files)
+		if err != nil {
+			return Config{}, err
 		}
+		cfg.Process[name] = item
+		dtoSectionKeys(iniSectionRef{Name: "process", Sub: name}, p, item, set)
+	}
+	if err := cfg.resolveTemplates(set); err != nil {
+		return Config{}, err
+	}
+	cfg.expandVars()
+	return cfg, nil
+}
 
-		cfg.Process[name] = &ProcessItem{
-			Disabled:            p.Disabled,
-			Type:                strings.TrimSpace(p.Type),
-			Process:             p.Process,
-			Path:                p.Path,
-			Command:             p.Command,
-			Args:                p.Args,
-			Screen:              p.Screen,
-			CheckProcess:        p.CheckProcess,
-			CheckCmdline:        p.CheckCmdline,
-			CheckCmdlineExclude: p.CheckCmdlineExclude,
-			DelayStartTime:      dst,
-			MonitorHang:         p.MonitorHang,
-			HangTimeout:         ht,
+func processFromDTO(p ProcessDTO, files []string) (*ProcessItem, error) {
+	name := strings.TrimSpace(p.Name)
+	if p.Source != "" && !slices.Contains(files, p.Source) {
+		return nil, fmt.Errorf("%s: unknown config file %q", name, p.Source)
+	}
+	var ht Duration
+	if err := ht.UnmarshalText([]byte(p.HangTimeout)); err != nil {
+		return nil, fmt.Errorf("hangTimeout for %s: %w", name, err)
+	}
+	var dst Duration
+	if err := dst.UnmarshalText([]byte(p.DelayStartTime)); err != nil {
+		return nil, fmt.Errorf("delayStartTime for %s: %w", name, err)
+	}
+	var startTimeout Duration
+	if err := startTimeout.UnmarshalText([]byte(p.StartTimeout)); err != nil {
+		return nil, fmt.Errorf("startTimeout for %s: %w", name, err)
+	}
+	var minUptime Duration
+	if err := minUptime.UnmarshalText([]byte(p.MinUptime)); err != nil {
+		return nil, fmt.Errorf("minUptime for %s: %w", name, err)
+	}
+	var maxUptime Duration
+	if err := maxUptime.UnmarshalText([]byte(p.MaxUptime)); err != nil {
+		return nil, fmt.Errorf("maxUptime for %s: %w", name, err)
+	}
+	var timeout Duration
+	if err := timeout.UnmarshalText([]byte(p.Timeout)); err != nil {
+		return nil, fmt.Errorf("timeout for %s: %w", name, err)
+	}
+	screen := ScreenSpec{N: p.Screen}
+	if strings.TrimSpace(p.ScreenExpr) != "" {
+		if err := screen.UnmarshalText([]byte(p.ScreenExpr)); err != nil {
+			return nil, fmt.Errorf("screen for %s: %w", name, err)
 		}
 	}
-	return cfg, nil
+	return &ProcessItem{
+		Disabled:            p.Disabled,
+		Type:                strings.TrimSpace(p.Type),
+		Process:             p.Process,
+		Path:                p.Path,
+		Command:             p.Command,
+		Args:                p.Args,
+		Screen:              screen,
+		Instances:           p.Instances,
+		CheckProcess:        p.CheckProcess,
+		CheckCmdline:        p.CheckCmdline,
+		CheckCmdlineExclude: p.CheckCmdlineExclude,
+		DelayStartTime:      dst,
+		StartTimeout:        startTimeout,
+		MinUptime:           minUptime,
+		MonitorHang:         p.MonitorHang,
+		HangTimeout:         ht,
+		RunWindow:           strings.TrimSpace(p.RunWindow),
+		MaxUptime:           maxUptime,
+		RestartAt:           strings.TrimSpace(p.RestartAt),
+		Cron:                strings.TrimSpace(p.Cron),
+		Timeout:             timeout,
+		Extends:             strings.TrimSpace(p.Extends),
+		Groups:              strings.Join(parseNameList(p.Groups), ", "),
+		source:              p.Source,
+	}, nil
 }
 
 func durString(d Duration) string {
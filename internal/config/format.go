@@ -0,0 +1,467 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a config file syntax.
+type Format string
+
+const (
+	FormatINI  Format = "ini"
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// DefaultConfigNames are the config file names looked up next to the
+// executable, in order of preference.
+var DefaultConfigNames = []string{DefaultConfigName, "config.yaml", "config.yml", "config.toml", "config.json"}
+
+// DetectFormat picks the format from the file extension. Unknown extensions
+// are read as INI.
+func DetectFormat(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatINI
+	}
+}
+
+// FindInDir returns the first existing config file from DefaultConfigNames.
+func FindInDir(dir string) (string, bool) {
+	for _, name := range DefaultConfigNames {
+		candidate := filepath.Join(dir, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// ConvertFile loads src in its own format and writes it to dst in the format
+// implied by dst's extension. dst must not exist yet. The files src
+// includes are merged into dst and left as they are, so dst must not be
+// next to a conf.d folder that it would load again.
+func ConvertFile(src, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	cfg, err := Load(src)
+	if err != nil {
+		return err
+	}
+	dto := ToDTO(cfg)
+	if len(dto.Files) > 0 {
+		if again, _ := IncludedFiles(dst, ""); len(again) > 0 {
+			return fmt.Errorf("%s would also load %s, which is merged into it; convert into another folder", dst, strings.Join(again, ", "))
+		}
+		dto.Files = nil
+		dto.Settings.Include = ""
+		for i := range dto.Templates {
+			dto.Templates[i].Source = ""
+		}
+		for i := range dto.Processes {
+			dto.Processes[i].Source = ""
+		}
+	}
+	return WriteFromDTO(dst, dto)
+}
+
+func decodeStructured(path string, format Format) (configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return configFile{}, err
+	}
+	raw := map[string]any{}
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&raw)
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &raw)
+	case FormatTOML:
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return configFile{}, fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return configFile{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return decodeMap(raw)
+}
+
+func encodeStructured(format Format, doc map[string]any) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case FormatJSON:
+		data, err = json.MarshalIndent(doc, "", "  ")
+		data = append(data, '\n')
+	case FormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		err = enc.Encode(doc)
+		data = buf.Bytes()
+	case FormatTOML:
+		var buf bytes.Buffer
+		err = toml.NewEncoder(&buf).Encode(doc)
+		data = buf.Bytes()
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	return data, err
+}
+
+// decodeMap maps a decoded JSON/YAML/TOML document onto Config. Keys are
+// matched case-insensitively against field names, like gcfg does for INI.
+func decodeMap(raw map[string]any) (configFile, error) {
+	cfg := Config{Process: make(map[string]*ProcessItem)}
+	set := make(sectionKeys)
+	sections := make(map[string]bool)
+	autoRestartOnExitSet := false
+	for key, val := range raw {
+		sections[strings.ToLower(key)] = true
+		switch strings.ToLower(key) {
+		case "settings":
+			m, ok := asMap(val)
+			if !ok {
+				return configFile{}, fmt.Errorf("settings: expected a table")
+			}
+			if err := assignFields(&cfg.Settings, m, "settings"); err != nil {
+				return configFile{}, err
+			}
+			for k := range m {
+				if strings.EqualFold(k, "autoRestartOnExit") {
+					autoRestartOnExitSet = true
+				}
+			}
+		case "vars":
+			m, ok := asMap(val)
+			if !ok {
+				return configFile{}, fmt.Errorf("vars: expected a table")
+			}
+			cfg.Vars = make(map[string]string, len(m))
+			for k, v := range m {
+				if cfg.Vars[k], ok = v.(string); !ok {
+					cfg.Vars[k] = fmt.Sprint(v)
+				}
+			}
+		case "profile":
+			profiles, err := profilesFromMap(val)
+			if err != nil {
+				return configFile{}, err
+			}
+			cfg.Profile = profiles
+		case "schedule":
+			schedules, err := schedulesFromMap(val)
+			if err != nil {
+				return configFile{}, err
+			}
+			cfg.Schedule = schedules
+		case "process", "template":
+			section := strings.ToLower(key)
+			items, err := itemsFromMap(val, section, set)
+			if err != nil {
+				return configFile{}, err
+			}
+			if section == "process" {
+				cfg.Process = items
+			} else {
+				cfg.Template = items
+			}
+		default:
+			return configFile{}, fmt.Errorf("unknown section %q", key)
+		}
+	}
+	if !autoRestartOnExitSet {
+		cfg.Settings.AutoRestartOnExit = true
+	}
+	return configFile{cfg: cfg, set: set, sections: sections}, nil
+}
+
+func itemsFromMap(val any, section string, set sectionKeys) (map[string]*ProcessItem, error) {
+	raw, ok := asMap(val)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a table of named entries", section)
+	}
+	items := make(map[string]*ProcessItem, len(raw))
+	for name, pv := range raw {
+		m, ok := asMap(pv)
+		if !ok {
+			return nil, fmt.Errorf("%s %q: expected a table", section, name)
+		}
+		item := &ProcessItem{}
+		if err := assignFields(item, m, fmt.Sprintf("%s %q", section, name)); err != nil {
+			return nil, err
+		}
+		for k := range m {
+			set.add(iniSectionRef{Name: section, Sub: name}, k)
+		}
+		items[name] = item
+	}
+	return items, nil
+}
+
+// configToMap builds the document for one file: the main config ("") gets
+// settings, vars, profiles, schedules and its own entries, an included file
+// only its entries.
+func configToMap(cfg Config, source string) map[string]any {
+	out := map[string]any{
+		"process": itemsToMap(cfg.Process, source),
+	}
+	if templates := itemsToMap(cfg.Template, source); len(templates) > 0 {
+		out["template"] = templates
+	}
+	if source != "" {
+		return out
+	}
+	settings := fieldsToMap(reflect.ValueOf(cfg.Settings))
+	for key, raw := range cfg.Settings.raw {
+		settings[key] = raw
+	}
+	out["settings"] = settings
+	if len(cfg.Vars) > 0 {
+		out["vars"] = cfg.Vars
+	}
+	if len(cfg.Profile) > 0 {
+		out["profile"] = profilesToMap(cfg.Profile)
+	}
+	if len(cfg.Schedule) > 0 {
+		schedules := make(map[string]any, len(cfg.Schedule))
+		for name, s := range cfg.Schedule {
+			schedules[name] = fieldsToMap(reflect.ValueOf(*s))
+		}
+		out["schedule"] = schedules
+	}
+	return out
+}
+
+// itemsToMap writes the own fields of each item defined in source, with
+// variables unexpanded; inherited ones are left to the template.
+func itemsToMap(items map[string]*ProcessItem, source string) map[string]any {
+	out := make(map[string]any, len(items))
+	for name, item := range items {
+		if item.source != source {
+			continue
+		}
+		m := fieldsToMap(reflect.ValueOf(*item))
+		for key, raw := range item.raw {
+			m[key] = raw
+		}
+		for key := range item.inherited {
+			delete(m, key)
+		}
+		out[name] = m
+	}
+	return out
+}
+
+var (
+	durationType = reflect.TypeOf(Duration{})
+	screenType   = reflect.TypeOf(ScreenSpec{})
+)
+
+func assignFields(dst any, m map[string]any, where string) error {
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		idx := -1
+		for i := 0; i < t.NumField(); i++ {
+			if strings.EqualFold(t.Field(i).Name, key) && t.Field(i).IsExported() && !isRuntimeField(t.Field(i).Name) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("%s: unknown key %q", where, key)
+		}
+		if err := assignValue(v.Field(idx), t.Field(idx).Name, m[key]); err != nil {
+			return fmt.Errorf("%s: %s: %w", where, key, err)
+		}
+	}
+	return nil
+}
+
+func assignValue(f reflect.Value, name string, val any) error {
+	if f.Type() == durationType {
+		var d Duration
+		switch x := val.(type) {
+		case string:
+			if err := d.UnmarshalText([]byte(x)); err != nil {
+				return err
+			}
+		default:
+			secs, ok := asFloat(val)
+			if !ok {
+				return fmt.Errorf("expected duration, got %T", val)
+			}
+			d.Duration = secondsToDuration(secs)
+		}
+		f.Set(reflect.ValueOf(d))
+		return nil
+	}
+	if f.Type() == screenType {
+		var s ScreenSpec
+		switch x := val.(type) {
+		case string:
+			if err := s.UnmarshalText([]byte(x)); err != nil {
+				return err
+			}
+		default:
+			n, ok := asFloat(val)
+			if !ok || n != math.Trunc(n) {
+				return fmt.Errorf("expected screen number or template, got %v", val)
+			}
+			s.N = int(n)
+		}
+		f.Set(reflect.ValueOf(s))
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.String:
+		switch x := val.(type) {
+		case string:
+			f.SetString(x)
+		case []any:
+			parts := make([]string, 0, len(x))
+			for _, p := range x {
+				s, ok := p.(string)
+				if !ok {
+					return fmt.Errorf("expected list of strings")
+				}
+				parts = append(parts, s)
+			}
+			if name == "Args" {
+				f.SetString(JoinArgs(parts))
+			} else {
+				f.SetString(strings.Join(parts, ", "))
+			}
+		default:
+			return fmt.Errorf("expected string, got %T", val)
+		}
+	case reflect.Bool:
+		b, ok := val.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool, got %T", val)
+		}
+		f.SetBool(b)
+	case reflect.Int:
+		n, ok := asFloat(val)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("expected integer, got %v", val)
+		}
+		f.SetInt(int64(n))
+	case reflect.Float64:
+		n, ok := asFloat(val)
+		if !ok {
+			return fmt.Errorf("expected number, got %v", val)
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Kind())
+	}
+	return nil
+}
+
+func fieldsToMap(v reflect.Value) map[string]any {
+	t := v.Type()
+	out := make(map[string]any, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if isRuntimeField(name) || !t.Field(i).IsExported() {
+			continue
+		}
+		f := v.Field(i)
+		key := fieldKey(name)
+		if f.Type() == durationType {
+			if d := f.Interface().(Duration); d.Duration != 0 {
+				out[key] = d.Duration.String()
+			}
+			continue
+		}
+		if f.Type() == screenType {
+			if s := f.Interface().(ScreenSpec); s.Expr != "" {
+				out[key] = s.Expr
+			} else if s.N != 0 {
+				out[key] = s.N
+			}
+			continue
+		}
+		switch f.Kind() {
+		case reflect.String:
+			if f.String() == "" {
+				continue
+			}
+			if name == "Args" {
+				out[key] = SplitArgs(f.String())
+			} else {
+				out[key] = f.String()
+			}
+		case reflect.Bool:
+			out[key] = f.Bool()
+		case reflect.Int:
+			if f.Int() != 0 {
+				out[key] = f.Int()
+			}
+		case reflect.Float64:
+			if f.Float() != 0 {
+				out[key] = f.Float()
+			}
+		}
+	}
+	return out
+}
+
+// isRuntimeField reports struct fields that hold runtime state, not config.
+func isRuntimeField(name string) bool {
+	return name == "Pid"
+}
+
+func asMap(v any) (map[string]any, bool) {
+	m, ok := v.(map[string]any)
+	return m, ok
+}
+
+func asFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float64:
+		return x, true
+	case json.Number:
+		f, err := strconv.ParseFloat(x.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const formatTestINI = `[settings]
+checkTiming=1s
+restartTiming=5s
+autoRestartOnExit=false
+watchConfig=true
+netScale=1.5
+bulkParallel=2
+controlAddr=127.0.0.1:47700
+
+[vars]
+root="C:\\Exhibit"
+
+[process "UE"]
+type=exe
+path="${root}\\UE"
+process=Game.exe
+args="-windowed -ResX=1920"
+screen=2
+groups=show, main
+delayStartTime=3s
+maxUptime=12h
+runWindow="Mon-Fri 09:00-21:00; Sat-Sun 10:00-18:00"
+
+[process "WEB"]
+type=cmd
+path="${root}\\web"
+command="npm start"
+checkProcess=node.exe
+checkCmdline=server.js
+checkCmdlineExclude=--inspect
+disabled=true
+
+[schedule "nightly"]
+cron=30 4 * * *
+action=rolling-restart
+target=UE
+`
+
+func TestConvertRoundTrip(t *testing.T) {
+	for _, ext := range []string{".json", ".yaml", ".toml"} {
+		t.Run(ext, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "config.ini")
+			mid := filepath.Join(dir, "config"+ext)
+			back := filepath.Join(dir, "back.ini")
+			if err := os.WriteFile(src, []byte(formatTestINI), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := ConvertFile(src, mid); err != nil {
+				t.Fatalf("ini -> %s: %v", ext, err)
+			}
+			if err := ConvertFile(mid, back); err != nil {
+				t.Fatalf("%s -> ini: %v", ext, err)
+			}
+			want, err := Load(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ue := want.Process["UE"]; ue == nil || ue.RunWindow != "Mon-Fri 09:00-21:00; Sat-Sun 10:00-18:00" || len(want.Schedule) != 1 {
+				t.Fatalf("source not loaded as expected: %+v", want)
+			}
+			for _, path := range []string{mid, back} {
+				got, err := Load(path)
+				if err != nil {
+					t.Fatalf("%s: %v", filepath.Base(path), err)
+				}
+				if !reflect.DeepEqual(ToDTO(got), ToDTO(want)) {
+					t.Errorf("%s differs from the source:\ngot  %+v\nwant %+v", filepath.Base(path), ToDTO(got), ToDTO(want))
+				}
+			}
+		})
+	}
+}
+
+func TestConvertFileRefusesExistingTarget(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "config.ini")
+	dst := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(src, []byte(formatTestINI), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvertFile(src, dst); err == nil {
+		t.Fatal("ConvertFile overwrote an existing file")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		want Format
+	}{
+		{"config.ini", FormatINI},
+		{"config.json", FormatJSON},
+		{"config.yaml", FormatYAML},
+		{"config.yml", FormatYAML},
+		{"config.toml", FormatTOML},
+		{"CONFIG.JSON", FormatJSON},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.path); got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestConvertFileWithInclude(t *testing.T) {
+	const main = `[settings]
+checkTiming=1s
+restartTiming=5s
+
+[process "UE"]
+type=cmd
+command=game.cmd
+`
+	const part = `[process "WEB"]
+type=cmd
+command="npm start"
+`
+	dir := t.TempDir()
+	src := filepath.Join(dir, "config.ini")
+	inc := filepath.Join(dir, ConfDirName, "web.ini")
+	if err := os.MkdirAll(filepath.Dir(inc), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte(main), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(inc, []byte(part), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	unchanged := func() {
+		t.Helper()
+		if got, err := os.ReadFile(inc); err != nil || string(got) != part {
+			t.Fatalf("included file changed: %q, %v", got, err)
+		}
+		entries, err := os.ReadDir(filepath.Dir(inc))
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("conf.d holds %v, %v", entries, err)
+		}
+	}
+
+	// Next to conf.d the converted file would load web.ini a second time.
+	same := filepath.Join(dir, "config.json")
+	if err := ConvertFile(src, same); err == nil {
+		t.Fatal("ConvertFile wrote a file next to the conf.d it merged")
+	}
+	if _, err := os.Stat(same); !os.IsNotExist(err) {
+		t.Fatalf("%s written: %v", same, err)
+	}
+	unchanged()
+
+	dst := filepath.Join(t.TempDir(), "config.json")
+	if err := ConvertFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	unchanged()
+	got, err := Load(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Process) != 2 || got.Process["WEB"] == nil || len(got.Files()) != 0 {
+		t.Errorf("converted config has processes %v and files %v, want UE and WEB merged", sortedKeys(got.Process), got.Files())
+	}
+	if _, err := os.Stat(BackupDir(dst)); !os.IsNotExist(err) {
+		t.Errorf("backup folder created for a new file: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import "sort"
+
+// GroupList returns the distinct group names of groups in config order.
+// Instances of an entry share its groups.
+func (p *ProcessItem) GroupList() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, g := range parseNameList(p.Groups) {
+		if !seen[g] {
+			seen[g] = true
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// InGroup reports whether the process belongs to group.
+func (p *ProcessItem) InGroup(group string) bool {
+	for _, g := range parseNameList(p.Groups) {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Groups returns the sorted names of all groups used by processes.
+func (cfg Config) Groups() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, item := range cfg.Process {
+		for _, g := range item.GroupList() {
+			if !seen[g] {
+				seen[g] = true
+				out = append(out, g)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
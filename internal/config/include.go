@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConfDirName is the folder next to the config whose files are loaded
+// automatically, in name order, after the ones listed in settings.include.
+const ConfDirName = "conf.d"
+
+// IncludedFiles lists the files merged into the config at path: the
+// comma-separated files and globs of include, then every config file in
+// conf.d. Names are slash-separated and relative to the config's folder
+// when possible. A listed file without glob characters must exist.
+func IncludedFiles(path, include string) ([]string, error) {
+	dir := filepath.Dir(path)
+	self, _ := filepath.Abs(path)
+	seen := map[string]bool{self: true}
+	var out []string
+	add := func(matches []string) {
+		sort.Strings(matches)
+		for _, m := range matches {
+			abs, err := filepath.Abs(m)
+			if err != nil || seen[abs] {
+				continue
+			}
+			if fi, err := os.Stat(m); err != nil || fi.IsDir() {
+				continue
+			}
+			seen[abs] = true
+			name := m
+			if rel, err := filepath.Rel(dir, m); err == nil && !strings.HasPrefix(rel, "..") {
+				name = rel
+			}
+			out = append(out, filepath.ToSlash(name))
+		}
+	}
+
+	for _, pattern := range strings.Split(include, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		full := IncludePath(path, pattern)
+		matches, err := filepath.Glob(full)
+		if err != nil {
+			return nil, fmt.Errorf("include %q: %w", pattern, err)
+		}
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return nil, fmt.Errorf("include %q: file not found", pattern)
+		}
+		add(matches)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, ConfDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var confd []string
+	for _, e := range entries {
+		if !e.IsDir() && isConfigFile(e.Name()) {
+			confd = append(confd, filepath.Join(dir, ConfDirName, e.Name()))
+		}
+	}
+	add(confd)
+	return out, nil
+}
+
+// IncludePath resolves a file name from IncludedFiles against the config.
+func IncludePath(path, file string) string {
+	file = filepath.FromSlash(file)
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(filepath.Dir(path), file)
+}
+
+// Files returns the included files the config was merged from.
+func (cfg Config) Files() []string {
+	return cfg.files
+}
+
+// Source returns the included file that defines the entry, or "" for the
+// main config.
+func (p *ProcessItem) Source() string {
+	return p.source
+}
+
+func isConfigFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ini", ".json", ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+// merge adds the processes and templates of an included file. Names must
+// be unique across all files; settings, vars, profiles and schedules
+// belong to the main config.
+func (f *configFile) merge(part configFile, file string) error {
+	for _, section := range []string{"settings", "vars", "profile", "schedule"} {
+		if part.sections[section] {
+			return fmt.Errorf("%s: [%s] is only allowed in the main config", file, section)
+		}
+	}
+	add := func(section string, dst *map[string]*ProcessItem, items map[string]*ProcessItem) error {
+		if *dst == nil {
+			*dst = make(map[string]*ProcessItem, len(items))
+		}
+		for _, name := range sortedKeys(items) {
+			if prev, ok := (*dst)[name]; ok {
+				return fmt.Errorf("%s: %s %q is already defined in %s", file, section, name, sourceName(prev.source))
+			}
+			items[name].source = file
+			(*dst)[name] = items[name]
+			ref := iniSectionRef{Name: section, Sub: name}
+			for key := range part.set[ref] {
+				f.set.add(ref, key)
+			}
+		}
+		return nil
+	}
+	if err := add("template", &f.cfg.Template, part.cfg.Template); err != nil {
+		return err
+	}
+	if err := add("process", &f.cfg.Process, part.cfg.Process); err != nil {
+		return err
+	}
+	f.cfg.files = append(f.cfg.files, file)
+	return nil
+}
+
+func sourceName(source string) string {
+	if source == "" {
+		return "the main config"
+	}
+	return source
+}
+
+// watchedFiles lists the config, its included files and the holidays file
+// for change detection. It never modifies files and ignores decode errors.
+func watchedFiles(path string) []string {
+	files := []string{path}
+	include := ""
+	if f, err := decodeFile(path); err == nil {
+		include = f.cfg.Settings.Include
+		if holidays := strings.TrimSpace(f.cfg.Settings.Holidays); holidays != "" {
+			files = append(files, IncludePath(path, holidays))
+		}
+	}
+	names, err := IncludedFiles(path, include)
+	if err != nil {
+		names, _ = IncludedFiles(path, "")
+	}
+	for _, name := range names {
+		files = append(files, IncludePath(path, name))
+	}
+	return files
+}
@@ -0,0 +1,359 @@
+package config
+
+import (
+	"strings"
+)
+
+// iniDoc is a line-oriented INI document that can be patched in place.
+// Every line that is not explicitly changed is written back byte-for-byte,
+// so comments, blank lines, ordering, quoting and unknown keys survive.
+type iniDoc struct {
+	lines []iniLine
+	eol   string
+}
+
+type iniLineKind int
+
+const (
+	iniOther iniLineKind = iota // blank line, comment or anything unparsable
+	iniSection
+	iniKey
+)
+
+type iniLine struct {
+	text    string
+	kind    iniLineKind
+	section string // lower-case section name (header lines)
+	sub     string // subsection name (header lines)
+	key     string // key as written (key lines)
+}
+
+// iniSectionRef identifies a section such as [settings] or [process "NAME"].
+type iniSectionRef struct {
+	Name string
+	Sub  string
+}
+
+func (r iniSectionRef) header() string {
+	if r.Sub == "" {
+		return "[" + r.Name + "]"
+	}
+	return "[" + r.Name + " " + quoteSubsection(r.Sub) + "]"
+}
+
+func (r iniSectionRef) matches(l iniLine) bool {
+	return l.kind == iniSection && l.section == strings.ToLower(r.Name) && l.sub == r.Sub
+}
+
+func parseINI(data []byte) *iniDoc {
+	text := string(data)
+	doc := &iniDoc{eol: "\n"}
+	if strings.Contains(text, "\r\n") {
+		doc.eol = "\r\n"
+	}
+	if text == "" {
+		return doc
+	}
+	text = strings.TrimSuffix(text, "\n")
+	for _, raw := range strings.Split(text, "\n") {
+		doc.lines = append(doc.lines, parseINILine(strings.TrimSuffix(raw, "\r")))
+	}
+	return doc
+}
+
+func parseINILine(text string) iniLine {
+	l := iniLine{text: text}
+	trim := strings.TrimSpace(text)
+	if trim == "" || trim[0] == ';' || trim[0] == '#' {
+		return l
+	}
+	if trim[0] == '[' {
+		end := strings.LastIndexByte(trim, ']')
+		if end < 0 {
+			return l
+		}
+		inner := strings.TrimSpace(trim[1:end])
+		name, sub := inner, ""
+		if i := strings.IndexAny(inner, " \t"); i >= 0 {
+			name = inner[:i]
+			sub = unquoteSubsection(strings.TrimSpace(inner[i:]))
+		}
+		l.kind = iniSection
+		l.section = strings.ToLower(name)
+		l.sub = sub
+		return l
+	}
+	key := trim
+	if i := strings.IndexByte(trim, '='); i >= 0 {
+		key = trim[:i]
+	}
+	l.kind = iniKey
+	l.key = strings.TrimSpace(key)
+	return l
+}
+
+// rawValue returns the value part of a key line exactly as written,
+// without surrounding whitespace and trailing comment.
+func (l iniLine) rawValue() (string, bool) {
+	i := strings.IndexByte(l.text, '=')
+	if i < 0 {
+		return "", false
+	}
+	v := l.text[i+1:]
+	if c := commentStart(v); c >= 0 {
+		v = v[:c]
+	}
+	return strings.TrimSpace(v), true
+}
+
+// value decodes the key value the same way gcfg does. A key without "="
+// is an implicit boolean true.
+func (l iniLine) value() string {
+	raw, ok := l.rawValue()
+	if !ok {
+		return "true"
+	}
+	return unquoteValue(raw)
+}
+
+// withValue returns the line with its value replaced by raw, keeping the key
+// spelling, indentation and any trailing comment.
+func (l iniLine) withValue(raw string) iniLine {
+	i := strings.IndexByte(l.text, '=')
+	if i < 0 {
+		return parseINILine(l.text + "=" + raw)
+	}
+	prefix := l.text[:i+1]
+	rest := l.text[i+1:]
+	if strings.HasPrefix(rest, " ") {
+		prefix += " "
+	}
+	suffix := ""
+	if c := commentStart(rest); c >= 0 {
+		suffix = rest[c:]
+		// Keep the whitespace that separated the value from the comment.
+		ws := rest[:c]
+		suffix = ws[len(strings.TrimRight(ws, " \t")):] + suffix
+	}
+	return parseINILine(prefix + raw + suffix)
+}
+
+func (d *iniDoc) bytes() []byte {
+	texts := make([]string, len(d.lines))
+	for i, l := range d.lines {
+		texts[i] = l.text
+	}
+	out := strings.Join(texts, d.eol)
+	if len(d.lines) > 0 {
+		out += d.eol
+	}
+	return []byte(out)
+}
+
+// sectionRange returns the [start, end) line range of a section body
+// including its header, or -1 if the section does not exist.
+func (d *iniDoc) sectionRange(ref iniSectionRef) (int, int) {
+	start := -1
+	for i, l := range d.lines {
+		if start < 0 {
+			if ref.matches(l) {
+				start = i
+			}
+			continue
+		}
+		if l.kind == iniSection {
+			return start, i
+		}
+	}
+	if start < 0 {
+		return -1, -1
+	}
+	return start, len(d.lines)
+}
+
+// sections lists the sections with the given name in document order.
+func (d *iniDoc) sections(name string) []iniSectionRef {
+	name = strings.ToLower(name)
+	var out []iniSectionRef
+	for _, l := range d.lines {
+		if l.kind == iniSection && l.section == name {
+			out = append(out, iniSectionRef{Name: name, Sub: l.sub})
+		}
+	}
+	return out
+}
+
+func (d *iniDoc) findKey(ref iniSectionRef, key string) int {
+	start, end := d.sectionRange(ref)
+	if start < 0 {
+		return -1
+	}
+	for i := start + 1; i < end; i++ {
+		if d.lines[i].kind == iniKey && strings.EqualFold(d.lines[i].key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// get returns the decoded value of key in section.
+func (d *iniDoc) get(ref iniSectionRef, key string) (string, bool) {
+	i := d.findKey(ref, key)
+	if i < 0 {
+		return "", false
+	}
+	return d.lines[i].value(), true
+}
+
+// set replaces the value of key in section, adding the key (and section)
+// when missing. raw must already be quoted for INI.
+func (d *iniDoc) set(ref iniSectionRef, key, raw string) {
+	if i := d.findKey(ref, key); i >= 0 {
+		d.lines[i] = d.lines[i].withValue(raw)
+		return
+	}
+	start, end := d.sectionRange(ref)
+	if start < 0 {
+		d.appendSection(ref)
+		start, end = d.sectionRange(ref)
+	}
+	at := start + 1
+	for i := start + 1; i < end; i++ {
+		if d.lines[i].kind == iniKey {
+			at = i + 1
+		}
+	}
+	d.insert(at, parseINILine(key+"="+raw))
+}
+
+// unset removes key from section.
+func (d *iniDoc) unset(ref iniSectionRef, key string) {
+	if i := d.findKey(ref, key); i >= 0 {
+		d.lines = append(d.lines[:i], d.lines[i+1:]...)
+	}
+}
+
+// removeSection deletes a section with its keys and the comments directly
+// above its header. Comments directly above the next section header are
+// kept since they describe that section.
+func (d *iniDoc) removeSection(ref iniSectionRef) {
+	start, end := d.sectionRange(ref)
+	if start < 0 {
+		return
+	}
+	from := start
+	for from > 0 && d.lines[from-1].kind == iniOther && strings.TrimSpace(d.lines[from-1].text) != "" {
+		from--
+	}
+	cut := end
+	if end < len(d.lines) {
+		for cut > start+1 && d.lines[cut-1].kind == iniOther && strings.TrimSpace(d.lines[cut-1].text) != "" {
+			cut--
+		}
+	}
+	d.lines = append(d.lines[:from], d.lines[cut:]...)
+}
+
+// appendSection adds an empty section after the last section of the same
+// name, or at the end of the document.
+func (d *iniDoc) appendSection(ref iniSectionRef) {
+	at := len(d.lines)
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		l := d.lines[i]
+		if l.kind == iniSection && l.section == strings.ToLower(ref.Name) {
+			_, end := d.sectionRange(iniSectionRef{Name: ref.Name, Sub: l.sub})
+			at = end
+			break
+		}
+	}
+	// Insert before blank lines and comments that lead into the next section.
+	if at < len(d.lines) {
+		for at > 0 && d.lines[at-1].kind == iniOther {
+			at--
+		}
+	}
+	block := []iniLine{parseINILine(ref.header())}
+	if at > 0 && strings.TrimSpace(d.lines[at-1].text) != "" {
+		block = append([]iniLine{{}}, block...)
+	}
+	if at < len(d.lines) && strings.TrimSpace(d.lines[at].text) != "" {
+		block = append(block, iniLine{})
+	}
+	d.lines = append(d.lines[:at], append(block, d.lines[at:]...)...)
+}
+
+func (d *iniDoc) insert(at int, l iniLine) {
+	d.lines = append(d.lines, iniLine{})
+	copy(d.lines[at+1:], d.lines[at:])
+	d.lines[at] = l
+}
+
+// commentStart returns the index of an inline comment outside quotes.
+func commentStart(s string) int {
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if inQuote {
+				i++
+			}
+		case '"':
+			inQuote = !inQuote
+		case ';', '#':
+			if !inQuote {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// unquoteValue decodes an INI value following gcfg rules: quoted parts keep
+// whitespace, backslash escapes \\ \" \n \t are supported.
+func unquoteValue(raw string) string {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch c {
+		case '"':
+			continue
+		case '\\':
+			if i+1 >= len(raw) {
+				continue
+			}
+			i++
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(raw[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func quoteSubsection(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func unquoteSubsection(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
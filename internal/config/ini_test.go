@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFromDTOUnchangedIsByteIdentical(t *testing.T) {
+	tests := []struct {
+		name string
+		ini  string
+	}{
+		{"minimal settings", `; exhibit hall 2
+[settings]
+checkTiming=1s
+restartTiming=5s
+watchConfig=true
+`},
+		{"process with comments", `[settings]
+checkTiming = 1s   ; poll
+restartTiming=5s
+
+; the main screen
+[process "UE"]
+type=exe
+path="C:\\UE"
+process=Game.exe
+args=-windowed -ResX=1920
+`},
+		{"autoRestartOnExit off", `[settings]
+checkTiming=1s
+restartTiming=5s
+autoRestartOnExit=false
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.ini")
+			if err := os.WriteFile(path, []byte(tt.ini), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteFromDTO(path, ToDTO(cfg)); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.ini {
+				t.Errorf("unchanged save rewrote the file:\n%s\nwant:\n%s", got, tt.ini)
+			}
+		})
+	}
+}
+
+func TestINIDocRemoveSection(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  iniSectionRef
+		in   string
+		want string
+	}{
+		{
+			name: "comment above removed header",
+			ref:  iniSectionRef{Name: "process", Sub: "A"},
+			in: `[settings]
+checkTiming=1s
+
+; about A
+[process "A"]
+type=exe
+
+; about B
+[process "B"]
+type=exe
+`,
+			want: `[settings]
+checkTiming=1s
+
+; about B
+[process "B"]
+type=exe
+`,
+		},
+		{
+			name: "comment glued to a key stays",
+			ref:  iniSectionRef{Name: "process", Sub: "A"},
+			in: `[settings]
+checkTiming=1s
+; note on settings
+[process "A"]
+type=exe
+`,
+			want: `[settings]
+checkTiming=1s
+`,
+		},
+		{
+			name: "last section",
+			ref:  iniSectionRef{Name: "process", Sub: "B"},
+			in: `[process "A"]
+type=exe
+
+; about B
+; still about B
+[process "B"]
+type=cmd
+`,
+			want: `[process "A"]
+type=exe
+
+`,
+		},
+		{
+			name: "missing section",
+			ref:  iniSectionRef{Name: "process", Sub: "C"},
+			in:   "[process \"A\"]\ntype=exe\n",
+			want: "[process \"A\"]\ntype=exe\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseINI([]byte(tt.in))
+			doc.removeSection(tt.ref)
+			if got := string(doc.bytes()); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestINIDocSetAndUnset(t *testing.T) {
+	doc := parseINI([]byte("[settings]\ncheckTiming = 1s ; poll\n\n[process \"A\"]\ntype=exe\n"))
+	ref := iniSectionRef{Name: "settings"}
+	doc.set(ref, "checkTiming", "2s")
+	doc.set(ref, "restartTiming", "5s")
+	doc.unset(iniSectionRef{Name: "process", Sub: "A"}, "type")
+	want := "[settings]\ncheckTiming = 2s ; poll\nrestartTiming=5s\n\n[process \"A\"]\n"
+	if got := string(doc.bytes()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if v, ok := doc.get(ref, "checktiming"); !ok || v != "2s" {
+		t.Errorf("get checktiming = %q, %v", v, ok)
+	}
+}
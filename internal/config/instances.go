@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// InstanceSep joins an entry name and instance number, e.g. "CHROME#2".
+const InstanceSep = "#"
+
+// InstanceVars are the template variables available in args, checkCmdline
+// and screen of entries with instances.
+type InstanceVars struct {
+	Name   string // entry name without the instance suffix
+	Index  int    // 1-based instance number
+	Count  int    // number of instances
+	Screen int    // resolved screen of this instance
+}
+
+// InstanceName returns the name of instance i of an entry.
+func InstanceName(name string, i int) string {
+	return name + InstanceSep + strconv.Itoa(i)
+}
+
+// BaseName strips the instance suffix from a process name. index is 0 for
+// names that are not instances.
+func BaseName(name string) (string, int) {
+	i := strings.LastIndex(name, InstanceSep)
+	if i < 0 {
+		return name, 0
+	}
+	n, err := strconv.Atoi(name[i+len(InstanceSep):])
+	if err != nil || n <= 0 {
+		return name, 0
+	}
+	return name[:i], n
+}
+
+func parseInstanceTemplate(text string) (*template.Template, error) {
+	return template.New("").Option("missingkey=error").Parse(text)
+}
+
+func renderInstance(text string, vars InstanceVars) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	t, err := parseInstanceTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// instanceError is a field of an entry with instances that does not
+// render for one instance.
+type instanceError struct {
+	Key string // field of the entry
+	Msg string
+}
+
+func (e *instanceError) Error() string {
+	return e.Key + ": " + e.Msg
+}
+
+// expandInstance builds instance index of item with its templates rendered.
+// Errors are *instanceError.
+func expandInstance(name string, item *ProcessItem, index int) (*ProcessItem, error) {
+	child := *item
+	vars := InstanceVars{Name: name, Index: index, Count: item.Instances, Screen: item.Screen.N}
+	if item.Screen.Expr != "" {
+		raw, err := renderInstance(item.Screen.Expr, vars)
+		if err != nil {
+			return nil, &instanceError{Key: "screen", Msg: err.Error()}
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, &instanceError{Key: "screen", Msg: fmt.Sprintf("%q is not a number", raw)}
+		}
+		child.Screen = ScreenSpec{N: n}
+		vars.Screen = n
+	}
+	var err error
+	if child.Args, err = renderInstance(item.Args, vars); err != nil {
+		return nil, &instanceError{Key: "args", Msg: err.Error()}
+	}
+	if child.CheckCmdline, err = renderInstance(item.CheckCmdline, vars); err != nil {
+		return nil, &instanceError{Key: "checkCmdline", Msg: err.Error()}
+	}
+	child.Pid = 0
+	return &child, nil
+}
+
+// Expanded returns a copy of cfg where every entry with instances=N is
+// replaced by N processes named NAME#1..NAME#N. Instances whose templates
+// fail to render are left out; Validate reports them.
+func (cfg Config) Expanded() Config {
+	out := cfg
+	out.Process = make(map[string]*ProcessItem, len(cfg.Process))
+	for name, item := range cfg.Process {
+		if item.Instances <= 0 {
+			out.Process[name] = item
+			continue
+		}
+		for i := 1; i <= item.Instances; i++ {
+			child, err := expandInstance(name, item, i)
+			if err != nil {
+				continue
+			}
+			out.Process[InstanceName(name, i)] = child
+		}
+	}
+	return out
+}
@@ -0,0 +1,364 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Profile is a named scene: the processes it lists run, all others are
+// disabled, and its overrides replace fields of the listed processes.
+//
+//	[profile "night"]
+//	processes=LOOP, CHROME
+//	CHROME.screen=2
+//	CHROME.args="--kiosk http://localhost/night"
+type Profile struct {
+	Processes []string                     // entry names, or NAME#N for one instance
+	Overrides map[string]map[string]string // process -> field key -> value
+}
+
+// profileKey is the list of processes in a profile section; other keys are
+// NAME.field overrides.
+const profileKey = "processes"
+
+// overridableField finds the ProcessItem field a profile override sets.
+// Enabling is done through the process list, and instances and extends
+// shape the config itself, so they cannot be overridden.
+func overridableField(key string) (reflect.StructField, bool) {
+	f, ok := fieldByKey(reflect.TypeOf(ProcessItem{}), key)
+	if !ok || f.Name == "Disabled" || f.Name == "Instances" || f.Name == "Extends" {
+		return reflect.StructField{}, false
+	}
+	return f, true
+}
+
+// splitOverrideKey splits "NAME.field" at the last dot.
+func splitOverrideKey(key string) (name, field string, ok bool) {
+	i := strings.LastIndex(key, ".")
+	if i <= 0 || i == len(key)-1 {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// setOverride parses the text of a profile override into item.
+func setOverride(item *ProcessItem, key, text string) error {
+	sf, ok := overridableField(key)
+	if !ok {
+		return fmt.Errorf("%s cannot be set in a profile", key)
+	}
+	f := reflect.ValueOf(item).Elem().FieldByIndex(sf.Index)
+	if u, ok := f.Addr().Interface().(interface{ UnmarshalText([]byte) error }); ok {
+		return u.UnmarshalText([]byte(text))
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(text)
+	case reflect.Bool:
+		b, err := parseINIBool(text)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			return fmt.Errorf("expected integer, got %q", text)
+		}
+		f.SetInt(int64(n))
+	default:
+		return fmt.Errorf("%s cannot be set in a profile", key)
+	}
+	return nil
+}
+
+// ProfileNames returns the defined profiles in name order.
+func (cfg Config) ProfileNames() []string {
+	names := make([]string, 0, len(cfg.Profile))
+	for name := range cfg.Profile {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProfile returns the expanded config (see Expanded) with a profile
+// applied. An empty name only expands instances. Listing an entry with
+// instances enables all of them; NAME#N enables one. Overrides of an entry
+// may use the instance templates, e.g. CHROME.screen={{.Index}}.
+func (cfg Config) WithProfile(name string) (Config, error) {
+	out := cfg.Expanded()
+	if name == "" {
+		return out, nil
+	}
+	expanded := out.Process
+	p, ok := cfg.Profile[name]
+	if !ok || p == nil {
+		return Config{}, fmt.Errorf("unknown profile %q", name)
+	}
+	listed := make(map[string]bool, len(p.Processes))
+	for _, n := range p.Processes {
+		listed[n] = true
+	}
+	out.Process = make(map[string]*ProcessItem, len(out.Process))
+	for _, procName := range sortedKeys(expanded) {
+		src := expanded[procName]
+		item := *src
+		base, index := procName, 0
+		if src.Instances > 0 {
+			base, index = BaseName(procName)
+		}
+		item.Disabled = !listed[procName] && !(index > 0 && listed[base])
+		keys := []string{base}
+		if procName != base {
+			keys = append(keys, procName)
+		}
+		for _, key := range keys {
+			if key == "" {
+				continue
+			}
+			for _, field := range sortedRawKeys(p.Overrides[key]) {
+				text := p.Overrides[key][field]
+				if index > 0 {
+					vars := InstanceVars{Name: base, Index: index, Count: src.Instances, Screen: item.Screen.N}
+					var err error
+					if text, err = renderInstance(text, vars); err != nil {
+						return Config{}, fmt.Errorf("profile %q: %s.%s: %w", name, key, field, err)
+					}
+				}
+				if err := setOverride(&item, field, text); err != nil {
+					return Config{}, fmt.Errorf("profile %q: %s.%s: %w", name, key, field, err)
+				}
+			}
+		}
+		out.Process[procName] = &item
+	}
+	return out, nil
+}
+
+// iniProfiles reads [profile "NAME"] sections and blanks them out so gcfg,
+// which only knows fixed keys, does not see them. Values are taken like
+// [vars]: quoted ones are unquoted, others used literally.
+func iniProfiles(doc *iniDoc) map[string]*Profile {
+	refs := doc.sections("profile")
+	if len(refs) == 0 {
+		return nil
+	}
+	profiles := make(map[string]*Profile, len(refs))
+	for _, ref := range refs {
+		p := profiles[ref.Sub]
+		if p == nil {
+			p = &Profile{}
+			profiles[ref.Sub] = p
+		}
+		start, end := doc.sectionRange(ref)
+		for i := start; i < end; i++ {
+			if l := doc.lines[i]; l.kind == iniKey {
+				p.set(l.key, l.varValue())
+			}
+			doc.lines[i] = iniLine{}
+		}
+	}
+	return profiles
+}
+
+// set applies one key of a profile section.
+func (p *Profile) set(key, value string) {
+	if strings.EqualFold(key, profileKey) {
+		p.Processes = parseNameList(value)
+		return
+	}
+	name, field, ok := splitOverrideKey(key)
+	if !ok {
+		// Kept so Validate can report it.
+		name, field = "", key
+	}
+	if p.Overrides == nil {
+		p.Overrides = make(map[string]map[string]string)
+	}
+	if p.Overrides[name] == nil {
+		p.Overrides[name] = make(map[string]string)
+	}
+	if f, ok := overridableField(field); ok {
+		field = fieldKey(f.Name)
+	}
+	p.Overrides[name][field] = value
+}
+
+func parseNameList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// overrideKey is one NAME.field key of a profile. Name is empty for keys
+// without a dot, which Validate reports.
+type overrideKey struct {
+	Name, Field string
+}
+
+func (k overrideKey) String() string {
+	if k.Name == "" {
+		return k.Field
+	}
+	return k.Name + "." + k.Field
+}
+
+// overrideKeys returns the override keys of a profile in sorted order.
+func (p *Profile) overrideKeys() []overrideKey {
+	var keys []overrideKey
+	for name, fields := range p.Overrides {
+		for field := range fields {
+			keys = append(keys, overrideKey{Name: name, Field: field})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
+
+// patchProfiles syncs [profile] sections with profiles, keeping untouched
+// lines and comments.
+func patchProfiles(doc *iniDoc, profiles []ProfileDTO) {
+	wanted := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		wanted[strings.TrimSpace(p.Name)] = true
+	}
+	for _, ref := range doc.sections("profile") {
+		if !wanted[ref.Sub] {
+			doc.removeSection(ref)
+		}
+	}
+	for _, dto := range profiles {
+		name := strings.TrimSpace(dto.Name)
+		if name == "" {
+			continue
+		}
+		ref := iniSectionRef{Name: "profile", Sub: name}
+		p := dto.profile()
+		keep := map[string]bool{profileKey: true}
+		for _, key := range p.overrideKeys() {
+			keep[strings.ToLower(key.String())] = true
+		}
+		if start, end := doc.sectionRange(ref); start >= 0 {
+			for i := end - 1; i > start; i-- {
+				if l := doc.lines[i]; l.kind == iniKey && !keep[strings.ToLower(l.key)] {
+					doc.lines = append(doc.lines[:i], doc.lines[i+1:]...)
+				}
+			}
+		}
+		list := strings.Join(p.Processes, ", ")
+		if i := doc.findKey(ref, profileKey); i < 0 || !slices.Equal(parseNameList(doc.lines[i].varValue()), p.Processes) {
+			doc.set(ref, profileKey, quoteIfNeeded(list))
+		}
+		for _, key := range p.overrideKeys() {
+			value := p.Overrides[key.Name][key.Field]
+			if i := doc.findKey(ref, key.String()); i >= 0 && doc.lines[i].varValue() == value {
+				continue
+			}
+			doc.set(ref, key.String(), quoteIfNeeded(value))
+		}
+	}
+}
+
+// profilesFromMap decodes the profile table of a JSON, YAML or TOML file.
+// Each profile uses the same keys as INI: processes (a list or a
+// comma-separated string) and NAME.field overrides.
+func profilesFromMap(val any) (map[string]*Profile, error) {
+	raw, ok := asMap(val)
+	if !ok {
+		return nil, fmt.Errorf("profile: expected a table of named entries")
+	}
+	profiles := make(map[string]*Profile, len(raw))
+	for name, pv := range raw {
+		m, ok := asMap(pv)
+		if !ok {
+			return nil, fmt.Errorf("profile %q: expected a table", name)
+		}
+		p := &Profile{}
+		for key, v := range m {
+			switch x := v.(type) {
+			case []any:
+				parts := make([]string, 0, len(x))
+				for _, item := range x {
+					parts = append(parts, fmt.Sprint(item))
+				}
+				p.set(key, strings.Join(parts, ","))
+			case string:
+				p.set(key, x)
+			default:
+				p.set(key, fmt.Sprint(x))
+			}
+		}
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+func profilesToMap(profiles map[string]*Profile) map[string]any {
+	out := make(map[string]any, len(profiles))
+	for name, p := range profiles {
+		m := map[string]any{profileKey: append([]string{}, p.Processes...)}
+		for _, key := range p.overrideKeys() {
+			m[key.String()] = p.Overrides[key.Name][key.Field]
+		}
+		out[name] = m
+	}
+	return out
+}
+
+// validateProfiles reports unknown processes and fields in profiles and
+// overrides whose values do not parse.
+func validateProfiles(cfg Config) Diagnostics {
+	var ds Diagnostics
+	known := cfg.knownProcess
+	for _, profileName := range cfg.ProfileNames() {
+		p := cfg.Profile[profileName]
+		section := iniSectionRef{Name: "profile", Sub: profileName}.header()
+		failed := false
+		add := func(sev Severity, key, msg string) {
+			failed = failed || sev == SeverityError
+			ds = append(ds, Diagnostic{Severity: sev, Section: section, Key: key, Message: msg})
+		}
+		if len(p.Processes) == 0 {
+			add(SeverityWarning, profileKey, "no processes listed, the profile disables everything")
+		}
+		for _, name := range p.Processes {
+			if !known(name) {
+				add(SeverityWarning, profileKey, fmt.Sprintf("unknown process %q", name))
+			}
+		}
+		for _, key := range p.overrideKeys() {
+			if key.Name == "" {
+				add(SeverityError, key.String(), "unknown key, expected processes or NAME.field")
+				continue
+			}
+			if !known(key.Name) {
+				add(SeverityWarning, key.String(), fmt.Sprintf("unknown process %q", key.Name))
+				continue
+			}
+			value := p.Overrides[key.Name][key.Field]
+			if strings.Contains(value, "{{") {
+				if _, err := parseInstanceTemplate(value); err != nil {
+					add(SeverityError, key.String(), err.Error())
+				}
+				continue
+			}
+			var item ProcessItem
+			if err := setOverride(&item, key.Field, value); err != nil {
+				add(SeverityError, key.String(), err.Error())
+			}
+		}
+		if _, err := cfg.WithProfile(profileName); err != nil && !failed {
+			add(SeverityError, "", err.Error())
+		}
+	}
+	return ds
+}
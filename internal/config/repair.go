@@ -6,65 +6,79 @@ import (
 	"strings"
 )
 
+// repairKeys are the string keys whose values usually hold Windows paths.
+var repairKeys = map[string]bool{
+	"path":                true,
+	"process":             true,
+	"command":             true,
+	"checkprocess":        true,
+	"checkcmdline":        true,
+	"checkcmdlineexclude": true,
+	"args":                true,
+	"errorwindowtitles":   true,
+}
+
 // RepairFile tries to fix common Windows path escaping issues by quoting values.
+// Only the affected values are rewritten; non-INI files are left alone.
 // Returns true if file was modified.
 func RepairFile(path string) (bool, error) {
+	if DetectFormat(path) != FormatINI {
+		return false, nil
+	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return false, err
 	}
-	lines := strings.Split(string(data), "\n")
-	changed := false
+	doc := parseINI(data)
+	if !repairDoc(doc) {
+		return false, nil
+	}
+	if err := backupFile(path, DefaultBackupCount); err != nil {
+		return false, err
+	}
+	if err := WriteFileAtomic(path, doc.bytes()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-	for i, line := range lines {
-		trim := strings.TrimSpace(line)
-		if trim == "" || strings.HasPrefix(trim, ";") || strings.HasPrefix(trim, "#") || strings.HasPrefix(trim, "[") {
-			continue
-		}
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
+// repairDoc quotes path-like values in doc and reports whether it changed.
+func repairDoc(doc *iniDoc) bool {
+	changed := false
+	for i, line := range doc.lines {
+		if line.kind != iniKey || !repairKeys[strings.ToLower(line.key)] {
 			continue
 		}
-		key := strings.TrimSpace(parts[0])
-		val := strings.TrimSpace(parts[1])
-		if val == "" {
+		val, ok := line.rawValue()
+		if !ok || val == "" {
 			continue
 		}
 		// Quote values for known keys if they include backslashes/spaces/commas.
-		if key == "path" || key == "process" || key == "command" || key == "checkProcess" ||
-			key == "checkCmdline" || key == "checkCmdlineExclude" || key == "args" || key == "errorWindowTitles" {
-			quoted := val
-			if strings.HasPrefix(quoted, `"`) && strings.HasSuffix(quoted, `"`) {
-				inner := strings.TrimSuffix(strings.TrimPrefix(quoted, `"`), `"`)
-				inner = escapeBackslashes(inner)
-				quoted = `"` + strings.ReplaceAll(inner, `"`, `\"`) + `"`
-				if quoted != val {
-					lines[i] = parts[0] + "=" + quoted
-					changed = true
-				}
-				continue
-			}
-			if strings.ContainsAny(val, `\ ,`) {
-				val = escapeBackslashes(val)
-				val = `"` + strings.ReplaceAll(val, `"`, `\"`) + `"`
-				lines[i] = parts[0] + "=" + val
+		if strings.HasPrefix(val, `"`) && strings.HasSuffix(val, `"`) && len(val) > 1 {
+			inner := strings.TrimSuffix(strings.TrimPrefix(val, `"`), `"`)
+			inner = escapeBackslashes(inner)
+			quoted := `"` + strings.ReplaceAll(inner, `"`, `\"`) + `"`
+			if quoted != val {
+				doc.lines[i] = line.withValue(quoted)
 				changed = true
 			}
+			continue
+		}
+		if strings.ContainsAny(val, `\ ,`) {
+			val = escapeBackslashes(val)
+			val = `"` + strings.ReplaceAll(val, `"`, `\"`) + `"`
+			doc.lines[i] = line.withValue(val)
+			changed = true
 		}
 	}
-
-	if !changed {
-		return false, nil
-	}
-	if err := atomicWrite(path, []byte(strings.Join(lines, "\n"))); err != nil {
-		return false, err
-	}
-	return true, nil
+	return changed
 }
 
-func atomicWrite(path string, data []byte) error {
+// WriteFileAtomic replaces path with data through a temporary file in the
+// same folder, so readers never see a partly written file.
+func WriteFileAtomic(path string, data []byte) error {
 	dir := filepath.Dir(path)
-	tmp, err := os.CreateTemp(dir, "*.ini.tmp")
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
 	if err != nil {
 		return err
 	}
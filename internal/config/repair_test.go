@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestLoadReadOnly(t *testing.T) {
+	path := writeConfig(t, map[string]string{
+		"config.ini":     "[settings]\ncheckTiming=1s\nrestartTiming=5s\ninclude=conf.d/*.ini\n\n[process \"UE\"]\ntype=exe\npath=C:\\Games\\UE\nprocess=Game.exe\n",
+		"conf.d/web.ini": "[process \"WEB\"]\ntype=cmd\ncommand=start.cmd\ncheckProcess=node.exe\n",
+	})
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, repaired, err := LoadReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(repaired, []string{path}) {
+		t.Errorf("repaired = %q, want the main file", repaired)
+	}
+	if got := cfg.Process["UE"].Path; got != `C:\Games\UE` {
+		t.Errorf("path = %q, want the repaired value", got)
+	}
+	if cfg.Process["WEB"] == nil {
+		t.Error("included process missing")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Errorf("file rewritten:\n%s", after)
+	}
+	if list, _ := ListBackups(path); len(list) != 0 {
+		t.Errorf("backups made: %v", list)
+	}
+
+	// Load writes the same repair.
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Process["UE"].Path != cfg.Process["UE"].Path {
+		t.Errorf("Load path = %q, LoadReadOnly %q", loaded.Process["UE"].Path, cfg.Process["UE"].Path)
+	}
+	if after, _ := os.ReadFile(path); string(after) == string(before) {
+		t.Error("Load did not repair the file")
+	}
+	if _, repaired, err := LoadReadOnly(path); err != nil || len(repaired) != 0 {
+		t.Errorf("after the repair: repaired %q, err %v", repaired, err)
+	}
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"goRunFiles/internal/schedule"
+)
+
+// Schedule actions.
+const (
+	ActionRestartAll     = "restart-all"
+	ActionStart          = "start"
+	ActionStop           = "stop"
+	ActionRestart        = "restart"
+	ActionRollingRestart = "rolling-restart" // target processes, or all when empty, a batch at a time
+	ActionMaintenance    = "maintenance"     // target processes, or all when empty, left alone for duration
+)
+
+// LegacyScheduleName names the schedule made from the old autoRestart and
+// autoRestartTime settings.
+const LegacyScheduleName = "autoRestart"
+
+// Schedule runs an action on a cron expression.
+//
+//	[schedule "nightly"]
+//	cron=30 4 * * Mon-Fri
+//	action=restart
+//	target=UE, CHROME
+//	timezone=Europe/Moscow
+//	catchUp=2h
+type Schedule struct {
+	Disabled bool
+	Cron     string   // see schedule.Parse
+	Action   string   // restart-all (default), start, stop, restart, rolling-restart or maintenance
+	Target   string   // comma-separated processes for start, stop, restart, rolling-restart and maintenance
+	Timezone string   // IANA zone; the PC's local time when empty
+	CatchUp  Duration // how late a run missed while the PC was off may still be made up at startup
+	Duration Duration // how long a maintenance lasts
+}
+
+// ScheduleEntry is a named schedule, see Config.Schedules.
+type ScheduleEntry struct {
+	Name string
+	Schedule
+}
+
+// ActionName returns the action with the default applied.
+func (s Schedule) ActionName() string {
+	if a := strings.ToLower(strings.TrimSpace(s.Action)); a != "" {
+		return a
+	}
+	return ActionRestartAll
+}
+
+// Targets returns the process names of Target.
+func (s Schedule) Targets() []string {
+	return parseNameList(s.Target)
+}
+
+// Parse parses the cron expression and loads the time zone.
+func (s Schedule) Parse() (*schedule.Cron, *time.Location, error) {
+	c, err := schedule.Parse(s.Cron)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cron: %w", err)
+	}
+	loc := time.Local
+	if tz := strings.TrimSpace(s.Timezone); tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, nil, fmt.Errorf("timezone: %w", err)
+		}
+	}
+	return c, loc, nil
+}
+
+// Window parses RunWindow; it is nil when the process may run at any time.
+func (p *ProcessItem) Window() (*schedule.Window, error) {
+	if strings.TrimSpace(p.RunWindow) == "" {
+		return nil, nil
+	}
+	return schedule.ParseWindow(p.RunWindow)
+}
+
+// RecycleTimes parses RestartAt; it is nil when unset.
+func (p *ProcessItem) RecycleTimes() (*schedule.Times, error) {
+	if strings.TrimSpace(p.RestartAt) == "" {
+		return nil, nil
+	}
+	return schedule.ParseTimes(p.RestartAt)
+}
+
+// JobCron parses the Cron of a job; it is nil when the job only runs on
+// demand.
+func (p *ProcessItem) JobCron() (*schedule.Cron, error) {
+	if strings.TrimSpace(p.Cron) == "" {
+		return nil, nil
+	}
+	return schedule.Parse(p.Cron)
+}
+
+// Schedules returns the enabled schedules in name order. A daily restart
+// set with the legacy autoRestart and autoRestartTime settings is included
+// as LegacyScheduleName unless a schedule of that name exists.
+func (cfg Config) Schedules() []ScheduleEntry {
+	var out []ScheduleEntry
+	for _, name := range sortedKeys(cfg.Schedule) {
+		if s := cfg.Schedule[name]; s != nil && !s.Disabled {
+			out = append(out, ScheduleEntry{Name: name, Schedule: *s})
+		}
+	}
+	if _, taken := cfg.Schedule[LegacyScheduleName]; !taken {
+		if s, ok := legacySchedule(cfg.Settings); ok {
+			out = append(out, ScheduleEntry{Name: LegacyScheduleName, Schedule: s})
+		}
+	}
+	return out
+}
+
+// legacySchedule turns autoRestartTime=HH:MM[:SS] into a daily cron.
+func legacySchedule(s Settings) (Schedule, bool) {
+	if !s.AutoRestart || validateAutoRestartTime(s.AutoRestartTime) != nil {
+		return Schedule{}, false
+	}
+	parts := strings.Split(strings.TrimSpace(s.AutoRestartTime), ":")
+	sec := "0"
+	if len(parts) == 3 {
+		sec = strings.TrimSpace(parts[2])
+	}
+	num := func(s string) string {
+		n, _ := strconv.Atoi(strings.TrimSpace(s))
+		return strconv.Itoa(n)
+	}
+	return Schedule{
+		Cron:   fmt.Sprintf("%s %s %s * * *", num(sec), num(parts[1]), num(parts[0])),
+		Action: ActionRestartAll,
+	}, true
+}
+
+// knownProcess reports whether name is an entry or one of its instances.
+func (cfg Config) knownProcess(name string) bool {
+	if _, ok := cfg.Process[name]; ok {
+		return true
+	}
+	base, index := BaseName(name)
+	item, ok := cfg.Process[base]
+	return ok && index <= item.Instances
+}
+
+// validateSchedules reports schedules whose cron, time zone, action or
+// targets are wrong.
+func validateSchedules(cfg Config) Diagnostics {
+	var ds Diagnostics
+	for _, name := range sortedKeys(cfg.Schedule) {
+		s := cfg.Schedule[name]
+		section := iniSectionRef{Name: "schedule", Sub: name}.header()
+		add := func(sev Severity, key, msg string) {
+			ds = append(ds, Diagnostic{Severity: sev, Section: section, Key: key, Message: msg})
+		}
+		if _, err := schedule.Parse(s.Cron); err != nil {
+			add(SeverityError, "cron", err.Error())
+		}
+		if tz := strings.TrimSpace(s.Timezone); tz != "" {
+			if _, err := time.LoadLocation(tz); err != nil {
+				add(SeverityError, "timezone", err.Error())
+			}
+		}
+		if s.CatchUp.Duration < 0 {
+			add(SeverityError, "catchUp", "must not be negative")
+		}
+		if s.ActionName() == ActionMaintenance {
+			if s.Duration.Duration <= 0 {
+				add(SeverityError, "duration", "is required for maintenance")
+			}
+		} else if s.Duration.Duration != 0 {
+			add(SeverityWarning, "duration", "only used by maintenance")
+		}
+		switch action := s.ActionName(); action {
+		case ActionRestartAll:
+			if strings.TrimSpace(s.Target) != "" {
+				add(SeverityWarning, "target", "ignored by restart-all")
+			}
+		case ActionStart, ActionStop, ActionRestart, ActionRollingRestart, ActionMaintenance:
+			if len(s.Targets()) == 0 && action != ActionRollingRestart && action != ActionMaintenance {
+				add(SeverityError, "target", "is required for "+action)
+			}
+			for _, t := range s.Targets() {
+				if !cfg.knownProcess(t) {
+					add(SeverityWarning, "target", fmt.Sprintf("unknown process %q", t))
+				}
+			}
+		default:
+			add(SeverityError, "action", fmt.Sprintf("unknown action %q (expected restart-all, start, stop, restart, rolling-restart or maintenance)", s.Action))
+		}
+	}
+	if cfg.Settings.AutoRestart {
+		msg := fmt.Sprintf("deprecated, use a [schedule] section; runs as schedule %q", LegacyScheduleName)
+		if _, taken := cfg.Schedule[LegacyScheduleName]; taken {
+			msg = fmt.Sprintf("ignored, schedule %q is defined", LegacyScheduleName)
+		}
+		ds = append(ds, Diagnostic{Severity: SeverityWarning, Section: "[settings]", Key: "autoRestart", Message: msg})
+	}
+	return ds
+}
+
+// patchSchedules syncs [schedule] sections with schedules.
+func patchSchedules(doc *iniDoc, schedules []ScheduleDTO) {
+	wanted := make(map[string]bool, len(schedules))
+	for _, s := range schedules {
+		wanted[strings.TrimSpace(s.Name)] = true
+	}
+	for _, ref := range doc.sections("schedule") {
+		if !wanted[ref.Sub] {
+			doc.removeSection(ref)
+		}
+	}
+	for _, s := range schedules {
+		name := strings.TrimSpace(s.Name)
+		if name == "" {
+			continue
+		}
+		patchSection(doc, iniSectionRef{Name: "schedule", Sub: name}, scheduleFields(s))
+	}
+}
+
+func scheduleFields(s ScheduleDTO) []iniField {
+	return []iniField{
+		{key: "disabled", kind: iniBool, value: strconv.FormatBool(s.Disabled), zero: !s.Disabled},
+		{key: "cron", value: s.Cron},
+		{key: "action", value: s.Action, omit: strings.TrimSpace(s.Action) == ""},
+		{key: "target", value: s.Target, omit: strings.TrimSpace(s.Target) == ""},
+		{key: "timezone", value: s.Timezone, omit: strings.TrimSpace(s.Timezone) == ""},
+		{key: "catchUp", kind: iniDuration, value: s.CatchUp, omit: strings.TrimSpace(s.CatchUp) == ""},
+		{key: "duration", kind: iniDuration, value: s.Duration, omit: strings.TrimSpace(s.Duration) == ""},
+	}
+}
+
+// schedulesFromMap decodes the schedule table of a JSON, YAML or TOML file.
+func schedulesFromMap(val any) (map[string]*Schedule, error) {
+	raw, ok := asMap(val)
+	if !ok {
+		return nil, fmt.Errorf("schedule: expected a table of named entries")
+	}
+	out := make(map[string]*Schedule, len(raw))
+	for name, sv := range raw {
+		m, ok := asMap(sv)
+		if !ok {
+			return nil, fmt.Errorf("schedule %q: expected a table", name)
+		}
+		s := &Schedule{}
+		if err := assignFields(s, m, fmt.Sprintf("schedule %q", name)); err != nil {
+			return nil, err
+		}
+		out[name] = s
+	}
+	return out, nil
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// sectionKeys records which keys each [process] and [template] section sets
+// explicitly. Keys are lower-case. Fields not listed are inherited through
+// extends.
+type sectionKeys map[iniSectionRef]map[string]bool
+
+func (s sectionKeys) add(ref iniSectionRef, key string) {
+	if s[ref] == nil {
+		s[ref] = make(map[string]bool)
+	}
+	s[ref][strings.ToLower(key)] = true
+}
+
+// iniSectionKeys collects explicitly set keys from an INI document.
+func iniSectionKeys(doc *iniDoc) sectionKeys {
+	set := make(sectionKeys)
+	var ref iniSectionRef
+	for _, l := range doc.lines {
+		switch l.kind {
+		case iniSection:
+			ref = iniSectionRef{Name: l.section, Sub: l.sub}
+		case iniKey:
+			if ref.Name == "process" || ref.Name == "template" {
+				set.add(ref, l.key)
+			}
+		}
+	}
+	return set
+}
+
+// InheritedFrom returns the template a field value came from, or "" if the
+// process sets it itself. key is the DTO field name, e.g. "checkCmdline".
+func (p *ProcessItem) InheritedFrom(key string) string {
+	return p.inherited[key]
+}
+
+// inheritableFields are the ProcessItem fields a template can provide.
+func inheritableFields() []reflect.StructField {
+	t := reflect.TypeOf(ProcessItem{})
+	out := make([]reflect.StructField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || isRuntimeField(f.Name) || f.Name == "Extends" {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// fieldKey is the DTO/JSON spelling of a field name.
+func fieldKey(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// resolveTemplates fills the fields a process or template does not set
+// itself from the template named by extends. Templates may extend other
+// templates; cycles and unknown names are errors.
+func (cfg *Config) resolveTemplates(set sectionKeys) error {
+	done := make(map[string]bool, len(cfg.Template))
+	visiting := make(map[string]bool)
+
+	var resolveTemplate func(name string) error
+	resolveTemplate = func(name string) error {
+		if done[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("template %q: extends cycle", name)
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+		item := cfg.Template[name]
+		if err := cfg.inherit(item, iniSectionRef{Name: "template", Sub: name}, set, resolveTemplate); err != nil {
+			return fmt.Errorf("template %q: %w", name, err)
+		}
+		done[name] = true
+		return nil
+	}
+
+	for _, name := range sortedKeys(cfg.Template) {
+		if err := resolveTemplate(name); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(cfg.Process) {
+		if err := cfg.inherit(cfg.Process[name], iniSectionRef{Name: "process", Sub: name}, set, resolveTemplate); err != nil {
+			return fmt.Errorf("process %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) inherit(item *ProcessItem, ref iniSectionRef, set sectionKeys, resolve func(string) error) error {
+	item.inherited, item.overridden = nil, nil
+	parentName := strings.TrimSpace(item.Extends)
+	if parentName == "" {
+		return nil
+	}
+	parent, ok := cfg.Template[parentName]
+	if !ok || parent == nil {
+		return fmt.Errorf("unknown template %q", parentName)
+	}
+	if err := resolve(parentName); err != nil {
+		return err
+	}
+	own := set[ref]
+	parentOwn := set[iniSectionRef{Name: "template", Sub: parentName}]
+	dst := reflect.ValueOf(item).Elem()
+	src := reflect.ValueOf(parent).Elem()
+	for _, f := range inheritableFields() {
+		key := fieldKey(f.Name)
+		origin := parent.inherited[key]
+		if origin == "" && parentOwn[strings.ToLower(f.Name)] {
+			origin = parentName
+		}
+		if own[strings.ToLower(f.Name)] {
+			if origin != "" {
+				setKey(&item.overridden, key, origin)
+			}
+			continue
+		}
+		if origin == "" {
+			continue
+		}
+		dst.FieldByIndex(f.Index).Set(src.FieldByIndex(f.Index))
+		setKey(&item.inherited, key, origin)
+	}
+	return nil
+}
+
+func setKey(m *map[string]string, key, val string) {
+	if *m == nil {
+		*m = make(map[string]string)
+	}
+	(*m)[key] = val
+}
+
+// dtoSectionKeys marks the fields a DTO entry sets itself, mirroring what
+// WriteFromDTO keeps in the file: overridden fields always, inherited ones
+// never, otherwise any non-blank value or true flag. Clearing an overridden
+// value in the GUI thus falls back to the template.
+func dtoSectionKeys(ref iniSectionRef, p ProcessDTO, item *ProcessItem, set sectionKeys) {
+	v := reflect.ValueOf(item).Elem()
+	extends := strings.TrimSpace(p.Extends) != ""
+	for _, f := range inheritableFields() {
+		key := fieldKey(f.Name)
+		fv := v.FieldByIndex(f.Index)
+		if _, ok := p.Inherited[key]; ok && extends {
+			continue
+		}
+		_, overridden := p.Overridden[key]
+		switch {
+		case fv.Kind() == reflect.Bool && !fv.Bool() && !overridden:
+			continue
+		case fv.Kind() != reflect.Bool && isBlankField(fv):
+			continue
+		}
+		set.add(ref, f.Name)
+	}
+}
+
+func isBlankField(v reflect.Value) bool {
+	if v.Type() == durationType {
+		return v.Interface().(Duration).Duration == 0
+	}
+	if v.Type() == screenType {
+		return v.Interface().(ScreenSpec) == ScreenSpec{}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Int:
+		return v.Int() == 0
+	case reflect.Float64:
+		return v.Float() == 0
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveTemplates(t *testing.T) {
+	const ini = `[settings]
+checkTiming=1s
+restartTiming=5s
+
+[template "base"]
+type=cmd
+path=C:/web
+minUptime=10s
+disabled=true
+
+[template "nuxt"]
+extends=base
+command="npm run dev"
+checkProcess=node.exe
+
+[process "SHOP"]
+extends=nuxt
+checkCmdline=shop
+disabled=false
+
+[process "KIOSK"]
+extends=base
+path=D:/kiosk
+command=kiosk.cmd
+`
+	cfg, err := loadINI([]byte(ini))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		process    string
+		get        func(*ProcessItem) any
+		want       any
+		inherited  map[string]string
+		overridden map[string]string
+	}{
+		{
+			process: "SHOP",
+			get: func(p *ProcessItem) any {
+				return []any{p.Type, p.Path, p.Command, p.CheckProcess, p.CheckCmdline, p.MinUptime.Duration.String(), p.Disabled}
+			},
+			want:       []any{"cmd", "C:/web", "npm run dev", "node.exe", "shop", "10s", false},
+			inherited:  map[string]string{"type": "base", "path": "base", "minUptime": "base", "command": "nuxt", "checkProcess": "nuxt"},
+			overridden: map[string]string{"disabled": "base"},
+		},
+		{
+			process: "KIOSK",
+			get: func(p *ProcessItem) any {
+				return []any{p.Type, p.Path, p.Command, p.CheckProcess, p.Disabled}
+			},
+			want:       []any{"cmd", "D:/kiosk", "kiosk.cmd", "", true},
+			inherited:  map[string]string{"type": "base", "minUptime": "base", "disabled": "base"},
+			overridden: map[string]string{"path": "base"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.process, func(t *testing.T) {
+			p := cfg.Process[tt.process]
+			if got := tt.get(p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(p.inherited, tt.inherited) {
+				t.Errorf("inherited = %v, want %v", p.inherited, tt.inherited)
+			}
+			if !reflect.DeepEqual(p.overridden, tt.overridden) {
+				t.Errorf("overridden = %v, want %v", p.overridden, tt.overridden)
+			}
+			for key, from := range tt.inherited {
+				if got := p.InheritedFrom(key); got != from {
+					t.Errorf("InheritedFrom(%q) = %q, want %q", key, got, from)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveTemplatesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ini  string
+		want string
+	}{
+		{"unknown template", "[process \"A\"]\nextends=nope\ntype=exe\n", `process "A": unknown template "nope"`},
+		{"cycle", "[template \"a\"]\nextends=b\n[template \"b\"]\nextends=a\n", "extends cycle"},
+		{"self", "[template \"a\"]\nextends=a\n[process \"A\"]\nextends=a\n", `template "a": extends cycle`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadINI([]byte("[settings]\ncheckTiming=1s\nrestartTiming=5s\n" + tt.ini))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,604 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity of a validation diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is one validation problem. Line is 1-based and 0 when unknown.
+// File is the included file the line belongs to, "" for the main config.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Section  string   `json:"section"`
+	Process  string   `json:"process"`
+	Key      string   `json:"key"`
+	Line     int      `json:"line"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	if d.File != "" {
+		b.WriteString(d.File + ": ")
+	}
+	if d.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", d.Line)
+	}
+	fmt.Fprintf(&b, "%s: ", d.Severity)
+	if d.Section != "" {
+		b.WriteString(d.Section + " ")
+	}
+	if d.Key != "" {
+		b.WriteString(d.Key + ": ")
+	}
+	b.WriteString(d.Message)
+	return b.String()
+}
+
+// Diagnostics is a list of validation problems.
+type Diagnostics []Diagnostic
+
+// HasErrors reports whether any diagnostic is an error.
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the errors joined into one error, or nil.
+func (ds Diagnostics) Err() error {
+	var msgs []string
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			msgs = append(msgs, d.String())
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(msgs, "; "))
+}
+
+// ValidateOptions tunes environment-dependent checks.
+type ValidateOptions struct {
+	// Screens is the number of connected monitors; 0 skips the screen check.
+	Screens int
+	// SkipFiles disables checks for missing paths and executables.
+	SkipFiles bool
+}
+
+// ValidateFile checks a config file and the files it includes and returns
+// every problem found, with line numbers for INI files. No file is modified.
+func ValidateFile(path string, opts ValidateOptions) Diagnostics {
+	main, doc, ds := validateSyntax(path, "")
+	if main == nil {
+		return ds
+	}
+	docs := map[string]*iniDoc{"": doc}
+	files, err := IncludedFiles(path, main.cfg.Settings.Include)
+	if err != nil {
+		ds = append(ds, Diagnostic{Severity: SeverityError, Section: "[settings]", Key: "include", Message: err.Error()})
+	}
+	for _, file := range files {
+		part, partDoc, partDs := validateSyntax(IncludePath(path, file), file)
+		ds = append(ds, partDs...)
+		if part == nil {
+			continue
+		}
+		docs[file] = partDoc
+		if err := main.merge(*part, file); err != nil {
+			ds = append(ds, Diagnostic{Severity: SeverityError, File: file, Message: strings.TrimPrefix(err.Error(), file+": ")})
+		}
+	}
+	cfg, err := main.resolve()
+	if err != nil {
+		return append(ds, Diagnostic{Severity: SeverityError, Message: err.Error()})
+	}
+	cfg.loadHolidays(path)
+	for _, d := range Validate(cfg, opts) {
+		if doc := docs[d.File]; doc != nil {
+			d.Line = doc.lineOf(d)
+		}
+		ds = append(ds, d)
+	}
+	if doc != nil {
+		if _, ok := doc.get(iniSectionRef{Name: "settings"}, "autoRestartOnExit"); !ok {
+			ds = append(ds, Diagnostic{
+				Severity: SeverityWarning,
+				Section:  "[settings]",
+				Key:      "autoRestartOnExit",
+				Message:  "not set, defaults to true",
+			})
+		}
+	}
+	sort.SliceStable(ds, func(i, j int) bool {
+		if ds[i].File != ds[j].File {
+			return ds[i].File < ds[j].File
+		}
+		return ds[i].Line > 0 && (ds[j].Line == 0 || ds[i].Line < ds[j].Line)
+	})
+	return ds
+}
+
+// validateSyntax decodes one file for ValidateFile. The INI document is
+// returned for line lookups; f is nil when the file cannot be decoded.
+func validateSyntax(path, file string) (f *configFile, doc *iniDoc, ds Diagnostics) {
+	fail := func(err error) (*configFile, *iniDoc, Diagnostics) {
+		return nil, nil, append(ds, Diagnostic{Severity: SeverityError, File: file, Message: err.Error()})
+	}
+	if DetectFormat(path) != FormatINI {
+		part, err := decodeFile(path)
+		if err != nil {
+			return fail(err)
+		}
+		return &part, nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fail(err)
+	}
+	doc = parseINI(data)
+	for _, d := range validateINISyntax(doc) {
+		d.File = file
+		ds = append(ds, d)
+	}
+	part, err := decodeINI(data)
+	if err != nil {
+		// Load repairs unquoted paths on disk; do the same in memory.
+		fixed := parseINI(data)
+		if repairDoc(fixed) {
+			part, err = decodeINI(fixed.bytes())
+		}
+	}
+	if err != nil {
+		if !ds.HasErrors() {
+			return fail(err)
+		}
+		return nil, nil, ds
+	}
+	return &part, doc, ds
+}
+
+// ValidateDTO checks a config edited in the GUI before it is saved.
+func ValidateDTO(dto ConfigDTO, opts ValidateOptions) Diagnostics {
+	cfg, err := FromDTO(dto)
+	if err != nil {
+		return Diagnostics{{Severity: SeverityError, Message: err.Error()}}
+	}
+	return Validate(cfg, opts)
+}
+
+// Validate checks a loaded config for semantic problems.
+func Validate(cfg Config, opts ValidateOptions) Diagnostics {
+	var ds Diagnostics
+	settingsErr := func(key, msg string) {
+		ds = append(ds, Diagnostic{Severity: SeverityError, Section: "[settings]", Key: key, Message: msg})
+	}
+	settingsWarn := func(key, msg string) {
+		ds = append(ds, Diagnostic{Severity: SeverityWarning, Section: "[settings]", Key: key, Message: msg})
+	}
+
+	s := cfg.Settings
+	if s.CheckTiming.Duration <= 0 {
+		settingsErr("checkTiming", "must be greater than zero")
+	}
+	if s.RestartTiming.Duration <= 0 {
+		settingsErr("restartTiming", "must be greater than zero")
+	}
+	if s.CheckTimingMax.Duration < 0 {
+		settingsErr("checkTimingMax", "must not be negative")
+	} else if s.CheckTimingMax.Duration > 0 && s.CheckTimingMax.Duration < s.CheckTiming.Duration {
+		settingsWarn("checkTimingMax", fmt.Sprintf("is below checkTiming %s and has no effect", s.CheckTiming.Duration))
+	}
+	if s.MetricsTiming.Duration < 0 {
+		settingsErr("metricsTiming", "must not be negative")
+	}
+	if s.BulkParallel < 0 {
+		settingsErr("bulkParallel", "must not be negative")
+	}
+	if s.BulkStagger.Duration < 0 {
+		settingsErr("bulkStagger", "must not be negative")
+	}
+	if s.RollingBatch < 0 {
+		settingsErr("rollingBatch", "must not be negative")
+	}
+	if s.RollingTimeout.Duration < 0 {
+		settingsErr("rollingTimeout", "must not be negative")
+	}
+	if s.AutoRestart {
+		if err := validateAutoRestartTime(s.AutoRestartTime); err != nil {
+			settingsErr("autoRestartTime", err.Error())
+		}
+	}
+	if u := strings.ToUpper(strings.TrimSpace(s.NetUnit)); u != "" && u != "KB" && u != "MB" {
+		settingsWarn("netUnit", fmt.Sprintf("unknown unit %q, expected KB or MB", s.NetUnit))
+	}
+	if s.NetScale < 0 {
+		settingsErr("netScale", "must not be negative")
+	}
+	for key, raw := range s.raw {
+		for _, v := range cfg.missingVars(raw) {
+			settingsWarn(key, fmt.Sprintf("variable %s is not set and expands to empty", v))
+		}
+	}
+	if s.Profile != "" && cfg.Profile[s.Profile] == nil {
+		settingsErr("profile", fmt.Sprintf("unknown profile %q", s.Profile))
+	}
+	if err := checkControlAddr(s.ControlAddr); err != nil {
+		settingsErr("controlAddr", err.Error())
+	}
+	if _, err := cfg.Holidays(); err != nil {
+		settingsErr("holidays", err.Error())
+	}
+	ds = append(ds, validateProfiles(cfg)...)
+	ds = append(ds, validateSchedules(cfg)...)
+
+	names := make([]string, 0, len(cfg.Process))
+	for name := range cfg.Process {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := make(map[string]string)
+	for _, name := range names {
+		item := cfg.Process[name]
+		section := fmt.Sprintf("[process %q]", name)
+		add := func(sev Severity, key, msg string) {
+			ds = append(ds, Diagnostic{Severity: sev, File: item.source, Section: section, Process: name, Key: key, Message: msg})
+		}
+
+		for _, key := range sortedRawKeys(item.raw) {
+			for _, v := range cfg.missingVars(item.raw[key]) {
+				add(SeverityWarning, key, fmt.Sprintf("variable %s is not set and expands to empty", v))
+			}
+		}
+
+		switch item.Type {
+		case "":
+			add(SeverityError, "type", "is required (exe, cmd, bat or job)")
+		case TypeExe, TypeBat:
+			if strings.TrimSpace(item.Process) == "" {
+				add(SeverityError, "process", "is required for type "+item.Type)
+			}
+		case TypeJob:
+			if strings.TrimSpace(item.Command) == "" {
+				add(SeverityError, "command", "is required for type job")
+			}
+		case TypeCmd:
+			if strings.TrimSpace(item.Command) == "" {
+				add(SeverityError, "command", "is required for type cmd")
+			}
+			if strings.TrimSpace(item.CheckProcess) == "" && strings.TrimSpace(item.CheckCmdline) == "" {
+				add(SeverityWarning, "checkCmdline", "cmd without checkProcess/checkCmdline is tracked by PID only")
+			}
+		default:
+			add(SeverityError, "type", fmt.Sprintf("unknown type %q (expected exe, cmd, bat or job)", item.Type))
+		}
+
+		if !opts.SkipFiles && strings.TrimSpace(item.Path) != "" {
+			if _, err := os.Stat(item.Path); err != nil {
+				add(SeverityWarning, "path", "directory not found: "+item.Path)
+			} else if (item.Type == TypeExe || item.Type == TypeBat) && strings.TrimSpace(item.Process) != "" {
+				if _, err := os.Stat(filepath.Join(item.Path, item.Process)); err != nil {
+					add(SeverityWarning, "process", "file not found: "+filepath.Join(item.Path, item.Process))
+				}
+			}
+		}
+
+		checkScreen := func(n int, prefix string) {
+			if n < 0 {
+				add(SeverityError, "screen", prefix+"must not be negative")
+			} else if opts.Screens > 0 && n > opts.Screens {
+				add(SeverityWarning, "screen", fmt.Sprintf("%sscreen %d out of range, %d connected", prefix, n, opts.Screens))
+			}
+		}
+		instances := []*ProcessItem{item}
+		instanceNames := []string{name}
+		switch {
+		case item.Instances < 0:
+			add(SeverityError, "instances", "must not be negative")
+		case item.Instances == 0:
+			checkScreen(item.Screen.N, "")
+			if item.Screen.Expr != "" {
+				add(SeverityError, "screen", "templates need instances=N")
+			}
+			for key, val := range map[string]string{"args": item.Args, "checkCmdline": item.CheckCmdline} {
+				if strings.Contains(val, "{{") {
+					add(SeverityWarning, key, "template is used as is without instances=N")
+				}
+			}
+		default:
+			instances, instanceNames = nil, nil
+			for i := 1; i <= item.Instances; i++ {
+				prefix := fmt.Sprintf("instance %d: ", i)
+				if _, clash := cfg.Process[InstanceName(name, i)]; clash {
+					add(SeverityError, "instances", fmt.Sprintf("%q is also defined as a process", InstanceName(name, i)))
+				}
+				child, err := expandInstance(name, item, i)
+				if err != nil {
+					var ie *instanceError
+					if !errors.As(err, &ie) {
+						ie = &instanceError{Key: "instances", Msg: err.Error()}
+					}
+					add(SeverityError, ie.Key, prefix+ie.Msg)
+					break
+				}
+				checkScreen(child.Screen.N, prefix)
+				instances = append(instances, child)
+				instanceNames = append(instanceNames, InstanceName(name, i))
+			}
+		}
+		if item.DelayStartTime.Duration < 0 {
+			add(SeverityError, "delayStartTime", "must not be negative")
+		}
+		if item.HangTimeout.Duration < 0 {
+			add(SeverityError, "hangTimeout", "must not be negative")
+		}
+		if item.MonitorHang {
+			if item.HangTimeout.Duration == 0 {
+				add(SeverityWarning, "hangTimeout", "monitorHang is on but hangTimeout is not set")
+			}
+			if item.Type != TypeExe {
+				add(SeverityWarning, "monitorHang", "only supported for type exe")
+			}
+		}
+		if _, err := item.JobCron(); err != nil {
+			add(SeverityError, "cron", err.Error())
+		} else if strings.TrimSpace(item.Cron) != "" && item.Type != TypeJob {
+			add(SeverityWarning, "cron", "only used by type job")
+		}
+		if item.Timeout.Duration < 0 {
+			add(SeverityError, "timeout", "must not be negative")
+		} else if item.Timeout.Duration > 0 && item.Type != TypeJob {
+			add(SeverityWarning, "timeout", "only used by type job")
+		}
+		if item.StartTimeout.Duration < 0 {
+			add(SeverityError, "startTimeout", "must not be negative")
+		} else if item.StartTimeout.Duration > 0 && item.Type == TypeJob {
+			add(SeverityWarning, "startTimeout", "not used by type job")
+		}
+		if item.MinUptime.Duration < 0 {
+			add(SeverityError, "minUptime", "must not be negative")
+		} else if item.MinUptime.Duration > 0 && item.Type == TypeJob {
+			add(SeverityWarning, "minUptime", "not used by type job")
+		}
+		if item.MaxUptime.Duration < 0 {
+			add(SeverityError, "maxUptime", "must not be negative")
+		}
+		if _, err := item.RecycleTimes(); err != nil {
+			add(SeverityError, "restartAt", err.Error())
+		}
+		if w, err := item.Window(); err != nil {
+			add(SeverityError, "runWindow", err.Error())
+		} else if w != nil && w.UsesHolidays() && strings.TrimSpace(cfg.Settings.Holidays) == "" {
+			add(SeverityWarning, "runWindow", "holiday hours are unused without settings.holidays")
+		}
+
+		if item.Disabled || item.Type == TypeJob {
+			continue
+		}
+		for i, inst := range instances {
+			key := matcherKey(inst)
+			if key == "" {
+				continue
+			}
+			if other, ok := matchers[key]; ok {
+				add(SeverityWarning, "checkCmdline", fmt.Sprintf("%s matches the same processes as %q", instanceNames[i], other))
+			} else {
+				matchers[key] = instanceNames[i]
+			}
+		}
+	}
+	return ds
+}
+
+func sortedRawKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matcherKey describes how a process is found among running processes.
+// Two enabled items with the same key would claim the same PIDs.
+func matcherKey(item *ProcessItem) string {
+	var procs []string
+	src := item.CheckProcess
+	if strings.TrimSpace(src) == "" && item.Type != TypeCmd {
+		src = item.Process
+	}
+	for _, p := range strings.Split(src, ",") {
+		if p = strings.ToLower(strings.Trim(strings.TrimSpace(p), "\"'")); p != "" {
+			procs = append(procs, p)
+		}
+	}
+	cmdline := strings.ToLower(strings.TrimSpace(item.CheckCmdline))
+	if len(procs) == 0 && cmdline == "" {
+		return ""
+	}
+	sort.Strings(procs)
+	return strings.Join(procs, ",") + "|" + cmdline + "|" + strings.ToLower(strings.TrimSpace(item.CheckCmdlineExclude))
+}
+
+// knownSections lists section names accepted in INI files.
+var knownSections = map[string]reflect.Type{
+	"process":  reflect.TypeOf(ProcessItem{}),
+	"template": reflect.TypeOf(ProcessItem{}),
+	"settings": reflect.TypeOf(Settings{}),
+	"vars":     nil, // free-form NAME=value
+	"profile":  nil, // processes plus NAME.field overrides, see Profile
+	"schedule": reflect.TypeOf(Schedule{}),
+}
+
+// validateINISyntax reports unknown sections and keys and values that do not
+// parse as the field type, with line numbers.
+func validateINISyntax(doc *iniDoc) Diagnostics {
+	var ds Diagnostics
+	var fields reflect.Type
+	section := ""
+	process := ""
+	for i, l := range doc.lines {
+		line := i + 1
+		switch l.kind {
+		case iniSection:
+			section = strings.TrimSpace(l.text)
+			process = ""
+			t, ok := knownSections[l.section]
+			fields = t
+			if !ok {
+				ds = append(ds, Diagnostic{Severity: SeverityError, Section: section, Line: line, Message: "unknown section"})
+				continue
+			}
+			if l.section == "process" || l.section == "template" || l.section == "schedule" {
+				if l.section == "process" {
+					process = l.sub
+				}
+				if l.sub == "" {
+					ds = append(ds, Diagnostic{Severity: SeverityError, Section: section, Line: line, Message: fmt.Sprintf(`%s section needs a name: [%s "NAME"]`, l.section, l.section)})
+				}
+			}
+		case iniKey:
+			if fields == nil {
+				if section == "" {
+					ds = append(ds, Diagnostic{Severity: SeverityError, Key: l.key, Line: line, Message: "key outside of a section"})
+				}
+				continue
+			}
+			f, ok := fieldByKey(fields, l.key)
+			if !ok {
+				ds = append(ds, Diagnostic{Severity: SeverityError, Section: section, Process: process, Key: l.key, Line: line, Message: "unknown key"})
+				continue
+			}
+			if sev, msg := checkINIValue(f.Type, l); msg != "" {
+				ds = append(ds, Diagnostic{Severity: sev, Section: section, Process: process, Key: l.key, Line: line, Message: msg})
+			}
+		default:
+			trim := strings.TrimSpace(l.text)
+			if trim != "" && trim[0] == '[' {
+				ds = append(ds, Diagnostic{Severity: SeverityError, Line: line, Message: "malformed section header"})
+			}
+		}
+	}
+	return ds
+}
+
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if strings.EqualFold(f.Name, key) && f.IsExported() && !isRuntimeField(f.Name) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func checkINIValue(t reflect.Type, l iniLine) (Severity, string) {
+	raw, hasValue := l.rawValue()
+	val := strings.TrimSpace(l.value())
+	if t == durationType || t == screenType {
+		u := reflect.New(t).Interface().(interface{ UnmarshalText([]byte) error })
+		if err := u.UnmarshalText([]byte(val)); err != nil {
+			return SeverityError, err.Error()
+		}
+		return "", ""
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if hasValue {
+			if _, err := parseINIBool(val); err != nil {
+				return SeverityError, fmt.Sprintf("expected true/false, got %q", val)
+			}
+		}
+	case reflect.Int:
+		if _, err := strconv.Atoi(val); err != nil {
+			return SeverityError, fmt.Sprintf("expected integer, got %q", val)
+		}
+	case reflect.Float64:
+		if _, err := strconv.ParseFloat(val, 64); err != nil {
+			return SeverityError, fmt.Sprintf("expected number, got %q", val)
+		}
+	case reflect.String:
+		if !strings.HasPrefix(raw, `"`) && strings.Contains(raw, `\`) {
+			return SeverityWarning, `unquoted backslashes, the value will be quoted on load`
+		}
+	}
+	return "", ""
+}
+
+// lineOf returns the line of the key a diagnostic refers to, or of its
+// section header when the key is absent.
+func (d *iniDoc) lineOf(diag Diagnostic) int {
+	var ref iniSectionRef
+	switch {
+	case diag.Process != "":
+		ref = iniSectionRef{Name: "process", Sub: diag.Process}
+	case diag.Section == "[settings]":
+		ref = iniSectionRef{Name: "settings"}
+	case strings.HasPrefix(diag.Section, "[profile "), strings.HasPrefix(diag.Section, "[schedule "):
+		name, _, _ := strings.Cut(strings.TrimPrefix(diag.Section, "["), " ")
+		for _, r := range d.sections(name) {
+			if r.header() == diag.Section {
+				ref = r
+			}
+		}
+		if ref.Name == "" {
+			return 0
+		}
+	default:
+		return 0
+	}
+	if diag.Key != "" {
+		if i := d.findKey(ref, diag.Key); i >= 0 {
+			return i + 1
+		}
+	}
+	if start, _ := d.sectionRange(ref); start >= 0 {
+		return start + 1
+	}
+	return 0
+}
+
+// checkControlAddr accepts "" or "off" (no control channel) or a loopback
+// host:port; ctl commands must not be reachable from the network.
+func checkControlAddr(addr string) error {
+	addr = strings.TrimSpace(addr)
+	if addr == "" || strings.EqualFold(addr, "off") {
+		return nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("host must be a loopback address, got %q", host)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// diag is the part of a Diagnostic the tests compare.
+type diag struct {
+	sev  Severity
+	file string
+	key  string
+	line int
+	msg  string
+}
+
+func diags(ds Diagnostics) []diag {
+	out := make([]diag, len(ds))
+	for i, d := range ds {
+		out[i] = diag{d.Severity, d.File, d.Key, d.Line, d.Message}
+	}
+	return out
+}
+
+func writeConfig(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "config.ini")
+}
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		opts  ValidateOptions
+		want  []diag
+	}{
+		{
+			name: "unknown keys and sections",
+			files: map[string]string{"config.ini": `[settings]
+checkTiming=1s
+colour=red
+
+[process "A"]
+type=exe
+process=a.exe
+bogus=1
+
+[proces "B"]
+[process "C"
+`},
+			want: []diag{
+				{SeverityError, "", "colour", 3, "unknown key"},
+				{SeverityError, "", "bogus", 8, "unknown key"},
+				{SeverityError, "", "", 10, "unknown section"},
+				{SeverityError, "", "", 11, "malformed section header"},
+			},
+		},
+		{
+			name: "semantic problems on their lines",
+			files: map[string]string{"config.ini": `[settings]
+checkTiming=1s
+restartTiming=0s
+autoRestartOnExit=true
+
+[process "A"]
+type=exe
+process=a.exe
+screen=3
+
+[process "B"]
+type=exe
+process=A.EXE
+
+[process "C"]
+type=cmd
+command=run.cmd
+checkProcess=node.exe
+instances=2
+screen={{.Index}}x
+`},
+			opts: ValidateOptions{Screens: 2, SkipFiles: true},
+			want: []diag{
+				{SeverityError, "", "restartTiming", 3, "must be greater than zero"},
+				{SeverityWarning, "", "screen", 9, "screen 3 out of range, 2 connected"},
+				{SeverityWarning, "", "checkCmdline", 11, `B matches the same processes as "A"`},
+				{SeverityError, "", "screen", 20, `instance 1: "1x" is not a number`},
+			},
+		},
+		{
+			name: "screens not checked without a count",
+			files: map[string]string{"config.ini": `[settings]
+checkTiming=1s
+restartTiming=5s
+autoRestartOnExit=true
+
+[process "A"]
+type=exe
+process=a.exe
+screen=3
+`},
+			opts: ValidateOptions{SkipFiles: true},
+		},
+		{
+			name: "included file",
+			files: map[string]string{
+				"config.ini": `[settings]
+checkTiming=1s
+restartTiming=5s
+`,
+				"conf.d/web.ini": `; web front
+[process "WEB"]
+type=cmd
+checkProcess=node.exe
+`,
+			},
+			opts: ValidateOptions{SkipFiles: true},
+			want: []diag{
+				{SeverityWarning, "", "autoRestartOnExit", 0, "not set, defaults to true"},
+				{SeverityError, "conf.d/web.ini", "command", 2, "is required for type cmd"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diags(ValidateFile(writeConfig(t, tt.files), tt.opts))
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMatchers(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string // process sections without the header
+		dup  bool
+	}{
+		{"same exe", "type=exe\nprocess=a.exe", "type=exe\nprocess=A.exe", true},
+		{"checkProcess list order", "type=cmd\ncommand=x\ncheckProcess=node.exe, npm.exe", "type=cmd\ncommand=y\ncheckProcess=NPM.EXE,node.exe", true},
+		{"other cmdline", "type=cmd\ncommand=x\ncheckProcess=node.exe\ncheckCmdline=a.js", "type=cmd\ncommand=y\ncheckProcess=node.exe\ncheckCmdline=b.js", false},
+		{"other exclude", "type=cmd\ncommand=x\ncheckCmdline=a.js", "type=cmd\ncommand=y\ncheckCmdline=a.js\ncheckCmdlineExclude=--inspect", false},
+		{"disabled", "type=exe\nprocess=a.exe", "type=exe\nprocess=a.exe\ndisabled=true", false},
+		{"cmd tracked by PID", "type=cmd\ncommand=x", "type=cmd\ncommand=x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadINI([]byte("[settings]\ncheckTiming=1s\nrestartTiming=5s\n[process \"A\"]\n" + tt.a + "\n[process \"B\"]\n" + tt.b + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dup := false
+			for _, d := range Validate(cfg, ValidateOptions{SkipFiles: true}) {
+				if d.Key == "checkCmdline" && d.Severity == SeverityWarning && d.Process == "B" && d.Message == `B matches the same processes as "A"` {
+					dup = true
+				}
+			}
+			if dup != tt.dup {
+				t.Errorf("duplicate matcher reported: %v, want %v", dup, tt.dup)
+			}
+		})
+	}
+}
+
+func TestValidateScreens(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		screens int
+		want    []diag
+	}{
+		{"in range", "screen=2", 2, nil},
+		{"out of range", "screen=3", 2, []diag{{SeverityWarning, "", "screen", 0, "screen 3 out of range, 2 connected"}}},
+		{"unknown count", "screen=9", 0, nil},
+		{"instance out of range", "instances=2\nscreen={{.Index}}\ncheckCmdline=--slot {{.Index}}", 1, []diag{{SeverityWarning, "", "screen", 0, "instance 2: screen 2 out of range, 1 connected"}}},
+		{"template without instances", "screen={{.Index}}", 2, []diag{{SeverityError, "", "screen", 0, "templates need instances=N"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadINI([]byte("[settings]\ncheckTiming=1s\nrestartTiming=5s\n[process \"A\"]\ntype=exe\nprocess=a.exe\n" + tt.section + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := diags(Validate(cfg, ValidateOptions{Screens: tt.screens, SkipFiles: true}))
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
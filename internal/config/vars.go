@@ -0,0 +1,192 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// expandableFields are the ProcessItem fields where ${VAR} is expanded.
+var expandableFields = []string{"Path", "Process", "Command", "Args", "CheckCmdline"}
+
+// expandString replaces ${NAME} and ${NAME:-default} in s. Unknown names
+// without a default expand to "" and are returned in missing.
+func expandString(s string, lookup func(string) (string, bool)) (out string, missing []string) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			break
+		}
+		end := matchingBrace(s, start+2)
+		if end < 0 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:start])
+		expr := s[start+2 : end]
+		name, def, hasDef := strings.Cut(expr, ":-")
+		name = strings.TrimSpace(name)
+		val, ok := lookup(name)
+		switch {
+		case ok && val != "":
+			b.WriteString(val)
+		case hasDef:
+			d, miss := expandString(def, lookup)
+			b.WriteString(d)
+			missing = append(missing, miss...)
+		case !ok:
+			missing = append(missing, name)
+		}
+		s = s[end+1:]
+	}
+	return b.String(), missing
+}
+
+// matchingBrace returns the index of the "}" closing a "${" whose body
+// starts at i, allowing nested ${...} in defaults.
+func matchingBrace(s string, i int) int {
+	depth := 1
+	for ; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// varLookup resolves names against [vars] first and the environment second.
+// Vars may reference other vars and environment variables.
+func (cfg *Config) varLookup() func(string) (string, bool) {
+	resolved := make(map[string]string, len(cfg.Vars))
+	visiting := make(map[string]bool)
+	var lookup func(string) (string, bool)
+	lookup = func(name string) (string, bool) {
+		key := strings.ToLower(name)
+		if v, ok := resolved[key]; ok {
+			return v, true
+		}
+		for k, raw := range cfg.Vars {
+			if !strings.EqualFold(k, name) {
+				continue
+			}
+			if visiting[key] {
+				return "", false
+			}
+			visiting[key] = true
+			v, _ := expandString(raw, lookup)
+			delete(visiting, key)
+			resolved[key] = v
+			return v, true
+		}
+		return os.LookupEnv(name)
+	}
+	return lookup
+}
+
+// expandVars expands variables in processes, templates and settings. The
+// original text of every expanded field is kept so it can be written back.
+func (cfg *Config) expandVars() {
+	lookup := cfg.varLookup()
+	for _, items := range []map[string]*ProcessItem{cfg.Template, cfg.Process} {
+		for _, item := range items {
+			item.raw = nil
+			v := reflect.ValueOf(item).Elem()
+			for _, name := range expandableFields {
+				f := v.FieldByName(name)
+				if !strings.Contains(f.String(), "${") {
+					continue
+				}
+				setKey(&item.raw, fieldKey(name), f.String())
+				out, _ := expandString(f.String(), lookup)
+				f.SetString(out)
+			}
+		}
+	}
+	cfg.Settings.raw = nil
+	if s := cfg.Settings.ErrorWindowTitles; strings.Contains(s, "${") {
+		setKey(&cfg.Settings.raw, "errorWindowTitles", s)
+		cfg.Settings.ErrorWindowTitles, _ = expandString(s, lookup)
+	}
+}
+
+// missingVars lists variables in text that are not defined anywhere.
+func (cfg *Config) missingVars(text string) []string {
+	_, missing := expandString(text, cfg.varLookup())
+	return missing
+}
+
+// iniVars reads the [vars] section and blanks it out so gcfg, which only
+// knows fixed keys, does not see it. Line numbers are preserved.
+// Unquoted values are taken literally so Windows paths need no escaping.
+func iniVars(doc *iniDoc) map[string]string {
+	start, end := doc.sectionRange(iniSectionRef{Name: "vars"})
+	if start < 0 {
+		return nil
+	}
+	vars := make(map[string]string)
+	for i := start; i < end; i++ {
+		if l := doc.lines[i]; l.kind == iniKey {
+			vars[l.key] = l.varValue()
+		}
+		doc.lines[i] = iniLine{}
+	}
+	return vars
+}
+
+func (l iniLine) varValue() string {
+	raw, _ := l.rawValue()
+	if strings.HasPrefix(raw, `"`) {
+		return unquoteValue(raw)
+	}
+	return raw
+}
+
+// patchVars syncs the [vars] section with vars, keeping untouched lines.
+func patchVars(doc *iniDoc, vars map[string]string) {
+	ref := iniSectionRef{Name: "vars"}
+	start, end := doc.sectionRange(ref)
+	if start >= 0 {
+		for i := end - 1; i > start; i-- {
+			l := doc.lines[i]
+			if l.kind != iniKey {
+				continue
+			}
+			if _, ok := lookupFold(vars, l.key); !ok {
+				doc.lines = append(doc.lines[:i], doc.lines[i+1:]...)
+			}
+		}
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if i := doc.findKey(ref, name); i >= 0 && doc.lines[i].varValue() == vars[name] {
+			continue
+		}
+		doc.set(ref, name, quoteIfNeeded(vars[name]))
+	}
+}
+
+func lookupFold(m map[string]string, key string) (string, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
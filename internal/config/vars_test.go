@@ -0,0 +1,90 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandString(t *testing.T) {
+	vars := map[string]string{"ROOT": `C:\Exhibit`, "EMPTY": "", "PORT": "3000"}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+	tests := []struct {
+		in      string
+		want    string
+		missing []string
+	}{
+		{"plain", "plain", nil},
+		{`${ROOT}\UE`, `C:\Exhibit\UE`, nil},
+		{"--port=${PORT} --host=${HOST}", "--port=3000 --host=", []string{"HOST"}},
+		{"${HOST:-localhost}", "localhost", nil},
+		{"${EMPTY:-fallback}", "fallback", nil},
+		{"[${EMPTY}]", "[]", nil},
+		{"${HOST:-${ROOT}}", `C:\Exhibit`, nil},
+		{"${HOST:-${NOPE}}", "", []string{"NOPE"}},
+		{"${ ROOT }", `C:\Exhibit`, nil},
+		{"${ROOT", "${ROOT", nil},
+		{"$ROOT {ROOT}", "$ROOT {ROOT}", nil},
+	}
+	for _, tt := range tests {
+		got, missing := expandString(tt.in, lookup)
+		if got != tt.want || !reflect.DeepEqual(missing, tt.missing) {
+			t.Errorf("expandString(%q) = %q, %v; want %q, %v", tt.in, got, missing, tt.want, tt.missing)
+		}
+	}
+}
+
+func TestExpandVars(t *testing.T) {
+	t.Setenv("GRF_TEST_HOME", `D:\home`)
+	const ini = `[settings]
+checkTiming=1s
+restartTiming=5s
+errorWindowTitles="${APP} crashed"
+
+[vars]
+root=C:\Exhibit
+app=Game
+game="${ROOT}/${app}"
+loop=${LOOP}
+
+[template "ue"]
+type=exe
+path=${game}
+
+[process "UE"]
+extends=ue
+process=${APP}.exe
+args="-log=${GRF_TEST_HOME}/log -id=${ID:-1} ${loop}"
+`
+	cfg, err := loadINI([]byte(ini))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ue := cfg.Process["UE"]
+	tests := []struct {
+		field, got, want string
+	}{
+		{"path", ue.Path, `C:\Exhibit/Game`},
+		{"process", ue.Process, "Game.exe"},
+		{"args", ue.Args, `-log=D:\home/log -id=1 `},
+		{"errorWindowTitles", cfg.Settings.ErrorWindowTitles, "Game crashed"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+	// The text as written is kept for saving, inherited fields included.
+	if want := map[string]string{"path": "${game}", "process": "${APP}.exe", "args": `-log=${GRF_TEST_HOME}/log -id=${ID:-1} ${loop}`}; !reflect.DeepEqual(ue.raw, want) {
+		t.Errorf("raw = %v, want %v", ue.raw, want)
+	}
+	if got, want := cfg.missingVars(ue.raw["args"]), []string(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("missingVars(args) = %v, want %v", got, want)
+	}
+	// A var that refers to itself expands to nothing but still counts as defined.
+	if got, want := cfg.missingVars("${LOOP} ${NOPE}"), []string{"NOPE"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missingVars = %v, want %v", got, want)
+	}
+}
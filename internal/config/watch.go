@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultWatchInterval is how often Watch polls the config file.
+const DefaultWatchInterval = time.Second
+
+// Watch polls the config file and its included files and calls onChange with the config
+// freshly loaded by load, usually Load, whenever its content changes. A failed load is reported with a
+// non-nil error so the caller can keep the last good config. Watch blocks
+// until ctx is done.
+func Watch(ctx context.Context, path string, interval time.Duration, load func(path string) (Config, error), onChange func(Config, error)) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	last := fingerprint(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cur := fingerprint(path)
+			if cur == nil || bytes.Equal(cur, last) {
+				continue
+			}
+			cfg, err := load(path)
+			// Load may rewrite the file via RepairFile; pick up the final content.
+			if fp := fingerprint(path); fp != nil {
+				cur = fp
+			}
+			last = cur
+			onChange(cfg, err)
+		}
+	}
+}
+
+// fingerprint hashes the config together with the names and content of
+// its included files, so adding a file to conf.d counts as a change.
+func fingerprint(path string) []byte {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	h := sha256.New()
+	for _, file := range watchedFiles(path) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", file, len(data))
+		h.Write(data)
+	}
+	return h.Sum(nil)
+}
@@ -1,104 +1,309 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
-	"sort"
+	"os"
+	"strconv"
 	"strings"
 )
 
-// WriteFromDTO writes config.ini content from ConfigDTO.
+// WriteFromDTO updates config.ini and its included files from ConfigDTO.
+// Each process and template is written to the file named by its Source.
+// Fields that use ${VAR} are written unexpanded (see ProcessDTO.Raw). INI
+// files are patched in place: only keys whose values changed are
+// rewritten, removed processes lose their sections and new ones are
+// appended, while comments, blank lines, ordering and unknown keys are
+// kept. When any file changes, the previous versions of the main config
+// and all included files are archived first as one set with one id (see
+// RestoreBackup). JSON, YAML and TOML files are written whole in their own
+// format.
 func WriteFromDTO(path string, dto ConfigDTO) error {
-	var b strings.Builder
-
-	names := make([]string, 0, len(dto.Processes))
-	procByName := make(map[string]ProcessDTO, len(dto.Processes))
-	for _, p := range dto.Processes {
-		name := strings.TrimSpace(p.Name)
-		if name == "" {
-			continue
-		}
-		procByName[name] = p
-		names = append(names, name)
+	dto = dto.unexpanded()
+	parts, err := splitBySource(dto)
+	if err != nil {
+		return err
 	}
-	sort.Strings(names)
-
-	for _, name := range names {
-		p := procByName[name]
-		b.WriteString(fmt.Sprintf("[process %q]\n", name))
-		b.WriteString(fmt.Sprintf("disabled=%v\n", p.Disabled))
-		if p.Path != "" {
-			b.WriteString(fmt.Sprintf("path=%s\n", quoteIfNeeded(p.Path)))
+	var cfg *Config // resolved once, only for JSON, YAML and TOML files
+	changed := make(map[string][]byte)
+	var order []string
+	add := func(target string, data []byte) {
+		if old, err := os.ReadFile(target); err == nil && bytes.Equal(old, data) {
+			return
 		}
-		if p.Command != "" {
-			b.WriteString(fmt.Sprintf("command=%s\n", quoteIfNeeded(p.Command)))
+		changed[target] = data
+		order = append(order, target)
+	}
+	for _, file := range append([]string{""}, dto.Files...) {
+		target := path
+		if file != "" {
+			target = IncludePath(path, file)
 		}
-		if p.Process != "" {
-			b.WriteString(fmt.Sprintf("process=%s\n", quoteIfNeeded(p.Process)))
+		format := DetectFormat(target)
+		if format != FormatINI {
+			if cfg == nil {
+				c, err := FromDTO(dto)
+				if err != nil {
+					return err
+				}
+				cfg = &c
+			}
+			data, err := encodeStructured(format, configToMap(*cfg, file))
+			if err != nil {
+				return err
+			}
+			add(target, data)
+			continue
 		}
-		if p.CheckProcess != "" {
-			b.WriteString(fmt.Sprintf("checkProcess=%s\n", quoteIfNeeded(p.CheckProcess)))
+		data, err := os.ReadFile(target)
+		if err != nil && !os.IsNotExist(err) {
+			return err
 		}
-		if p.CheckCmdline != "" {
-			b.WriteString(fmt.Sprintf("checkCmdline=%s\n", quoteIfNeeded(p.CheckCmdline)))
+		doc := parseINI(data)
+		part := parts[file]
+		if file == "" {
+			patchINI(doc, part)
+		} else {
+			patchItems(doc, "template", part.Templates)
+			patchItems(doc, "process", part.Processes)
 		}
-		if p.CheckCmdlineExclude != "" {
-			b.WriteString(fmt.Sprintf("checkCmdlineExclude=%s\n", quoteIfNeeded(p.CheckCmdlineExclude)))
+		add(target, doc.bytes())
+	}
+	if len(order) == 0 {
+		return nil
+	}
+	if err := backupSet(path, dto.Files, dto.Settings.BackupCount); err != nil {
+		return err
+	}
+	for _, target := range order {
+		if err := WriteFileAtomic(target, changed[target]); err != nil {
+			return err
 		}
-		if p.Args != "" {
-			b.WriteString(fmt.Sprintf("args=%s\n", quoteIfNeeded(p.Args)))
+	}
+	return nil
+}
+
+// splitBySource groups processes and templates by the file they belong to.
+// The part for the main config ("") also carries settings, vars, profiles
+// and schedules.
+func splitBySource(dto ConfigDTO) (map[string]ConfigDTO, error) {
+	parts := map[string]ConfigDTO{"": {Settings: dto.Settings, Vars: dto.Vars, Profiles: dto.Profiles, Schedules: dto.Schedules}}
+	for _, file := range dto.Files {
+		parts[file] = ConfigDTO{}
+	}
+	for _, p := range dto.Templates {
+		part, ok := parts[p.Source]
+		if !ok {
+			return nil, fmt.Errorf("template %s: unknown config file %q", p.Name, p.Source)
 		}
-		if p.Screen > 0 {
-			b.WriteString(fmt.Sprintf("screen=%d\n", p.Screen))
+		part.Templates = append(part.Templates, p)
+		parts[p.Source] = part
+	}
+	for _, p := range dto.Processes {
+		part, ok := parts[p.Source]
+		if !ok {
+			return nil, fmt.Errorf("process %s: unknown config file %q", p.Name, p.Source)
 		}
-		if p.Type != "" {
-			b.WriteString(fmt.Sprintf("type=%s\n", p.Type))
+		part.Processes = append(part.Processes, p)
+		parts[p.Source] = part
+	}
+	return parts, nil
+}
+
+func patchINI(doc *iniDoc, dto ConfigDTO) {
+	patchVars(doc, dto.Vars)
+	patchProfiles(doc, dto.Profiles)
+	patchSchedules(doc, dto.Schedules)
+	patchItems(doc, "template", dto.Templates)
+	patchItems(doc, "process", dto.Processes)
+	patchSection(doc, iniSectionRef{Name: "settings"}, settingsFields(dto.Settings))
+}
+
+// patchItems syncs all sections with the given name to items: missing
+// sections are removed, the rest are patched or appended.
+func patchItems(doc *iniDoc, section string, items []ProcessDTO) {
+	wanted := make(map[string]bool, len(items))
+	for _, p := range items {
+		if name := strings.TrimSpace(p.Name); name != "" {
+			wanted[name] = true
 		}
-		if strings.TrimSpace(p.DelayStartTime) != "" {
-			b.WriteString(fmt.Sprintf("delayStartTime=%s\n", p.DelayStartTime))
+	}
+	for _, ref := range doc.sections(section) {
+		if !wanted[ref.Sub] {
+			doc.removeSection(ref)
 		}
-		b.WriteString(fmt.Sprintf("monitorHang=%v\n", p.MonitorHang))
-		if strings.TrimSpace(p.HangTimeout) != "" {
-			b.WriteString(fmt.Sprintf("hangTimeout=%s\n", p.HangTimeout))
+	}
+	for _, p := range items {
+		name := strings.TrimSpace(p.Name)
+		if name == "" {
+			continue
 		}
-		b.WriteString("\n")
+		patchSection(doc, iniSectionRef{Name: section, Sub: name}, processFields(p))
 	}
+}
+
+type iniValueKind int
+
+const (
+	iniString iniValueKind = iota
+	iniBool
+	iniInt
+	iniDuration
+	iniFloat
+)
+
+// iniField is the desired state of one key. Omitted fields are removed;
+// zero fields hold the default and are only updated when already present.
+type iniField struct {
+	key   string
+	kind  iniValueKind
+	value string
+	omit  bool
+	zero  bool
+}
 
-	b.WriteString("[settings]\n")
-	if strings.TrimSpace(dto.Settings.CheckTiming) != "" {
-		b.WriteString(fmt.Sprintf("checkTiming=%s\n", dto.Settings.CheckTiming))
+// processFields omits values inherited from a template so they keep
+// following it.
+func processFields(p ProcessDTO) []iniField {
+	fields := []iniField{
+		{key: "extends", value: p.Extends, omit: strings.TrimSpace(p.Extends) == ""},
+		{key: "disabled", kind: iniBool, value: strconv.FormatBool(p.Disabled)},
+		{key: "path", value: p.Path, omit: p.Path == ""},
+		{key: "command", value: p.Command, omit: p.Command == ""},
+		{key: "process", value: p.Process, omit: p.Process == ""},
+		{key: "checkProcess", value: p.CheckProcess, omit: p.CheckProcess == ""},
+		{key: "checkCmdline", value: p.CheckCmdline, omit: p.CheckCmdline == ""},
+		{key: "checkCmdlineExclude", value: p.CheckCmdlineExclude, omit: p.CheckCmdlineExclude == ""},
+		{key: "args", value: p.Args, omit: p.Args == ""},
+		screenField(p),
+		{key: "instances", kind: iniInt, value: strconv.Itoa(p.Instances), omit: p.Instances <= 0},
+		{key: "type", value: p.Type, omit: p.Type == ""},
+		{key: "groups", value: p.Groups, omit: strings.TrimSpace(p.Groups) == ""},
+		{key: "delayStartTime", kind: iniDuration, value: p.DelayStartTime, omit: strings.TrimSpace(p.DelayStartTime) == ""},
+		{key: "startTimeout", kind: iniDuration, value: p.StartTimeout, omit: strings.TrimSpace(p.StartTimeout) == ""},
+		{key: "minUptime", kind: iniDuration, value: p.MinUptime, omit: strings.TrimSpace(p.MinUptime) == ""},
+		{key: "monitorHang", kind: iniBool, value: strconv.FormatBool(p.MonitorHang)},
+		{key: "hangTimeout", kind: iniDuration, value: p.HangTimeout, omit: strings.TrimSpace(p.HangTimeout) == ""},
+		{key: "runWindow", value: p.RunWindow, omit: strings.TrimSpace(p.RunWindow) == ""},
+		{key: "maxUptime", kind: iniDuration, value: p.MaxUptime, omit: strings.TrimSpace(p.MaxUptime) == ""},
+		{key: "restartAt", value: p.RestartAt, omit: strings.TrimSpace(p.RestartAt) == ""},
+		{key: "cron", value: p.Cron, omit: strings.TrimSpace(p.Cron) == ""},
+		{key: "timeout", kind: iniDuration, value: p.Timeout, omit: strings.TrimSpace(p.Timeout) == ""},
 	}
-	if strings.TrimSpace(dto.Settings.RestartTiming) != "" {
-		b.WriteString(fmt.Sprintf("restartTiming=%s\n", dto.Settings.RestartTiming))
+	for i := range fields {
+		f := &fields[i]
+		if _, ok := p.Overridden[f.key]; ok {
+			continue
+		}
+		if _, ok := p.Inherited[f.key]; ok && strings.TrimSpace(p.Extends) != "" {
+			f.omit = true
+			continue
+		}
+		f.zero = f.value == "false" || (f.kind == iniDuration && sameINIValue(iniDuration, f.value, "0"))
 	}
-	b.WriteString(fmt.Sprintf("autoRestart=%v\n", dto.Settings.AutoRestart))
-	if strings.TrimSpace(dto.Settings.AutoRestartTime) != "" {
-		b.WriteString(fmt.Sprintf("autoRestartTime=%s\n", dto.Settings.AutoRestartTime))
+	return fields
+}
+
+func screenField(p ProcessDTO) iniField {
+	if expr := strings.TrimSpace(p.ScreenExpr); expr != "" {
+		return iniField{key: "screen", value: expr}
 	}
-	b.WriteString(fmt.Sprintf("autoRestartOnExit=%v\n", dto.Settings.AutoRestartOnExit))
-	b.WriteString(fmt.Sprintf("launchInNewConsole=%v\n", dto.Settings.LaunchInNewConsole))
-	b.WriteString(fmt.Sprintf("autoCloseErrorDialogs=%v\n", dto.Settings.AutoCloseErrorDialogs))
-	b.WriteString(fmt.Sprintf("useETWNetwork=%v\n", dto.Settings.UseETWNetwork))
-	b.WriteString(fmt.Sprintf("netDebug=%v\n", dto.Settings.NetDebug))
-	if strings.TrimSpace(dto.Settings.NetUnit) != "" {
-		b.WriteString(fmt.Sprintf("netUnit=%s\n", dto.Settings.NetUnit))
+	return iniField{key: "screen", kind: iniInt, value: strconv.Itoa(p.Screen), omit: p.Screen <= 0}
+}
+
+func settingsFields(s SettingsDTO) []iniField {
+	return []iniField{
+		{key: "checkTiming", kind: iniDuration, value: s.CheckTiming, omit: strings.TrimSpace(s.CheckTiming) == ""},
+		{key: "checkTimingMax", kind: iniDuration, value: s.CheckTimingMax, omit: strings.TrimSpace(s.CheckTimingMax) == ""},
+		{key: "restartTiming", kind: iniDuration, value: s.RestartTiming, omit: strings.TrimSpace(s.RestartTiming) == ""},
+		{key: "metricsTiming", kind: iniDuration, value: s.MetricsTiming, omit: strings.TrimSpace(s.MetricsTiming) == ""},
+		{key: "bulkParallel", kind: iniInt, value: strconv.Itoa(s.BulkParallel), omit: s.BulkParallel == 0},
+		{key: "bulkStagger", kind: iniDuration, value: s.BulkStagger, omit: strings.TrimSpace(s.BulkStagger) == ""},
+		{key: "rollingBatch", kind: iniInt, value: strconv.Itoa(s.RollingBatch), omit: s.RollingBatch == 0},
+		{key: "rollingTimeout", kind: iniDuration, value: s.RollingTimeout, omit: strings.TrimSpace(s.RollingTimeout) == ""},
+		{key: "autoRestart", kind: iniBool, value: strconv.FormatBool(s.AutoRestart), zero: !s.AutoRestart},
+		{key: "autoRestartTime", value: s.AutoRestartTime, omit: strings.TrimSpace(s.AutoRestartTime) == ""},
+		{key: "autoRestartOnExit", kind: iniBool, value: strconv.FormatBool(s.AutoRestartOnExit), zero: s.AutoRestartOnExit},
+		{key: "launchInNewConsole", kind: iniBool, value: strconv.FormatBool(s.LaunchInNewConsole), zero: !s.LaunchInNewConsole},
+		{key: "autoCloseErrorDialogs", kind: iniBool, value: strconv.FormatBool(s.AutoCloseErrorDialogs), zero: !s.AutoCloseErrorDialogs},
+		{key: "useETWNetwork", kind: iniBool, value: strconv.FormatBool(s.UseETWNetwork), zero: !s.UseETWNetwork},
+		{key: "netDebug", kind: iniBool, value: strconv.FormatBool(s.NetDebug), zero: !s.NetDebug},
+		{key: "netUnit", value: s.NetUnit, omit: strings.TrimSpace(s.NetUnit) == ""},
+		{key: "netScale", kind: iniFloat, value: s.NetScale, omit: strings.TrimSpace(s.NetScale) == ""},
+		{key: "watchConfig", kind: iniBool, value: strconv.FormatBool(s.WatchConfig), zero: !s.WatchConfig},
+		{key: "restartOnConfigChange", kind: iniBool, value: strconv.FormatBool(s.RestartOnConfigChange), zero: !s.RestartOnConfigChange},
+		{key: "backupCount", kind: iniInt, value: strconv.Itoa(s.BackupCount), omit: s.BackupCount == 0},
+		{key: "errorWindowTitles", value: s.ErrorWindowTitles, omit: strings.TrimSpace(s.ErrorWindowTitles) == ""},
+		{key: "include", value: s.Include, omit: strings.TrimSpace(s.Include) == ""},
+		{key: "profile", value: s.Profile, omit: strings.TrimSpace(s.Profile) == ""},
+		{key: "controlAddr", value: s.ControlAddr, omit: strings.TrimSpace(s.ControlAddr) == ""},
+		{key: "holidays", value: s.Holidays, omit: strings.TrimSpace(s.Holidays) == ""},
 	}
-	if strings.TrimSpace(dto.Settings.NetScale) != "" {
-		b.WriteString(fmt.Sprintf("netScale=%s\n", dto.Settings.NetScale))
+}
+
+// patchSection brings a section to the desired state touching only the
+// keys whose effective value differs.
+func patchSection(doc *iniDoc, ref iniSectionRef, fields []iniField) {
+	for _, f := range fields {
+		cur, ok := doc.get(ref, f.key)
+		if f.omit {
+			if ok {
+				doc.unset(ref, f.key)
+			}
+			continue
+		}
+		if ok && sameINIValue(f.kind, cur, f.value) || !ok && f.zero {
+			continue
+		}
+		raw := f.value
+		if f.kind == iniString {
+			raw = quoteIfNeeded(f.value)
+		}
+		doc.set(ref, f.key, strings.TrimSpace(raw))
 	}
-	if strings.TrimSpace(dto.Settings.ErrorWindowTitles) != "" {
-		b.WriteString(fmt.Sprintf("errorWindowTitles=%s\n", quoteIfNeeded(dto.Settings.ErrorWindowTitles)))
+}
+
+func sameINIValue(kind iniValueKind, a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	switch kind {
+	case iniBool:
+		x, err1 := parseINIBool(a)
+		y, err2 := parseINIBool(b)
+		return err1 == nil && err2 == nil && x == y
+	case iniInt:
+		x, err1 := strconv.Atoi(a)
+		y, err2 := strconv.Atoi(b)
+		return err1 == nil && err2 == nil && x == y
+	case iniDuration:
+		var x, y Duration
+		return x.UnmarshalText([]byte(a)) == nil && y.UnmarshalText([]byte(b)) == nil && x == y
+	case iniFloat:
+		x, err1 := strconv.ParseFloat(a, 64)
+		y, err2 := strconv.ParseFloat(b, 64)
+		return err1 == nil && err2 == nil && x == y
+	default:
+		return a == b
 	}
+}
 
-	return atomicWrite(path, []byte(b.String()))
+// parseINIBool accepts the same spellings as gcfg.
+func parseINIBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid bool %q", s)
 }
 
 func quoteIfNeeded(s string) string {
 	if s == "" {
 		return "\"\""
 	}
-	need := false
+	need := s != strings.TrimSpace(s)
 	for _, r := range s {
-		if r == ' ' || r == '"' || r == ',' {
+		if r == ' ' || r == '"' || r == ',' || r == '\\' || r == ';' || r == '#' {
 			need = true
 			break
 		}
@@ -0,0 +1,137 @@
+// Package control lets goRunFiles commands talk to a running monitor. The
+// monitor listens on a loopback TCP address; each connection carries one
+// JSON request line and gets one JSON response line back.
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// SuggestedAddr is the address the docs and ctl suggest for
+// settings.controlAddr. The channel has no authentication, so it stays off
+// until an address is set.
+const SuggestedAddr = "127.0.0.1:47611"
+
+// callTimeout bounds a whole ctl round trip.
+const callTimeout = 30 * time.Second
+
+// Request is one ctl command, e.g. {"command":"profile","args":["night"]}.
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// Response is the result of a Request. Error is empty on success.
+type Response struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Handler executes a request.
+type Handler func(Request) Response
+
+// Addr resolves the configured address: "" and "off" leave the control
+// channel off, reported as ok=false.
+func Addr(configured string) (addr string, ok bool) {
+	configured = strings.TrimSpace(configured)
+	if configured == "" || strings.EqualFold(configured, "off") {
+		return "", false
+	}
+	return configured, true
+}
+
+// Serve accepts requests on addr until ctx is done. Only loopback
+// addresses are accepted.
+func Serve(ctx context.Context, addr string, h Handler) error {
+	ln, err := Listen(addr)
+	if err != nil {
+		return err
+	}
+	return ServeListener(ctx, ln, h)
+}
+
+// Listen opens the loopback address addr for ServeListener.
+func Listen(addr string) (net.Listener, error) {
+	if err := checkLoopback(addr); err != nil {
+		return nil, err
+	}
+	return net.Listen("tcp", addr)
+}
+
+// ServeListener accepts requests on ln until ctx is done and closes it.
+func ServeListener(ctx context.Context, ln net.Listener, h Handler) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			continue
+		}
+		go serveConn(conn, h)
+	}
+}
+
+func serveConn(conn net.Conn, h Handler) {
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(callTimeout))
+	var req Request
+	var resp Response
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &req)
+	}
+	if err != nil {
+		resp.Error = fmt.Sprintf("bad request: %v", err)
+	} else {
+		resp = h(req)
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(callTimeout))
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// Call sends a request to the monitor listening on addr.
+func Call(addr string, req Request) (Response, error) {
+	conn, err := net.DialTimeout("tcp", addr, 3*time.Second)
+	if err != nil {
+		return Response{}, fmt.Errorf("monitor is not running or not reachable at %s: %w", addr, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(callTimeout))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, err
+	}
+	return resp, nil
+}
+
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("control address %s is not a loopback address", addr)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package process
 
 import (
+	"context"
+	"errors"
 	"os/exec"
 	"path/filepath"
 	"runtime"
@@ -8,11 +10,19 @@ import (
 	"strings"
 	"time"
 
+	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/process"
 )
 
 // ByName reports if a process with the given name is running and returns one PID if found.
 func ByName(name string) (bool, int, error) {
+	if s := sandboxed.Load(); s != nil {
+		found, err := s.find(func(p Proc) bool { return sameProcessName(p.Name, name) })
+		if err != nil || len(found) == 0 {
+			return false, 0, err
+		}
+		return true, found[0].Pid, nil
+	}
 	processes, err := process.Processes()
 	if err != nil {
 		return false, 0, err
@@ -31,6 +41,10 @@ func ByName(name string) (bool, int, error) {
 
 // PidsByName returns all PIDs that match the given process name.
 func PidsByName(name string) ([]int, error) {
+	if s := sandboxed.Load(); s != nil {
+		found, err := s.find(func(p Proc) bool { return sameProcessName(p.Name, name) })
+		return pids(found), err
+	}
 	processes, err := process.Processes()
 	if err != nil {
 		return nil, err
@@ -50,6 +64,10 @@ func PidsByName(name string) ([]int, error) {
 
 // IsPidAlive reports if a PID is running.
 func IsPidAlive(pid int) bool {
+	if s := sandboxed.Load(); s != nil {
+		found, _ := s.find(func(p Proc) bool { return p.Pid == pid })
+		return len(found) > 0
+	}
 	p, err := process.NewProcess(int32(pid))
 	if err != nil {
 		return false
@@ -75,27 +93,67 @@ func KillByName(name string) error {
 
 // KillByNames terminates processes for all provided names.
 func KillByNames(names []string) error {
+	return KillByNamesContext(context.Background(), names)
+}
+
+// KillByNamesContext is KillByNames that gives up once ctx is done.
+func KillByNamesContext(ctx context.Context, names []string) error {
 	var lastErr error
 	for _, n := range names {
 		n = strings.TrimSpace(n)
 		if n == "" {
 			continue
 		}
-		if err := KillByName(n); err != nil {
+		pids, err := PidsByName(n)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := killPids(ctx, pids); err != nil {
+			lastErr = err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return lastErr
+}
+
+// killPids terminates pids one by one until ctx is done and returns the
+// last error.
+func killPids(ctx context.Context, pids []int) error {
+	var lastErr error
+	for _, pid := range pids {
+		if err := KillPidContext(ctx, pid); err != nil {
 			lastErr = err
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 	}
 	return lastErr
 }
 
 // KillPid attempts to terminate a process by PID.
 func KillPid(pid int) error {
+	return KillPidContext(context.Background(), pid)
+}
+
+// KillPidContext is KillPid that gives up once ctx is done; on Windows the
+// taskkill run is killed with it.
+func KillPidContext(ctx context.Context, pid int) error {
 	if pid <= 0 {
 		return nil
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s := sandboxed.Load(); s != nil {
+		return s.kill(pid)
+	}
 	if runtime.GOOS == "windows" {
 		// Kill entire process tree for cmd/bat wrappers (e.g. npm/node children).
-		cmd := exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(pid))
+		cmd := exec.CommandContext(ctx, "taskkill", "/F", "/T", "/PID", strconv.Itoa(pid))
 		hideCmdWindow(cmd)
 		return cmd.Run()
 	}
@@ -108,6 +166,13 @@ func KillPid(pid int) error {
 
 // StartTime returns the process start time for a PID.
 func StartTime(pid int) (time.Time, bool) {
+	if s := sandboxed.Load(); s != nil {
+		found, _ := s.find(func(p Proc) bool { return p.Pid == pid })
+		if len(found) == 0 || found[0].Created <= 0 {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(found[0].Created), true
+	}
 	p, err := process.NewProcess(int32(pid))
 	if err != nil {
 		return time.Time{}, false
@@ -119,6 +184,15 @@ func StartTime(pid int) (time.Time, bool) {
 	return time.Unix(0, ms*int64(time.Millisecond)), true
 }
 
+// BootTime returns when the system was last started.
+func BootTime() (time.Time, bool) {
+	secs, err := host.BootTime()
+	if err != nil || secs == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(secs), 0), true
+}
+
 // ByNameAndCmdlineArgsExact reports if a process with the given name has cmdline
 // containing the argument sequence (token match). Matching is performed against
 // the process command line only (cwd is intentionally ignored to avoid false
@@ -142,6 +216,13 @@ func ByNameAndCmdlineArgsExactWithExclude(name, args, exclude string) (bool, int
 	if len(needle) == 0 {
 		return false, 0, nil
 	}
+	if s := sandboxed.Load(); s != nil {
+		found, err := s.byCmdline(name, args, exclude)
+		if err != nil || len(found) == 0 {
+			return false, 0, err
+		}
+		return true, newest(found), nil
+	}
 	excludeGroups := parsePatternGroups(exclude)
 	processes, err := process.Processes()
 	if err != nil {
@@ -197,6 +278,10 @@ func PidsByNameAndCmdlineArgsExactWithExclude(name, args, exclude string) ([]int
 	if len(needle) == 0 {
 		return nil, nil
 	}
+	if s := sandboxed.Load(); s != nil {
+		found, err := s.byCmdline(name, args, exclude)
+		return pids(found), err
+	}
 	excludeGroups := parsePatternGroups(exclude)
 	processes, err := process.Processes()
 	if err != nil {
@@ -266,17 +351,17 @@ func KillByNameAndCmdlineArgsExact(name, args string) error {
 
 // KillByNameAndCmdlineArgsExactWithExclude terminates matching processes and applies exclude patterns.
 func KillByNameAndCmdlineArgsExactWithExclude(name, args, exclude string) error {
+	return KillByNameAndCmdlineArgsExactWithExcludeContext(context.Background(), name, args, exclude)
+}
+
+// KillByNameAndCmdlineArgsExactWithExcludeContext is
+// KillByNameAndCmdlineArgsExactWithExclude that gives up once ctx is done.
+func KillByNameAndCmdlineArgsExactWithExcludeContext(ctx context.Context, name, args, exclude string) error {
 	pids, err := PidsByNameAndCmdlineArgsExactWithExclude(name, args, exclude)
 	if err != nil {
 		return err
 	}
-	var lastErr error
-	for _, pid := range pids {
-		if err := KillPid(pid); err != nil {
-			lastErr = err
-		}
-	}
-	return lastErr
+	return killPids(ctx, pids)
 }
 
 func parsePatternGroups(raw string) [][]string {
@@ -419,3 +504,15 @@ func splitTokenParts(s string) []string {
 	}
 	return out
 }
+
+// exitPollStep is how often WaitExit looks at its context while waiting.
+const exitPollStep = time.Second
+
+// WaitExit blocks until the process with pid exits or ctx ends. In a
+// sandbox exits are left to the regular checks.
+func WaitExit(ctx context.Context, pid int) error {
+	if sandboxed.Load() != nil {
+		return errors.ErrUnsupported
+	}
+	return waitExit(ctx, pid)
+}
@@ -0,0 +1,33 @@
+//go:build linux
+
+package process
+
+import (
+	"context"
+
+	"golang.org/x/sys/unix"
+)
+
+// waitExit blocks until the process with pid exits or ctx ends. It uses a
+// pidfd, so the PID can not be confused with a later process that reuses
+// it.
+func waitExit(ctx context.Context, pid int) error {
+	fd, err := unix.PidfdOpen(pid, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	for {
+		n, err := unix.Poll(fds, int(exitPollStep.Milliseconds()))
+		if err != nil && err != unix.EINTR {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
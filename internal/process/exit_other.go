@@ -0,0 +1,13 @@
+//go:build !linux && !windows
+
+package process
+
+import (
+	"context"
+	"errors"
+)
+
+// waitExit is not supported here; process exits are found by polling.
+func waitExit(ctx context.Context, pid int) error {
+	return errors.ErrUnsupported
+}
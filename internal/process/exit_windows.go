@@ -0,0 +1,32 @@
+//go:build windows
+
+package process
+
+import (
+	"context"
+
+	"golang.org/x/sys/windows"
+)
+
+// waitExit blocks until the process with pid exits or ctx ends. It waits
+// on a process handle, which stays bound to the process even if the PID
+// is reused.
+func waitExit(ctx context.Context, pid int) error {
+	h, err := windows.OpenProcess(windows.SYNCHRONIZE, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+	for {
+		ev, err := windows.WaitForSingleObject(h, uint32(exitPollStep.Milliseconds()))
+		if err != nil {
+			return err
+		}
+		if ev == windows.WAIT_OBJECT_0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
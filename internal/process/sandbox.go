@@ -0,0 +1,376 @@
+package process
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Sandbox modes, see SandboxMode.
+const (
+	ModeDryRun     = "dry-run"
+	ModeSimulation = "simulation"
+)
+
+// sandboxPidBase is the first PID given to processes started in a sandbox,
+// well above the PIDs of a real table.
+const sandboxPidBase = 900000
+
+// sandboxActions is the number of recent actions SandboxActions keeps.
+const sandboxActions = 20
+
+// Proc is one process of a process table, as recorded by Record.
+type Proc struct {
+	Pid     int    `json:"pid"`
+	Name    string `json:"name"`
+	Cmdline string `json:"cmdline,omitempty"`
+	Cwd     string `json:"cwd,omitempty"`
+	Created int64  `json:"created,omitempty"` // Unix ms
+}
+
+// Frame is the process table at one moment of a recording.
+type Frame struct {
+	At        int64  `json:"at"` // ms since the start of the recording
+	Processes []Proc `json:"processes"`
+}
+
+// Recording is a series of process tables for a simulation.
+type Recording struct {
+	Host     string    `json:"host,omitempty"`
+	Recorded time.Time `json:"recorded"`
+	Frames   []Frame   `json:"frames"`
+}
+
+// sandbox stands between the monitor and the processes of the system: it
+// logs kills and starts instead of acting. The table it shows is the live
+// one in a dry run or the frames of a recording in a simulation, less the
+// processes killed and plus the ones started in the sandbox.
+type sandbox struct {
+	mode  string
+	logf  func(format string, args ...any)
+	rec   *Recording // nil in a dry run
+	start time.Time
+	shift int64 // ms added to the recorded start times
+
+	mu      sync.Mutex
+	spawned []Proc
+	killed  map[int]bool
+	nextPid int
+	actions []string
+}
+
+// sandboxed is the active sandbox, nil when processes are real. It is set
+// at startup, before the monitor runs; tests set a new one for each App.
+var sandboxed atomic.Pointer[sandbox]
+
+// DryRun makes kills only logged through logf while processes are still
+// looked up in the live table. Processes started through Spawn are added
+// to what the checks see, and killed ones are hidden from them.
+func DryRun(logf func(format string, args ...any)) {
+	sandboxed.Store(newSandbox(ModeDryRun, nil, logf))
+}
+
+// Simulate replays rec instead of the live process table: each frame
+// replaces the table once its time since the start of the simulation has
+// come, the first one is there from the start and the last one stays.
+// Kills and starts change the table as in DryRun. Start times are moved
+// as if the recording had started now. rec holds at least one frame, as
+// LoadRecording makes sure.
+func Simulate(rec *Recording, logf func(format string, args ...any)) {
+	s := newSandbox(ModeSimulation, rec, logf)
+	if !rec.Recorded.IsZero() {
+		s.shift = s.start.Sub(rec.Recorded).Milliseconds()
+	}
+	sandboxed.Store(s)
+}
+
+func newSandbox(mode string, rec *Recording, logf func(format string, args ...any)) *sandbox {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	return &sandbox{mode: mode, logf: logf, rec: rec, start: time.Now(), killed: make(map[int]bool), nextPid: sandboxPidBase}
+}
+
+// SandboxMode returns ModeDryRun or ModeSimulation, or "" when processes
+// are real.
+func SandboxMode() string {
+	s := sandboxed.Load()
+	if s == nil {
+		return ""
+	}
+	return s.mode
+}
+
+// SandboxActions returns the recent kills and starts of the sandbox,
+// oldest first.
+func SandboxActions() []string {
+	s := sandboxed.Load()
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.actions)
+}
+
+// SandboxNote logs an action the sandbox did not take and keeps it for
+// SandboxActions. It does nothing outside a sandbox.
+func SandboxNote(format string, args ...any) {
+	if s := sandboxed.Load(); s != nil {
+		s.note(format, args...)
+	}
+}
+
+// Spawn adds a process started in the sandbox to its table and returns
+// its PID. what describes the start for the log.
+func Spawn(name, cmdline, cwd, what string) int {
+	s := sandboxed.Load()
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	s.nextPid++
+	pid := s.nextPid
+	s.spawned = append(s.spawned, Proc{Pid: pid, Name: name, Cmdline: cmdline, Cwd: cwd, Created: time.Now().UnixMilli()})
+	s.mu.Unlock()
+	s.note("would start %s as PID %d", what, pid)
+	return pid
+}
+
+func (s *sandbox) note(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	s.mu.Lock()
+	s.actions = append(s.actions, time.Now().Format("15:04:05")+" "+msg)
+	if n := len(s.actions); n > sandboxActions {
+		s.actions = slices.Clone(s.actions[n-sandboxActions:])
+	}
+	s.mu.Unlock()
+	s.logf("%s: %s", s.mode, msg)
+}
+
+// table returns the process table the checks see now.
+func (s *sandbox) table() ([]Proc, error) {
+	var base []Proc
+	if s.rec == nil {
+		var err error
+		if base, err = Record(); err != nil {
+			return nil, err
+		}
+	} else {
+		f := s.rec.frameAt(time.Since(s.start))
+		base = make([]Proc, len(f.Processes))
+		for i, p := range f.Processes {
+			if p.Created > 0 {
+				p.Created += s.shift
+			}
+			base[i] = p
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Proc, 0, len(base)+len(s.spawned))
+	for _, p := range append(base, s.spawned...) {
+		if !s.killed[p.Pid] {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// find returns the processes of the table for which match is true.
+func (s *sandbox) find(match func(Proc) bool) ([]Proc, error) {
+	table, err := s.table()
+	if err != nil {
+		return nil, err
+	}
+	var out []Proc
+	for _, p := range table {
+		if match(p) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// kill hides pid from the table.
+func (s *sandbox) kill(pid int) error {
+	found, err := s.find(func(p Proc) bool { return p.Pid == pid })
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("process %d does not exist", pid)
+	}
+	s.mu.Lock()
+	s.killed[pid] = true
+	s.mu.Unlock()
+	s.note("would kill PID %d %s", pid, found[0].Describe())
+	return nil
+}
+
+// byCmdline returns the processes matched by the check of name, args and
+// exclude, see ByNameAndCmdlineArgsExactWithExclude.
+func (s *sandbox) byCmdline(name, args, exclude string) ([]Proc, error) {
+	matched, _, err := s.explain(name, args, exclude)
+	return matched, err
+}
+
+// explain splits the processes found by name and args into the ones
+// matched and the ones excluded. An empty args matches on the name only.
+func (s *sandbox) explain(name, args, exclude string) (matched, excluded []Proc, err error) {
+	table, err := s.table()
+	if err != nil {
+		return nil, nil, err
+	}
+	matched, excluded = explainTable(table, name, args, exclude)
+	return matched, excluded, nil
+}
+
+func explainTable(table []Proc, name, args, exclude string) (matched, excluded []Proc) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if strings.TrimSpace(args) == "" {
+		for _, p := range table {
+			if sameProcessName(p.Name, name) {
+				matched = append(matched, p)
+			}
+		}
+		return matched, nil
+	}
+	needle := parseCmdlineTokens(strings.TrimSpace(args))
+	if len(needle) == 0 {
+		return nil, nil
+	}
+	excludeGroups := parsePatternGroups(exclude)
+	for _, p := range table {
+		if name != "" && !sameProcessName(p.Name, name) {
+			continue
+		}
+		tokens := append(parseCmdlineTokens(p.Cmdline), parseCmdlineTokens(p.Cwd)...)
+		if !containsTokenSequence(tokens, needle) {
+			continue
+		}
+		if matchesAnyPatternGroup(tokens, excludeGroups) {
+			excluded = append(excluded, p)
+			continue
+		}
+		matched = append(matched, p)
+	}
+	return matched, excluded
+}
+
+// Explain splits the processes a check finds by name and by args, the
+// checkCmdline of a process, into the ones it matches and the ones left
+// out by exclude. An empty args matches on the name only. The table is
+// the one of the sandbox, if any.
+func Explain(name, args, exclude string) (matched, excluded []Proc, err error) {
+	if s := sandboxed.Load(); s != nil {
+		return s.explain(name, args, exclude)
+	}
+	table, err := Record()
+	if err != nil {
+		return nil, nil, err
+	}
+	matched, excluded = explainTable(table, name, args, exclude)
+	return matched, excluded, nil
+}
+
+// Describe returns the name and command line of p for logs.
+func (p Proc) Describe() string {
+	if p.Cmdline == "" {
+		return p.Name
+	}
+	return fmt.Sprintf("%s (%s)", p.Name, p.Cmdline)
+}
+
+// newest returns the PID of the process started last.
+func newest(procs []Proc) int {
+	best := procs[0]
+	for _, p := range procs[1:] {
+		if p.Created >= best.Created {
+			best = p
+		}
+	}
+	return best.Pid
+}
+
+func pids(procs []Proc) []int {
+	out := make([]int, 0, len(procs))
+	for _, p := range procs {
+		out = append(out, p.Pid)
+	}
+	return out
+}
+
+// Record returns the live process table. Processes whose name can not be
+// read are left out.
+func Record() ([]Proc, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Proc, 0, len(processes))
+	for _, p := range processes {
+		name, err := p.Name()
+		if err != nil || name == "" {
+			continue
+		}
+		rp := Proc{Pid: int(p.Pid), Name: name}
+		rp.Cmdline, _ = p.Cmdline()
+		rp.Cwd, _ = p.Cwd()
+		rp.Created, _ = p.CreateTime()
+		out = append(out, rp)
+	}
+	return out, nil
+}
+
+// frameAt returns the frame in effect at d since the start of the
+// recording. The first frame holds until the second one is due.
+func (r *Recording) frameAt(d time.Duration) *Frame {
+	f := &r.Frames[0]
+	for i := 1; i < len(r.Frames); i++ {
+		if r.Frames[i].At > d.Milliseconds() {
+			break
+		}
+		f = &r.Frames[i]
+	}
+	return f
+}
+
+// LoadRecording reads a recording written by Recording.Save. A file that
+// holds only a list of processes is a recording of one frame.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		var procs []Proc
+		if json.Unmarshal(data, &procs) != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		rec.Frames = []Frame{{Processes: procs}}
+	}
+	if len(rec.Frames) == 0 {
+		return nil, fmt.Errorf("%s: no frames", path)
+	}
+	slices.SortStableFunc(rec.Frames, func(a, b Frame) int { return cmp.Compare(a.At, b.At) })
+	return &rec, nil
+}
+
+// Save writes the recording to path as JSON.
+func (r *Recording) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
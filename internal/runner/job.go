@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/process"
+)
+
+// MaxJobOutput is how much of a job's output is kept, from the end.
+const MaxJobOutput = 64 << 10
+
+// JobResult is the outcome of one job run.
+type JobResult struct {
+	ExitCode int    // -1 when the job did not start or was killed
+	Output   string // stdout and stderr interleaved, the last MaxJobOutput bytes
+	TimedOut bool
+	Err      error // nil only for exit code 0
+}
+
+// RunJob runs the command of a type=job item in its path and waits for it.
+// started receives the PID once the command runs. When ctx is done or
+// item.Timeout passes, the command is killed with its child processes.
+// In a dry run or simulation the job is only logged.
+func RunJob(ctx context.Context, item *config.ProcessItem, started func(pid int)) JobResult {
+	if process.SandboxMode() != "" {
+		return runJobSandboxed(item)
+	}
+	if d := item.Timeout.Duration; d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	cmd := jobCommand(ctx, item.Command)
+	cmd.Dir = item.Path
+	hideWindow(cmd)
+	out := &tailBuffer{max: MaxJobOutput}
+	cmd.Stdout, cmd.Stderr = out, out
+	cmd.Cancel = func() error { return process.KillPid(cmd.Process.Pid) }
+	// Children that keep the output pipe open must not hold up the result.
+	cmd.WaitDelay = 5 * time.Second
+
+	if err := cmd.Start(); err != nil {
+		return JobResult{ExitCode: -1, Err: err}
+	}
+	started(cmd.Process.Pid)
+	err := cmd.Wait()
+
+	res := JobResult{ExitCode: -1, Output: out.String()}
+	if cmd.ProcessState != nil {
+		res.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.TimedOut = true
+		res.Err = fmt.Errorf("timed out after %s", item.Timeout.Duration)
+	case ctx.Err() != nil:
+		res.Err = errors.New("cancelled")
+	case errors.As(err, &exitErr) && res.ExitCode >= 0:
+		res.Err = fmt.Errorf("exit code %d", res.ExitCode)
+	case err != nil:
+		res.Err = err
+	}
+	return res
+}
+
+// tailBuffer keeps the last max bytes written to it. exec.Cmd writes to a
+// shared Stdout and Stderr from one goroutine at a time.
+type tailBuffer struct {
+	buf []byte
+	max int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
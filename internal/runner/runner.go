@@ -9,10 +9,24 @@ import (
 
 	"goRunFiles/internal/config"
 	"goRunFiles/internal/display"
+	"goRunFiles/internal/process"
 )
 
-// Start launches the process described by item and returns a PID for cmd tasks.
-func Start(item *config.ProcessItem, launchInNewConsole bool) (int, error) {
+// Exit reports the end of a process launched by Start.
+type Exit struct {
+	Pid int
+	Err error // as returned by exec.Cmd.Wait
+}
+
+// Start launches the process described by item and returns a PID for cmd
+// tasks. onExit, if not nil, is called from another goroutine when the
+// launched process ends. It is not called for launches in a new console,
+// where the process waited for is only the starter. In a dry run or
+// simulation nothing is launched, see startSandboxed.
+func Start(item *config.ProcessItem, launchInNewConsole bool, onExit func(Exit)) (int, error) {
+	if process.SandboxMode() != "" {
+		return startSandboxed(item)
+	}
 	processPath := filepath.Join(item.Path, item.Process)
 	switch item.Type {
 	case config.TypeExe:
@@ -23,8 +37,8 @@ func Start(item *config.ProcessItem, launchInNewConsole bool) (int, error) {
 			return 0, err
 		}
 
-		args := splitArgs(item.Args)
-		args = injectWindowPosition(args, item.Screen, processPath)
+		args := config.SplitArgs(item.Args)
+		args = injectWindowPosition(args, item.Screen.N, processPath)
 		cmd := exec.Command(processPath, args...)
 		cmd.Dir = filepath.Dir(processPath)
 		hideWindow(cmd)
@@ -32,8 +46,8 @@ func Start(item *config.ProcessItem, launchInNewConsole bool) (int, error) {
 		if err := cmd.Start(); err != nil {
 			return 0, err
 		}
-		go cmd.Wait()
-		moveWindowAsync(cmd.Process.Pid, item.Screen)
+		go wait(cmd, onExit)
+		moveWindowAsync(cmd.Process.Pid, item.Screen.N)
 		return cmd.Process.Pid, nil
 	case config.TypeCmd:
 		var cmd *exec.Cmd
@@ -48,8 +62,11 @@ func Start(item *config.ProcessItem, launchInNewConsole bool) (int, error) {
 		if err := cmd.Start(); err != nil {
 			return 0, err
 		}
-		go cmd.Wait()
-		moveWindowAsync(cmd.Process.Pid, item.Screen)
+		if launchInNewConsole {
+			onExit = nil
+		}
+		go wait(cmd, onExit)
+		moveWindowAsync(cmd.Process.Pid, item.Screen.N)
 		return cmd.Process.Pid, nil
 	case config.TypeBat:
 		if item.Process == "" {
@@ -61,7 +78,7 @@ func Start(item *config.ProcessItem, launchInNewConsole bool) (int, error) {
 			}
 			return 0, err
 		}
-		args := splitArgs(item.Args)
+		args := config.SplitArgs(item.Args)
 		var cmd *exec.Cmd
 		if launchInNewConsole {
 			callArgs := append([]string{"/C", "start", "", "cmd.exe", "/C", "call", processPath}, args...)
@@ -76,14 +93,25 @@ func Start(item *config.ProcessItem, launchInNewConsole bool) (int, error) {
 		if err := cmd.Start(); err != nil {
 			return 0, err
 		}
-		go cmd.Wait()
-		moveWindowAsync(cmd.Process.Pid, item.Screen)
+		if launchInNewConsole {
+			onExit = nil
+		}
+		go wait(cmd, onExit)
+		moveWindowAsync(cmd.Process.Pid, item.Screen.N)
 		return cmd.Process.Pid, nil
 	default:
 		return 0, fmt.Errorf("unknown process type %q", item.Type)
 	}
 }
 
+// wait reaps cmd and reports its end to onExit, if set.
+func wait(cmd *exec.Cmd, onExit func(Exit)) {
+	err := cmd.Wait()
+	if onExit != nil {
+		onExit(Exit{Pid: cmd.Process.Pid, Err: err})
+	}
+}
+
 func moveWindowAsync(pid int, screen int) {
 	if pid <= 0 || screen <= 0 {
 		return
@@ -129,42 +157,3 @@ func injectWindowPosition(args []string, screen int, processPath string) []strin
 	}
 	return out
 }
-
-func splitArgs(raw string) []string {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return nil
-	}
-	var args []string
-	var current strings.Builder
-	inQuote := false
-	quoteChar := byte(0)
-	for i := 0; i < len(raw); i++ {
-		ch := raw[i]
-		if inQuote {
-			if ch == quoteChar {
-				inQuote = false
-				continue
-			}
-			current.WriteByte(ch)
-		} else {
-			if ch == '"' || ch == '\'' {
-				inQuote = true
-				quoteChar = ch
-				continue
-			}
-			if ch == ' ' || ch == '\t' {
-				if current.Len() > 0 {
-					args = append(args, current.String())
-					current.Reset()
-				}
-				continue
-			}
-			current.WriteByte(ch)
-		}
-	}
-	if current.Len() > 0 {
-		args = append(args, current.String())
-	}
-	return args
-}
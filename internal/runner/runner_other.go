@@ -2,6 +2,14 @@
 
 package runner
 
-import "os/exec"
+import (
+	"context"
+	"os/exec"
+)
 
 func hideWindow(cmd *exec.Cmd) {}
+
+// jobCommand runs a job command line through sh, for development builds.
+func jobCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -3,6 +3,7 @@
 package runner
 
 import (
+	"context"
 	"os/exec"
 	"syscall"
 )
@@ -13,3 +14,8 @@ func hideWindow(cmd *exec.Cmd) {
 	}
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
 }
+
+// jobCommand runs a job command line through cmd.exe.
+func jobCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd.exe", "/C", command)
+}
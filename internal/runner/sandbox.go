@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goRunFiles/internal/config"
+	"goRunFiles/internal/process"
+)
+
+// startSandboxed stands in for Start in a dry run or simulation. Nothing
+// is launched: the process is added to the sandbox table as the checks of
+// the item look for it, named after checkProcess and with checkCmdline on
+// its command line, so a start that works in the field also works here.
+func startSandboxed(item *config.ProcessItem) (int, error) {
+	processPath := filepath.Join(item.Path, item.Process)
+	name, cmdline, cwd := "cmd.exe", "", item.Path
+	switch item.Type {
+	case config.TypeExe, config.TypeBat:
+		if item.Type == config.TypeBat && item.Process == "" {
+			return 0, fmt.Errorf("bat process is empty")
+		}
+		// Recorded tables come from another machine, paths only exist in a
+		// dry run.
+		if process.SandboxMode() == process.ModeDryRun {
+			if _, err := os.Stat(processPath); os.IsNotExist(err) {
+				return 0, fmt.Errorf("file %s does not exist", processPath)
+			}
+		}
+		cmdline = strings.TrimSpace(processPath + " " + item.Args)
+		if item.Type == config.TypeExe {
+			name = item.Process
+		} else {
+			cmdline = "cmd.exe /C call " + cmdline
+		}
+		cwd = filepath.Dir(processPath)
+	case config.TypeCmd:
+		cmdline = "cmd.exe /C " + item.Command
+	default:
+		return 0, fmt.Errorf("unknown process type %q", item.Type)
+	}
+	for _, n := range strings.Split(item.CheckProcess, ",") {
+		if n = strings.Trim(strings.TrimSpace(n), `"'`); n != "" {
+			name = n
+			break
+		}
+	}
+	if check := strings.TrimSpace(item.CheckCmdline); check != "" {
+		cmdline += " " + check
+	}
+	return process.Spawn(name, cmdline, cwd, fmt.Sprintf("%s [%s]", name, cmdline)), nil
+}
+
+// runJobSandboxed stands in for RunJob in a dry run or simulation: the job
+// is logged and succeeds at once.
+func runJobSandboxed(item *config.ProcessItem) JobResult {
+	process.SandboxNote("would run job %s in %s", item.Command, item.Path)
+	return JobResult{ExitCode: 0, Output: "(" + process.SandboxMode() + ": not run)"}
+}
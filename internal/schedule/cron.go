@@ -0,0 +1,309 @@
+// Package schedule parses cron expressions and weekly run windows and finds
+// their next times.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Time zones must resolve on Windows PCs without a zoneinfo database.
+	_ "time/tzdata"
+)
+
+// Cron is a parsed cron expression. It accepts five fields (minute, hour,
+// day of month, month, day of week) or six with seconds first, and the
+// macros @yearly, @monthly, @weekly, @daily, @midnight and @hourly.
+// Fields take *, ?, lists, ranges, steps and the names JAN-DEC and SUN-SAT;
+// 7 is also Sunday. When both day fields are restricted either may match,
+// as in classic cron.
+type Cron struct {
+	expr                            string
+	sec, min, hour, dom, month, dow uint64
+	domAny, dowAny, hourAny         bool
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+type bounds struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondBounds = bounds{name: "second", min: 0, max: 59}
+	minuteBounds = bounds{name: "minute", min: 0, max: 59}
+	hourBounds   = bounds{name: "hour", min: 0, max: 23}
+	domBounds    = bounds{name: "day of month", min: 1, max: 31}
+	monthBounds  = bounds{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Parse parses a cron expression.
+func Parse(expr string) (*Cron, error) {
+	s := strings.TrimSpace(expr)
+	if s == "" {
+		return nil, fmt.Errorf("empty cron expression")
+	}
+	if strings.HasPrefix(s, "@") {
+		m, ok := macros[strings.ToLower(s)]
+		if !ok {
+			return nil, fmt.Errorf("unknown macro %q", s)
+		}
+		s = m
+	}
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("expected 5 or 6 fields, got %d", len(fields))
+	}
+	c := &Cron{expr: strings.TrimSpace(expr)}
+	var err error
+	for i, dst := range []struct {
+		bits *uint64
+		b    bounds
+	}{
+		{&c.sec, secondBounds},
+		{&c.min, minuteBounds},
+		{&c.hour, hourBounds},
+		{&c.dom, domBounds},
+		{&c.month, monthBounds},
+		{&c.dow, dowBounds},
+	} {
+		if *dst.bits, err = parseField(fields[i], dst.b); err != nil {
+			return nil, err
+		}
+	}
+	// Sunday may be written as 7.
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	c.domAny = isAny(fields[3])
+	c.dowAny = isAny(fields[5])
+	c.hourAny = c.hour == 1<<24-1
+	return c, nil
+}
+
+// String returns the expression as written.
+func (c *Cron) String() string {
+	return c.expr
+}
+
+func isAny(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		r, step, hasStep := strings.Cut(part, "/")
+		lo, hi := b.min, b.max
+		switch {
+		case isAny(r):
+			if b.name == "day of week" {
+				hi = 6
+			}
+		default:
+			from, to, isRange := strings.Cut(r, "-")
+			var err error
+			if lo, err = parseValue(from, b); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(to, b); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = b.max
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: range %q is reversed", b.name, r)
+			}
+		}
+		n := 1
+		if hasStep {
+			var err error
+			if n, err = strconv.Atoi(step); err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", b.name, step)
+			}
+		}
+		for v := lo; v <= hi; v += n {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", b.name, s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("%s: %d out of range %d-%d", b.name, v, b.min, b.max)
+	}
+	return v, nil
+}
+
+// Next returns the first run time after t, in t's location, or the zero
+// time when there is none within five years (e.g. "0 0 30 2 *").
+// Expressions with a fixed hour run once across daylight saving changes,
+// as in classic cron: a time the clocks skip runs at the change and a
+// time they repeat runs the first time only. Hourly ones follow the clock.
+func (c *Cron) Next(t time.Time) time.Time {
+	for {
+		n := c.next(t)
+		if n.IsZero() || c.hourAny {
+			return n
+		}
+		if s := c.skipped(t, n); !s.IsZero() {
+			return s
+		}
+		if !repeated(n) {
+			return n
+		}
+		t = n
+	}
+}
+
+// skipped returns the first clock change after t and up to n that skipped
+// a time c runs at, or the zero time.
+func (c *Cron) skipped(t, n time.Time) time.Time {
+	var first time.Time
+	for z := n; ; {
+		start, _ := z.ZoneBounds()
+		if start.IsZero() || !start.After(t) {
+			return first
+		}
+		_, before := start.Add(-time.Nanosecond).Zone()
+		_, after := start.Zone()
+		if gap := time.Duration(after-before) * time.Second; gap > 0 {
+			// The skipped clock times, as UTC so that they all exist.
+			from := start.Add(time.Duration(before) * time.Second).UTC()
+			if m := c.next(from.Add(-time.Second)); !m.IsZero() && m.Before(from.Add(gap)) {
+				first = start
+			}
+		}
+		z = start.Add(-time.Nanosecond)
+	}
+}
+
+// repeated reports whether the clock time of t already came once, before
+// the clocks went back.
+func repeated(t time.Time) bool {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return false
+	}
+	_, before := start.Add(-time.Nanosecond).Zone()
+	_, off := t.Zone()
+	back := time.Duration(before-off) * time.Second
+	return back > 0 && t.Sub(start) < back
+}
+
+func (c *Cron) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	limit := t.Year() + 5
+	added := false
+
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+	for !has(c.month, int(t.Month())) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !c.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for !has(c.hour, t.Hour()) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for !has(c.min, t.Minute()) {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for !has(c.sec, t.Second()) {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// Last returns the latest run time in [from, to), or the zero time.
+func (c *Cron) Last(from, to time.Time) time.Time {
+	var last time.Time
+	for t := c.Next(from.Add(-time.Nanosecond)); !t.IsZero() && t.Before(to); t = c.Next(t) {
+		last = t
+	}
+	return last
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := has(c.dom, t.Day())
+	dow := has(c.dow, int(t.Weekday()))
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"0 30 4 * * *",
+		"*/15 9-17 * * MON-FRI",
+		"0 0 1,15 jan,jul ?",
+		"0 0 * * 7",
+		"@daily",
+		"@Hourly",
+	}
+	for _, expr := range valid {
+		if _, err := Parse(expr); err != nil {
+			t.Errorf("Parse(%q): %v", expr, err)
+		}
+	}
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"x * * * *",
+		"@often",
+	}
+	for _, expr := range invalid {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) accepted an invalid expression", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(loc *time.Location, y int, mo time.Month, d, h, mi int) time.Time {
+		return time.Date(y, mo, d, h, mi, 0, 0, loc)
+	}
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			name: "31st skips short months",
+			expr: "0 0 31 * *",
+			from: at(time.UTC, 2026, 1, 31, 0, 0),
+			want: []time.Time{at(time.UTC, 2026, 3, 31, 0, 0), at(time.UTC, 2026, 5, 31, 0, 0)},
+		},
+		{
+			name: "29 February waits for a leap year",
+			expr: "0 12 29 2 *",
+			from: at(time.UTC, 2026, 3, 1, 0, 0),
+			want: []time.Time{at(time.UTC, 2028, 2, 29, 12, 0)},
+		},
+		{
+			name: "first of the month after the last day",
+			expr: "0 0 1 * *",
+			from: at(time.UTC, 2026, 2, 28, 23, 59),
+			want: []time.Time{at(time.UTC, 2026, 3, 1, 0, 0), at(time.UTC, 2026, 4, 1, 0, 0)},
+		},
+		{
+			name: "new year",
+			expr: "*/20 * * * *",
+			from: at(time.UTC, 2026, 12, 31, 23, 45),
+			want: []time.Time{at(time.UTC, 2027, 1, 1, 0, 0), at(time.UTC, 2027, 1, 1, 0, 20)},
+		},
+		{
+			name: "either day field",
+			expr: "0 0 15 * FRI",
+			from: at(time.UTC, 2026, 11, 10, 0, 0),
+			want: []time.Time{at(time.UTC, 2026, 11, 13, 0, 0), at(time.UTC, 2026, 11, 15, 0, 0), at(time.UTC, 2026, 11, 20, 0, 0)},
+		},
+		{
+			name: "never",
+			expr: "0 0 30 2 *",
+			from: at(time.UTC, 2026, 1, 1, 0, 0),
+			want: []time.Time{{}},
+		},
+		{
+			name: "skipped time runs at the change",
+			expr: "30 2 * * *",
+			from: at(berlin, 2026, 3, 28, 3, 0),
+			want: []time.Time{at(berlin, 2026, 3, 29, 3, 0), at(berlin, 2026, 3, 30, 2, 30)},
+		},
+		{
+			name: "repeated time runs once",
+			expr: "30 2 * * *",
+			from: at(berlin, 2026, 10, 24, 3, 0),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC).In(berlin),
+				at(berlin, 2026, 10, 26, 2, 30),
+			},
+		},
+		{
+			name: "hourly follows the clock forward",
+			expr: "0 * * * *",
+			from: at(berlin, 2026, 3, 29, 1, 30),
+			want: []time.Time{at(berlin, 2026, 3, 29, 3, 0), at(berlin, 2026, 3, 29, 4, 0)},
+		},
+		{
+			name: "hourly follows the clock back",
+			expr: "0 * * * *",
+			from: at(berlin, 2026, 10, 25, 1, 30),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC).In(berlin),
+				time.Date(2026, 10, 25, 1, 0, 0, 0, time.UTC).In(berlin),
+				at(berlin, 2026, 10, 25, 3, 0),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from := tt.from
+			for i, want := range tt.want {
+				got := c.Next(from)
+				if !got.Equal(want) {
+					t.Fatalf("run %d after %s: got %s, want %s", i+1, from, got, want)
+				}
+				if !got.IsZero() && got.Location() != tt.from.Location() {
+					t.Errorf("run %d in %s, want %s", i+1, got.Location(), tt.from.Location())
+				}
+				from = got
+			}
+		})
+	}
+}
+
+func TestCronLast(t *testing.T) {
+	c, err := Parse("0 4 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 5, 1, 4, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 5, 3, 4, 0, 0, 0, time.UTC)
+	if got, want := c.Last(from, to), time.Date(2026, 5, 2, 4, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Last = %s, want %s", got, want)
+	}
+	if got := c.Last(from.Add(time.Second), from.Add(time.Hour)); !got.IsZero() {
+		t.Errorf("Last with no run = %s", got)
+	}
+}
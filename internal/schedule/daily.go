@@ -0,0 +1,58 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Times is a list of daily wall-clock times such as "04:30, 16:00".
+type Times struct {
+	text string
+	mins []int // minutes since midnight, sorted
+}
+
+// ParseTimes parses comma-separated HH:MM times.
+func ParseTimes(text string) (*Times, error) {
+	t := &Times{text: strings.TrimSpace(text)}
+	for _, part := range strings.Split(text, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		m, err := parseClock(part)
+		if err != nil {
+			return nil, err
+		}
+		if m == minutesPerDay {
+			m = 0
+		}
+		t.mins = append(t.mins, m)
+	}
+	if len(t.mins) == 0 {
+		return nil, fmt.Errorf("no times")
+	}
+	sort.Ints(t.mins)
+	return t, nil
+}
+
+// String returns the times as written.
+func (t *Times) String() string {
+	return t.text
+}
+
+// Next returns the first of the times after after. A time skipped by a
+// daylight saving change is taken on the next day.
+func (t *Times) Next(after time.Time) time.Time {
+	day := midnight(after)
+	for i := 0; i <= 2; i++ {
+		d := day.AddDate(0, 0, i)
+		for _, m := range t.mins {
+			c := time.Date(d.Year(), d.Month(), d.Day(), m/60, m%60, 0, 0, d.Location())
+			if c.After(after) && c.Hour()*60+c.Minute() == m {
+				return c
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,204 @@
+package schedule
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Holidays is a set of days off keyed by "2006-01-02", or by "01-02" for
+// days that repeat every year.
+type Holidays map[string]bool
+
+// Has reports whether the day of t is a holiday.
+func (h Holidays) Has(t time.Time) bool {
+	return h[t.Format("2006-01-02")] || h[t.Format("01-02")]
+}
+
+// maxHolidaySpan bounds date ranges so a typo in a year cannot produce
+// millions of entries.
+const maxHolidaySpan = 366
+
+// LoadHolidays reads holidays from an iCalendar file (.ics) or a date
+// list. A date list has one entry per line: 2026-01-07, a range
+// 2026-01-01..2026-01-08, or 01-01 and 12-31..01-08 for every year. Text
+// after the date and lines starting with # or ; are ignored. From .ics
+// files the all-day and timed VEVENT dates are taken; RRULE:FREQ=YEARLY
+// makes an event repeat every year, other rules are ignored.
+func LoadHolidays(path string) (Holidays, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		return parseICS(data)
+	}
+	return parseDateList(data)
+}
+
+func parseDateList(data []byte) (Holidays, error) {
+	h := make(Holidays)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+		from, to, isRange := strings.Cut(fields[0], "..")
+		if !isRange {
+			to = from
+		}
+		if err := h.addRange(from, to); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return h, sc.Err()
+}
+
+// addRange adds the days from..to inclusive, both as YYYY-MM-DD or both as
+// MM-DD.
+func (h Holidays) addRange(from, to string) error {
+	yearly := len(from) == len("01-02")
+	parse := func(s string) (time.Time, error) {
+		if yearly {
+			// A leap year, so 02-29 is accepted.
+			s = "2000-" + s
+		}
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", strings.TrimPrefix(s, "2000-"))
+		}
+		return t, nil
+	}
+	start, err := parse(from)
+	if err != nil {
+		return err
+	}
+	end, err := parse(to)
+	if err != nil {
+		return err
+	}
+	if end.Before(start) {
+		if !yearly {
+			return fmt.Errorf("range %s..%s ends before it starts", from, to)
+		}
+		end = end.AddDate(1, 0, 0)
+	}
+	if end.Sub(start) > maxHolidaySpan*24*time.Hour {
+		return fmt.Errorf("range %s..%s is longer than a year", from, to)
+	}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if yearly {
+			h[d.Format("01-02")] = true
+		} else {
+			h[d.Format("2006-01-02")] = true
+		}
+	}
+	return nil
+}
+
+// parseICS collects the days covered by the VEVENTs of an iCalendar file.
+func parseICS(data []byte) (Holidays, error) {
+	h := make(Holidays)
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		// Long lines are folded onto lines starting with a space or tab.
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	var start, end time.Time
+	var endExclusive, yearly, inEvent bool
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		prop, _, _ := strings.Cut(strings.ToUpper(name), ";")
+		switch prop {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent = true
+				start, end, endExclusive, yearly = time.Time{}, time.Time{}, false, false
+			}
+		case "DTSTART", "DTEND":
+			if !inEvent {
+				continue
+			}
+			t, dateOnly, err := parseICSTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", prop, err)
+			}
+			if prop == "DTSTART" {
+				start = t
+			} else {
+				// An all-day DTEND is the day after the event; a timed one
+				// ending at midnight does not cover that day either.
+				end = t
+				endExclusive = dateOnly || t.Equal(midnight(t))
+			}
+		case "RRULE":
+			if inEvent && strings.Contains(strings.ToUpper(value), "FREQ=YEARLY") {
+				yearly = true
+			}
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+			if start.IsZero() {
+				continue
+			}
+			last := midnight(start)
+			if !end.IsZero() {
+				last = midnight(end)
+				if endExclusive {
+					last = last.AddDate(0, 0, -1)
+				}
+				if last.Before(start) {
+					last = midnight(start)
+				}
+			}
+			layout := "2006-01-02"
+			if yearly {
+				layout = "01-02"
+			}
+			if err := h.addRange(start.Format(layout), last.Format(layout)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return h, nil
+}
+
+// parseICSTime parses a DATE or DATE-TIME value. UTC times are converted
+// to local time; times with a TZID are taken as local wall time.
+func parseICSTime(value string) (t time.Time, dateOnly bool, err error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+		t = t.Local()
+	case strings.Contains(value, "T"):
+		t, err = time.ParseInLocation("20060102T150405", value, time.Local)
+	default:
+		t, err = time.ParseInLocation("20060102", value, time.Local)
+		dateOnly = true
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+	}
+	return t, dateOnly, nil
+}
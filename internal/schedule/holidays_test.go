@@ -0,0 +1,107 @@
+package schedule
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseDateList(t *testing.T) {
+	tests := []struct {
+		name string
+		list string
+		want []string
+	}{
+		{"single days", "# closed\n2026-01-07\n; also\n05-01 Labour Day\n", []string{"05-01", "2026-01-07"}},
+		{"range", "2026-12-30..2027-01-02\n", []string{"2026-12-30", "2026-12-31", "2027-01-01", "2027-01-02"}},
+		{"yearly range", "04-30..05-02\n", []string{"04-30", "05-01", "05-02"}},
+		{"yearly range across new year", "12-31..01-03\n", []string{"01-01", "01-02", "01-03", "12-31"}},
+		{"leap day", "02-29\n", []string{"02-29"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := parseDateList([]byte(tt.list))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for k := range h {
+				got = append(got, k)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateListErrors(t *testing.T) {
+	for _, list := range []string{
+		"2026-01-08..2026-01-01\n",
+		"2026-02-30\n",
+		"01-32\n",
+		"2026-01-01..2028-01-01\n",
+		"2026-01-01..01-08\n",
+	} {
+		if _, err := parseDateList([]byte(list)); err == nil {
+			t.Errorf("parseDateList(%q) accepted an invalid list", list)
+		}
+	}
+}
+
+func TestHolidaysHas(t *testing.T) {
+	h, err := parseDateList([]byte("12-31..01-08\n2026-04-03\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC), true},
+		{time.Date(2027, 1, 8, 12, 0, 0, 0, time.UTC), true},
+		{time.Date(2027, 1, 9, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2030, 1, 5, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2026, 4, 3, 9, 0, 0, 0, time.UTC), true},
+		{time.Date(2027, 4, 3, 9, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := h.Has(tt.at); got != tt.want {
+			t.Errorf("Has(%s) = %v, want %v", tt.at.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestParseICS(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Christmas\r\n" +
+		"DTSTART;VALUE=DATE:20261224\r\n" +
+		"DTEND;VALUE=DATE:20261227\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Labour\r\n" +
+		" Day\r\n" +
+		"DTSTART;VALUE=DATE:20260501\r\n" +
+		"RRULE:FREQ=YEARLY\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART:20260610T090000\r\n" +
+		"DTEND:20260611T000000\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	h, err := parseICS([]byte(ics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for k := range h {
+		got = append(got, k)
+	}
+	slices.Sort(got)
+	want := []string{"05-01", "2026-06-10", "2026-12-24", "2026-12-25", "2026-12-26"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,211 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window is a set of weekly opening hours, e.g.
+//
+//	Mon-Fri 09:00-21:00; Sat-Sun 10:00-18:00
+//
+// Segments are separated by ";". Each names its days (Mon..Sun, ranges
+// such as Fri-Mon, lists, * or daily; every day when omitted) followed by
+// one or more comma-separated HH:MM-HH:MM ranges. A range that ends before
+// it starts runs past midnight and 24:00 closes at the end of the day. On
+// holidays only segments for the day "Hol" apply, so without one the
+// window stays closed.
+type Window struct {
+	text  string
+	spans []span
+}
+
+// span is one time range on the days it is set for. Days are a bit mask of
+// time.Weekday; holiday spans apply instead of them on holidays.
+type span struct {
+	days     uint8
+	holiday  bool
+	from, to int // minutes since midnight; to <= from runs past midnight
+}
+
+const minutesPerDay = 24 * 60
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWindow parses a run window.
+func ParseWindow(text string) (*Window, error) {
+	w := &Window{text: strings.TrimSpace(text)}
+	for _, seg := range strings.Split(text, ";") {
+		seg = strings.TrimSpace(seg)
+		if seg == "" {
+			continue
+		}
+		fields := strings.Fields(seg)
+		i := 0
+		for i < len(fields) && (fields[i][0] < '0' || fields[i][0] > '9') {
+			i++
+		}
+		days, hol := uint8(0x7f), false
+		if i > 0 {
+			var err error
+			if days, hol, err = parseDays(strings.Join(fields[:i], "")); err != nil {
+				return nil, fmt.Errorf("%q: %w", seg, err)
+			}
+		}
+		times := strings.Join(fields[i:], "")
+		if times == "" {
+			return nil, fmt.Errorf("%q: no time range", seg)
+		}
+		for _, r := range strings.Split(times, ",") {
+			from, to, err := parseTimeRange(strings.TrimSpace(r))
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", seg, err)
+			}
+			w.spans = append(w.spans, span{days: days, holiday: hol, from: from, to: to})
+		}
+	}
+	if len(w.spans) == 0 {
+		return nil, fmt.Errorf("empty run window")
+	}
+	return w, nil
+}
+
+// parseDays parses a day list such as "Mon-Fri", "Sat,Sun" or "Hol".
+func parseDays(s string) (days uint8, holiday bool, err error) {
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		switch part = strings.TrimSpace(part); part {
+		case "":
+			continue
+		case "*", "daily":
+			days |= 0x7f
+			continue
+		case "hol", "holiday", "holidays":
+			holiday = true
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, ok := weekdayNames[lo]
+		if !ok {
+			return 0, false, fmt.Errorf("unknown day %q", lo)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[hi]; !ok {
+				return 0, false, fmt.Errorf("unknown day %q", hi)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days |= 1 << d
+			if d == last {
+				break
+			}
+		}
+	}
+	if days == 0 && !holiday {
+		return 0, false, fmt.Errorf("no days in %q", s)
+	}
+	return days, holiday, nil
+}
+
+func parseTimeRange(s string) (from, to int, err error) {
+	a, b, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected HH:MM-HH:MM, got %q", s)
+	}
+	if from, err = parseClock(a); err != nil {
+		return 0, 0, err
+	}
+	if to, err = parseClock(b); err != nil {
+		return 0, 0, err
+	}
+	if from == minutesPerDay {
+		return 0, 0, fmt.Errorf("range %q starts at 24:00", s)
+	}
+	if from == to {
+		return 0, 0, fmt.Errorf("empty range %q", s)
+	}
+	return from, to, nil
+}
+
+// parseClock parses HH:MM into minutes since midnight; 24:00 is allowed.
+func parseClock(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	hs, ms, ok := strings.Cut(s, ":")
+	h, err1 := strconv.Atoi(hs)
+	m, err2 := strconv.Atoi(ms)
+	if !ok || err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 || h > 24 || h == 24 && m != 0 {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	return h*60 + m, nil
+}
+
+// String returns the window as written.
+func (w *Window) String() string {
+	return w.text
+}
+
+// UsesHolidays reports whether the window has hours for holidays.
+func (w *Window) UsesHolidays() bool {
+	for _, s := range w.spans {
+		if s.holiday {
+			return true
+		}
+	}
+	return false
+}
+
+// Open reports whether t falls within the window.
+func (w *Window) Open(t time.Time, hol Holidays) bool {
+	m := t.Hour()*60 + t.Minute()
+	day := midnight(t)
+	prev := day.AddDate(0, 0, -1)
+	for _, s := range w.spans {
+		if s.appliesOn(day, hol) && m >= s.from && (s.to <= s.from || m < s.to) {
+			return true
+		}
+		if s.to <= s.from && m < s.to && s.appliesOn(prev, hol) {
+			return true
+		}
+	}
+	return false
+}
+
+// appliesOn reports whether the span is set for the given day.
+func (s span) appliesOn(day time.Time, hol Holidays) bool {
+	if hol.Has(day) {
+		return s.holiday
+	}
+	return s.days&(1<<day.Weekday()) != 0
+}
+
+// NextOpen returns when a window closed at t opens, or the zero time if
+// it stays closed for a year. Only midnights and span edges can open it,
+// so those are the candidates checked day by day.
+func (w *Window) NextOpen(t time.Time, hol Holidays) time.Time {
+	candidates := []int{0}
+	for _, s := range w.spans {
+		candidates = append(candidates, s.from, s.to%minutesPerDay)
+	}
+	sort.Ints(candidates)
+	day := midnight(t)
+	for i := 0; i <= 366; i++ {
+		d := day.AddDate(0, 0, i)
+		for _, m := range candidates {
+			c := time.Date(d.Year(), d.Month(), d.Day(), m/60, m%60, 0, 0, d.Location())
+			if c.After(t) && w.Open(c, hol) {
+				return c
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	valid := []string{
+		"09:00-17:00",
+		"daily 08:00-12:00, 13:00-17:00",
+		"Mon-Fri 09:00-21:00; Sat-Sun 10:00-18:00",
+		"Fri-Mon 20:00-04:00",
+		"Sat,Sun 10:00-24:00",
+		"Hol 10:00-14:00",
+	}
+	for _, text := range valid {
+		if _, err := ParseWindow(text); err != nil {
+			t.Errorf("ParseWindow(%q): %v", text, err)
+		}
+	}
+	invalid := []string{
+		"",
+		" ; ",
+		"Mon",
+		"Xyz 09:00-10:00",
+		"09:00",
+		"24:00-01:00",
+		"10:00-10:00",
+		"25:00-26:00",
+		"09:60-10:00",
+		"24:30-01:00",
+	}
+	for _, text := range invalid {
+		if _, err := ParseWindow(text); err == nil {
+			t.Errorf("ParseWindow(%q) accepted an invalid window", text)
+		}
+	}
+}
+
+// 2026-10-16 is a Friday.
+func windowTime(d, h, m int) time.Time {
+	return time.Date(2026, 10, d, h, m, 0, 0, time.UTC)
+}
+
+func TestWindowOpen(t *testing.T) {
+	tests := []struct {
+		name   string
+		window string
+		hol    Holidays
+		at     time.Time
+		want   bool
+	}{
+		{"before a night span", "Fri-Sat 22:00-02:00", nil, windowTime(16, 21, 59), false},
+		{"night span start", "Fri-Sat 22:00-02:00", nil, windowTime(16, 22, 0), true},
+		{"after midnight from Friday", "Fri-Sat 22:00-02:00", nil, windowTime(17, 1, 59), true},
+		{"night span end", "Fri-Sat 22:00-02:00", nil, windowTime(17, 2, 0), false},
+		{"after midnight from Saturday", "Fri-Sat 22:00-02:00", nil, windowTime(18, 1, 0), true},
+		{"Sunday evening", "Fri-Sat 22:00-02:00", nil, windowTime(18, 23, 0), false},
+		{"after midnight from Thursday", "Fri-Sat 22:00-02:00", nil, windowTime(16, 1, 0), false},
+		{"day range across the week end", "Fri-Mon 09:00-10:00", nil, windowTime(18, 9, 30), true},
+		{"24:00 before midnight", "Mon-Fri 09:00-24:00", nil, windowTime(16, 23, 59), true},
+		{"24:00 at midnight", "Mon-Fri 09:00-24:00", nil, windowTime(17, 0, 0), false},
+		{"second range", "daily 08:00-12:00, 13:00-17:00", nil, windowTime(17, 12, 30), false},
+		{"second range open", "daily 08:00-12:00, 13:00-17:00", nil, windowTime(17, 13, 0), true},
+		{"holiday closes weekday hours", "Mon-Fri 09:00-17:00; Hol 10:00-14:00", Holidays{"2026-10-19": true}, windowTime(19, 9, 30), false},
+		{"holiday hours", "Mon-Fri 09:00-17:00; Hol 10:00-14:00", Holidays{"2026-10-19": true}, windowTime(19, 10, 0), true},
+		{"holiday hours end", "Mon-Fri 09:00-17:00; Hol 10:00-14:00", Holidays{"2026-10-19": true}, windowTime(19, 15, 0), false},
+		{"day after a holiday", "Mon-Fri 09:00-17:00; Hol 10:00-14:00", Holidays{"2026-10-19": true}, windowTime(20, 9, 30), true},
+		{"yearly holiday", "Mon-Fri 09:00-17:00", Holidays{"10-16": true}, windowTime(16, 12, 0), false},
+		{"holiday without hours", "daily 09:00-17:00", Holidays{"2026-10-17": true}, windowTime(17, 12, 0), false},
+		{"night span into a holiday", "Fri 22:00-02:00", Holidays{"2026-10-17": true}, windowTime(17, 1, 0), true},
+		{"night span from a holiday", "Fri 22:00-02:00", Holidays{"2026-10-16": true}, windowTime(17, 1, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := ParseWindow(tt.window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Open(tt.at, tt.hol); got != tt.want {
+				t.Errorf("Open(%s) = %v, want %v", tt.at.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowNextOpen(t *testing.T) {
+	tests := []struct {
+		name   string
+		window string
+		hol    Holidays
+		from   time.Time
+		want   time.Time
+	}{
+		{"same night", "Fri-Sat 22:00-02:00", nil, windowTime(17, 3, 0), windowTime(17, 22, 0)},
+		{"next week", "Fri-Sat 22:00-02:00", nil, windowTime(18, 3, 0), windowTime(23, 22, 0)},
+		{"after a 24:00 close", "Mon-Fri 09:00-24:00", nil, windowTime(17, 0, 0), windowTime(19, 9, 0)},
+		{"holiday hours", "Mon-Fri 09:00-17:00; Hol 10:00-14:00", Holidays{"2026-10-19": true}, windowTime(18, 12, 0), windowTime(19, 10, 0)},
+		{"skips a holiday", "Mon-Fri 09:00-17:00", Holidays{"2026-10-19": true}, windowTime(18, 12, 0), windowTime(20, 9, 0)},
+		{"never", "Hol 10:00-12:00", nil, windowTime(18, 12, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := ParseWindow(tt.window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.NextOpen(tt.from, tt.hol); !got.Equal(tt.want) {
+				t.Errorf("NextOpen(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}